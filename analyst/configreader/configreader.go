@@ -7,22 +7,178 @@
 package configreader
 
 import (
+	"encoding/csv"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/demonware/harbor-analytics/analyst/registry"
 
-	yaml "gopkg.in/yaml.v2"
+	yaml "gopkg.in/yaml.v3"
 )
 
-const configFile = "../analyst.yaml"
+/*DefaultConfigFile is the path of the config file read by the analyst.*/
+const DefaultConfigFile = "../analyst.yaml"
 
-/*AnalystConfig is the representation of the analyst.yaml confi file*/
+/*AnalystConfig is the representation of the analyst.yaml confi file.
+The charts items are kept as yaml nodes so that problems in them
+can be reported with their position in the file.
+The top-level charts and sections items form the default report,
+further reports are configured as reports items (see ReportConfig).
+Charts outside of sections precede all sections in a report.
+The include and defaults items are resolved before the file
+is decoded (see resolveIncludes and applyDefaults).*/
 type AnalystConfig struct {
-	Charts []map[string]interface{} `yaml:"charts"`
+	Charts             []yaml.Node              `yaml:"charts"`
+	Sections           []SectionConfig          `yaml:"sections"`
+	Publish            PublishConfig            `yaml:"publish"`
+	Reports            []ReportConfig           `yaml:"reports"`
+	RegistryName       string                   `yaml:"registryName"`
+	AsOf               string                   `yaml:"asOf"`
+	UserClassification UserClassificationConfig `yaml:"userClassification"`
+	TeamMapping        TeamMappingConfig        `yaml:"teamMapping"`
+}
+
+/*UserClassificationConfig is the representation of the
+userClassification item in the analyst.yaml config file.
+See registry.UserClassification for the meaning of the fields.*/
+type UserClassificationConfig struct {
+	AutomationNamePatterns    []string `yaml:"automationNamePatterns"`
+	AutomationMinPushesPerDay int      `yaml:"automationMinPushesPerDay"`
+	AutomationMinHoursOfDay   int      `yaml:"automationMinHoursOfDay"`
+}
+
+/*toUserClassification converts the user classification
+configuration into the type used by the registry.*/
+func (u UserClassificationConfig) toUserClassification() registry.UserClassification {
+	return registry.UserClassification{
+		AutomationNamePatterns:    u.AutomationNamePatterns,
+		AutomationMinPushesPerDay: u.AutomationMinPushesPerDay,
+		AutomationMinHoursOfDay:   u.AutomationMinHoursOfDay,
+	}
+}
+
+/*TeamMappingConfig is the representation of the
+teamMapping item in the analyst.yaml config file.
+Teams can be defined directly in the config file and/or in a separate
+YAML file (of the same structure) or CSV file given as file.
+See registry.TeamMapping for how the teams are applied.*/
+type TeamMappingConfig struct {
+	Teams []TeamConfig `yaml:"teams"`
+	File  string       `yaml:"file"`
+}
+
+/*TeamConfig is the representation of a single team
+in the teamMapping item of the analyst.yaml config file.*/
+type TeamConfig struct {
+	Name               string   `yaml:"name"`
+	Projects           []string `yaml:"projects"`
+	RepositoryPatterns []string `yaml:"repositoryPatterns"`
+	Users              []string `yaml:"users"`
+}
+
+const (
+	teamMappingCSVProjectKind    = "project"
+	teamMappingCSVRepositoryKind = "repository"
+	teamMappingCSVUserKind       = "user"
+)
+
+/*readTeamMappingCSV reads teams from a CSV file in which every row
+consists of a team name, a kind ("project", "repository" or "user")
+and a project name, repository pattern or user name respectively.
+Rows of the same team are merged in the order of their first appearance.*/
+func readTeamMappingCSV(filePath string) ([]TeamConfig, error) {
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var teams []TeamConfig
+	teamIdxPerName := map[string]int{}
+	for rowIdx, row := range rows {
+		if len(row) != 3 {
+			return nil, fmt.Errorf("row %d of %s does not consist of team, kind and value", rowIdx+1, filePath)
+		}
+		teamName, kind, value := strings.TrimSpace(row[0]), strings.TrimSpace(row[1]), strings.TrimSpace(row[2])
+
+		teamIdx, ok := teamIdxPerName[teamName]
+		if !ok {
+			teamIdx = len(teams)
+			teamIdxPerName[teamName] = teamIdx
+			teams = append(teams, TeamConfig{Name: teamName})
+		}
+
+		switch kind {
+		case teamMappingCSVProjectKind:
+			teams[teamIdx].Projects = append(teams[teamIdx].Projects, value)
+		case teamMappingCSVRepositoryKind:
+			teams[teamIdx].RepositoryPatterns = append(teams[teamIdx].RepositoryPatterns, value)
+		case teamMappingCSVUserKind:
+			teams[teamIdx].Users = append(teams[teamIdx].Users, value)
+		default:
+			return nil, fmt.Errorf("kind \"%s\" in row %d of %s is not one of project, repository or user", kind, rowIdx+1, filePath)
+		}
+	}
+
+	return teams, nil
+
+}
+
+/*toTeamMapping converts the team mapping configuration
+into the type used by the registry. Teams defined in the config
+file precede the teams defined in a separate file.
+A relative file path is relative to the given config file path.*/
+func (t TeamMappingConfig) toTeamMapping(configFilePath string) (registry.TeamMapping, error) {
+
+	teams := t.Teams
+	if t.File != "" {
+		filePath := t.File
+		if !filepath.IsAbs(filePath) {
+			filePath = filepath.Join(filepath.Dir(configFilePath), filePath)
+		}
+
+		if strings.HasSuffix(strings.ToLower(filePath), ".csv") {
+			fileTeams, err := readTeamMappingCSV(filePath)
+			if err != nil {
+				return registry.TeamMapping{}, err
+			}
+			teams = append(teams, fileTeams...)
+		} else {
+			yamlFile, err := ioutil.ReadFile(filePath)
+			if err != nil {
+				return registry.TeamMapping{}, err
+			}
+			fileTeamMapping := TeamMappingConfig{}
+			if err := yaml.Unmarshal(yamlFile, &fileTeamMapping); err != nil {
+				return registry.TeamMapping{}, err
+			}
+			teams = append(teams, fileTeamMapping.Teams...)
+		}
+	}
+
+	var teamMapping registry.TeamMapping
+	for _, team := range teams {
+		teamMapping.Teams = append(teamMapping.Teams, registry.Team{
+			Name:               team.Name,
+			Projects:           team.Projects,
+			RepositoryPatterns: team.RepositoryPatterns,
+			Users:              team.Users,
+		})
+	}
+
+	return teamMapping, nil
+
 }
 
 const (
@@ -30,56 +186,122 @@ const (
 	statsMethodNameConfigParameter    = "statsMethodName"
 	timePeriodInDatsConfigParameter   = "timePeriodInDays"
 
-	configPlaceholderStartDate = "startDate"
-	timeFormat                 = "2006-01-02"
+	timeFormat        = "2006-01-02"
+	timeOfDayFormat   = "2006-01-02 15:04"
+	asOfConfigElement = "asOf"
 )
 
-func parseConfigFile() *AnalystConfig {
-	fullConfig := AnalystConfig{}
-	yamlFile, err := ioutil.ReadFile(configFile)
-	if err != nil {
-		log.Fatalf("Failed to access config file: %v", err)
+/*getReferenceDate returns the date a report is created as of.
+asOf is either a date (YYYY-MM-DD), which stands for the beginning of that day,
+or a date and time of day (YYYY-MM-DD HH:MM), both in local time.
+Without asOf, the report is created as of now.*/
+func getReferenceDate(asOf string) (time.Time, error) {
+	if asOf == "" {
+		return time.Now(), nil
+	}
+	for _, format := range []string{timeFormat, timeOfDayFormat} {
+		if referenceDate, err := time.ParseInLocation(format, asOf, time.Local); err == nil {
+			return referenceDate, nil
+		}
 	}
+	return time.Time{}, fmt.Errorf("\"%s\" is neither of the form YYYY-MM-DD nor YYYY-MM-DD HH:MM", asOf)
+}
+
+/*readConfigFile reads the config file at the given path,
+applies its userClassification and teamMapping items to the given registry
+and converts the charts items of its reports into stats methods computed on that registry.
+The given asOf date takes precedence over the asOf item of the config file.
+All problems found in the config file are returned at once.*/
+func readConfigFile(configFilePath string, concreteRegistry *registry.Registry, asOf string) ([]Report, ConfigErrors) {
 
-	err = yaml.Unmarshal(yamlFile, &fullConfig)
+	yamlFile, err := ioutil.ReadFile(configFilePath)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal config file: %v", err)
+		return nil, ConfigErrors{{File: configFilePath, Message: fmt.Sprintf("Failed to access config file: %v", err)}}
 	}
 
-	return &fullConfig
-}
+	var rootNode yaml.Node
+	if err := yaml.Unmarshal(yamlFile, &rootNode); err != nil {
+		return nil, newConfigErrorsFromYAML(configFilePath, err)
+	}
+	if len(rootNode.Content) == 0 {
+		return nil, ConfigErrors{{File: configFilePath, Message: "config file is empty"}}
+	}
 
-/*getStartDateFromPeriod returns the date of
-the current date minus the given time period given in days*/
-func getStartDateFromPeriod(timePeriodInDate int) time.Time {
-	//Go back 0 years, 0 months and <timePeriodInDate> days
-	return time.Now().AddDate(0, 0, -1*timePeriodInDate)
-}
+	//Environment variables, included files and defaults
+	//are resolved before the config is decoded
+	sourceFiles := nodeSourceFiles{}
+	var configErrors ConfigErrors
+	if rootNode.Content[0].Kind == yaml.MappingNode {
+		configErrors = append(configErrors, interpolateEnvironmentVariables(rootNode.Content[0])...)
+		configErrors = append(configErrors, resolveIncludes(rootNode.Content[0], configFilePath, sourceFiles, nil)...)
+		configErrors = append(configErrors, applyDefaults(rootNode.Content[0])...)
+	}
 
-/*formatTemplateString will turn the placeholder strings in a given
-templateString into concrete values and return the new string.*/
-func formatTemplateString(templateString string, startDate time.Time) string {
+	fullConfig := AnalystConfig{}
+	if err := rootNode.Decode(&fullConfig); err != nil {
+		return nil, append(configErrors.withFiles(sourceFiles, configFilePath), newConfigErrorsFromYAML(configFilePath, err)...)
+	}
+
+	newConfigError := func(node *yaml.Node, message string) ConfigError {
+		if node == nil {
+			return ConfigError{Message: message}
+		}
+		return newNodeConfigError(node, message)
+	}
 
-	genericTemplateFormat := "{{ %s }}"
+	concreteRegistry.Name = fullConfig.RegistryName
 
-	var concreteString string
+	//An asOf date given explicitly has no position in the config file
+	var asOfNode *yaml.Node
+	if asOf == "" {
+		asOf = fullConfig.AsOf
+		_, asOfNode = getMappingValue(rootNode.Content[0], asOfConfigElement)
+	}
+	referenceDate, err := getReferenceDate(asOf)
+	if err != nil {
+		configErrors = append(configErrors, newConfigError(asOfNode, fmt.Sprintf("%s is invalid: %v", asOfConfigElement, err)))
+		referenceDate = time.Now()
+	}
+	log.Printf("\nCreate report as of %s", referenceDate)
 
-	//Replace startDate placeholder
-	concreteString = strings.Replace(
-		templateString,
-		fmt.Sprintf(genericTemplateFormat, configPlaceholderStartDate),
-		startDate.Format(timeFormat), -1)
+	userClassificationNode, _ := getMappingValue(rootNode.Content[0], "userClassification")
+	concreteRegistry.UserClassification = fullConfig.UserClassification.toUserClassification()
+	if isValid, reason := concreteRegistry.UserClassification.IsValid(); !isValid {
+		configErrors = append(configErrors, newConfigError(userClassificationNode, fmt.Sprintf("userClassification is invalid: %s", reason)))
+	}
+
+	teamMappingNode, _ := getMappingValue(rootNode.Content[0], "teamMapping")
+	teamMapping, err := fullConfig.TeamMapping.toTeamMapping(configFilePath)
+	if err != nil {
+		configErrors = append(configErrors, newConfigError(teamMappingNode, fmt.Sprintf("Failed to read teamMapping: %v", err)))
+	}
+	concreteRegistry.TeamMapping = teamMapping
+	if isValid, reason := concreteRegistry.TeamMapping.IsValid(); !isValid {
+		configErrors = append(configErrors, newConfigError(teamMappingNode, fmt.Sprintf("teamMapping is invalid: %s", reason)))
+	}
+
+	reports, reportConfigErrors := getReports(concreteRegistry, &fullConfig, rootNode.Content[0], referenceDate)
+	configErrors = append(configErrors, reportConfigErrors...)
+
+	return reports, configErrors.withFiles(sourceFiles, configFilePath)
 
-	return concreteString
 }
 
-/*GetStatsMethodsFromConfig is the entrypoint for converting
-the analyst config file into references to executable statistical methods
-of the registry.Registry type.
+/*GetReportsFromConfig is the entrypoint for converting
+the analyst config file into Reports, i.e. references to executable
+statistical methods of the registry.Registry type and the date they are computed as of.
 The returned method references can be executed by running their
 Call() method.
+A non-empty asOf (see getReferenceDate) overrides the asOf item of the config file.
+Only the reports with the given names are returned, all reports if none are given.
+If there are any problems in the config file, all of them
+are returned as ConfigErrors instead.
 See registryreflector.GetAllChartStatsMethods() for more info.
 */
-func GetStatsMethodsFromConfig(registry registry.Registry) []ChartStatsMethod {
-	return getAllChartStatsMethods(registry, parseConfigFile(), getStartDateFromPeriod)
+func GetReportsFromConfig(registry registry.Registry, asOf string, reportNames []string) ([]Report, error) {
+	reports, configErrors := readConfigFile(DefaultConfigFile, &registry, asOf)
+	if len(configErrors) > 0 {
+		return nil, configErrors
+	}
+	return selectReports(reports, reportNames)
 }
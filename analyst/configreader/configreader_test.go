@@ -0,0 +1,141 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package configreader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/registry"
+)
+
+func TestGetReferenceDate(t *testing.T) {
+
+	testCases := []struct {
+		asOf    string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2017-10-30", time.Date(2017, time.October, 30, 0, 0, 0, 0, time.Local), false},
+		{"2017-10-30 08:15", time.Date(2017, time.October, 30, 8, 15, 0, 0, time.Local), false},
+		{"2017-10-30T08:15", time.Time{}, true},
+		{"30.10.2017", time.Time{}, true},
+		{"2017-02-30", time.Time{}, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.asOf, func(t *testing.T) {
+			got, err := getReferenceDate(testCase.asOf)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("getReferenceDate() failed with %v, want an error: %t", err, testCase.wantErr)
+			}
+			if !got.Equal(testCase.want) {
+				t.Errorf("getReferenceDate() = %s, want %s", got, testCase.want)
+			}
+		})
+	}
+
+	before := time.Now()
+	if got, err := getReferenceDate(""); err != nil || got.Before(before) || got.After(time.Now()) {
+		t.Errorf("getReferenceDate(\"\") = %s, %v, want now", got, err)
+	}
+
+}
+
+/*writeTestConfigFiles writes the given files (keyed by their name)
+to a new temporary directory and returns its path.*/
+func writeTestConfigFiles(t *testing.T, files map[string]string) string {
+	dirPath, err := ioutil.TempDir("", "analyst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for fileName, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dirPath, fileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dirPath
+}
+
+func TestReadConfigFileAsOf(t *testing.T) {
+
+	dirPath := writeTestConfigFiles(t, map[string]string{
+		"analyst.yaml": `
+asOf: 2017-10-30
+charts:
+  - statsMethodName: GetActivityOverTime
+    titleTemplate: Activity in {{ monthYear .startDate }}
+    period: last calendar month
+    Operation: push
+    Interval: day
+`,
+		"invalid.yaml": `
+asOf: yesterday
+charts: []
+`,
+	})
+	defer os.RemoveAll(dirPath)
+
+	testCases := []struct {
+		name          string
+		fileName      string
+		asOf          string
+		wantAsOf      time.Time
+		wantStartDate time.Time
+		wantErrLine   int
+	}{
+		{
+			name:          "asOf item",
+			fileName:      "analyst.yaml",
+			wantAsOf:      time.Date(2017, time.October, 30, 0, 0, 0, 0, time.Local),
+			wantStartDate: time.Date(2017, time.September, 1, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:          "asOf given explicitly",
+			fileName:      "analyst.yaml",
+			asOf:          "2017-12-01 12:00",
+			wantAsOf:      time.Date(2017, time.December, 1, 12, 0, 0, 0, time.Local),
+			wantStartDate: time.Date(2017, time.November, 1, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:        "invalid asOf item",
+			fileName:    "invalid.yaml",
+			wantErrLine: 2,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			reports, configErrors := readConfigFile(filepath.Join(dirPath, testCase.fileName), &registry.Registry{}, testCase.asOf)
+			if testCase.wantErrLine != 0 {
+				if len(configErrors) != 1 || configErrors[0].Line != testCase.wantErrLine {
+					t.Errorf("readConfigFile() failed with %v, want an error in line %d", configErrors, testCase.wantErrLine)
+				}
+				return
+			}
+			if len(configErrors) != 0 {
+				t.Fatalf("readConfigFile() failed with %v", configErrors)
+			}
+
+			if len(reports) != 1 || len(reports[0].Sections) != 1 || len(reports[0].Sections[0].Charts) != 1 {
+				t.Fatalf("readConfigFile() = %v, want a single report with a single chart", reports)
+			}
+			if !reports[0].AsOf.Equal(testCase.wantAsOf) {
+				t.Errorf("report is created as of %s, want %s", reports[0].AsOf, testCase.wantAsOf)
+			}
+			if startDate := reports[0].Sections[0].Charts[0].parameters.StartDate(); !startDate.Equal(testCase.wantStartDate) {
+				t.Errorf("chart starts at %s, want %s", startDate, testCase.wantStartDate)
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,326 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package configreader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/demonware/harbor-analytics/analyst/registry"
+)
+
+/*StatsMethodDoc describes a registered stats method
+and the parameters it can be configured with.*/
+type StatsMethodDoc struct {
+	Name        string
+	Description string
+	Parameters  []ParameterDoc
+}
+
+/*ParameterDoc describes a single parameter of a stats method.
+Enum lists the accepted values if they are limited
+(where an empty string stands for omitting the parameter).*/
+type ParameterDoc struct {
+	Name        string
+	Type        string
+	Default     interface{}
+	Description string
+	Enum        []string
+}
+
+/*commonParameterDocs describes the parameters
+which every charts item accepts, independent of its stats method.*/
+var commonParameterDocs = []ParameterDoc{
+	{Name: statsMethodNameConfigParameter, Type: "string", Description: "Name of the stats method (required)"},
+	{Name: timePeriodInDatsConfigParameter, Type: "integer", Description: "Number of days before the end of the period to start the analysis at, by default the analysis covers all data"},
+	{Name: startDateConfigParameter, Type: "string", Description: "First day of the analysis as YYYY-MM-DD (instead of timePeriodInDays)"},
+	{Name: endDateConfigParameter, Type: "string", Description: "Last day of the analysis as YYYY-MM-DD, by default the analysis ends now"},
+	{Name: periodConfigParameter, Type: "string", Description: "Relative period to analyse (instead of all of the above)", Enum: getRelativePeriodExpressions()},
+	{Name: chartTitleTemplateConfigParameter, Type: "string", Description: "Title of the chart as a Go text/template, e.g. {{ isoDate .startDate }} (required)"},
+}
+
+/*getParameterFields returns the fields of the given parameters struct type,
+where the fields of an embedded struct (e.g. registry.Filter)
+take the place of the embedded struct itself.*/
+func getParameterFields(parametersStructType reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for idx := 0; idx < parametersStructType.NumField(); idx++ {
+		field := parametersStructType.Field(idx)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, getParameterFields(field.Type)...)
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+/*GetStatsMethodDocs describes all registered stats methods and their
+parameters, which are read from the exported fields of their parameters
+struct and the "doc", "enum" and "default" tags of these fields.
+Lists default to the empty list and booleans to false, i.e. to the value
+of a parameter not given. Other parameters only have a default
+if their field has a "default" tag, as their zero value
+may well be rejected by the stats method.*/
+func GetStatsMethodDocs() []StatsMethodDoc {
+
+	var statsMethodDocs []StatsMethodDoc
+
+	for _, statsMethod := range registry.GetStatsMethods() {
+
+		statsMethodDoc := StatsMethodDoc{
+			Name:        statsMethod.Name,
+			Description: statsMethod.Description,
+		}
+
+		parametersStructType := reflect.TypeOf(statsMethod.NewParameters()).Elem()
+		for _, field := range getParameterFields(parametersStructType) {
+			typeName, isSupported := configValueTypeName(field.Type)
+			//Only exported fields of supported types can be configured
+			if field.PkgPath != "" || !isSupported {
+				continue
+			}
+			parameterDoc := ParameterDoc{
+				Name:        field.Name,
+				Type:        typeName,
+				Description: field.Tag.Get("doc"),
+			}
+			if defaultValue, hasDefault, err := getParameterDefault(field); err != nil {
+				log.Fatalf("\nParameters struct of stats method %s is broken :: %v", statsMethod.Name, err)
+			} else if hasDefault {
+				parameterDoc.Default = defaultValue.Interface()
+			} else if field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Bool {
+				parameterDoc.Default = reflect.Zero(field.Type).Interface()
+			}
+			if enum, hasEnum := field.Tag.Lookup("enum"); hasEnum {
+				parameterDoc.Enum = strings.Split(enum, ",")
+			}
+			statsMethodDoc.Parameters = append(statsMethodDoc.Parameters, parameterDoc)
+		}
+
+		statsMethodDocs = append(statsMethodDocs, statsMethodDoc)
+	}
+
+	return statsMethodDocs
+
+}
+
+/*formatDefault returns the human-readable default value of a parameter.*/
+func formatDefault(defaultValue interface{}) string {
+	switch value := defaultValue.(type) {
+	case nil:
+		return "none"
+	case string:
+		return fmt.Sprintf("%q", value)
+	case []string, []map[string]string:
+		return "empty list"
+	}
+	return fmt.Sprintf("%v", defaultValue)
+}
+
+/*WriteStatsMethodList writes a human-readable list of all registered
+stats methods with their parameters, types, defaults and descriptions.*/
+func WriteStatsMethodList(writer io.Writer) {
+
+	writeParameter := func(parameterDoc ParameterDoc) {
+		fmt.Fprintf(writer, "    %s (%s, default: %s)\n", parameterDoc.Name, parameterDoc.Type, formatDefault(parameterDoc.Default))
+		if parameterDoc.Description != "" {
+			fmt.Fprintf(writer, "        %s\n", parameterDoc.Description)
+		}
+	}
+
+	fmt.Fprintln(writer, "Parameters of every charts item:")
+	for _, parameterDoc := range commonParameterDocs {
+		writeParameter(parameterDoc)
+	}
+
+	for _, statsMethodDoc := range GetStatsMethodDocs() {
+		fmt.Fprintf(writer, "\n%s\n", statsMethodDoc.Name)
+		fmt.Fprintf(writer, "  %s\n", statsMethodDoc.Description)
+		if len(statsMethodDoc.Parameters) == 0 {
+			fmt.Fprintln(writer, "  No further parameters.")
+		}
+		for _, parameterDoc := range statsMethodDoc.Parameters {
+			writeParameter(parameterDoc)
+		}
+	}
+
+}
+
+/*getParameterSchema returns the JSON Schema of a single parameter.*/
+func getParameterSchema(parameterDoc ParameterDoc) map[string]interface{} {
+
+	schema := map[string]interface{}{}
+	switch parameterDoc.Type {
+	case "list of strings":
+		schema["type"] = "array"
+		schema["items"] = map[string]interface{}{"type": "string"}
+	case "list of single-entry mappings of strings":
+		schema["type"] = "array"
+		schema["items"] = map[string]interface{}{
+			"type":                 "object",
+			"minProperties":        1,
+			"maxProperties":        1,
+			"additionalProperties": map[string]interface{}{"type": "string"},
+		}
+	default:
+		schema["type"] = parameterDoc.Type
+	}
+
+	if parameterDoc.Description != "" {
+		schema["description"] = parameterDoc.Description
+	}
+	if parameterDoc.Default != nil && schema["type"] != "array" {
+		schema["default"] = parameterDoc.Default
+	}
+	if len(parameterDoc.Enum) > 0 {
+		schema["enum"] = parameterDoc.Enum
+	}
+
+	return schema
+
+}
+
+/*WriteConfigJSONSchema writes a JSON Schema of the analyst.yaml config file
+which editors can use for validation and autocompletion.
+Every charts item must match the schema of exactly one stats method,
+selected by its statsMethodName.*/
+func WriteConfigJSONSchema(writer io.Writer) error {
+
+	var chartSchemas []interface{}
+	for _, statsMethodDoc := range GetStatsMethodDocs() {
+
+		properties := map[string]interface{}{}
+		for _, parameterDoc := range append(commonParameterDocs, statsMethodDoc.Parameters...) {
+			properties[parameterDoc.Name] = getParameterSchema(parameterDoc)
+		}
+		properties[statsMethodNameConfigParameter] = map[string]interface{}{
+			"const":       statsMethodDoc.Name,
+			"description": statsMethodDoc.Description,
+		}
+
+		chartSchemas = append(chartSchemas, map[string]interface{}{
+			"title":                statsMethodDoc.Name,
+			"type":                 "object",
+			"properties":           properties,
+			"required":             []string{statsMethodNameConfigParameter, chartTitleTemplateConfigParameter},
+			"additionalProperties": false,
+		})
+	}
+
+	stringList := map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+	charts := map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"oneOf": chartSchemas},
+	}
+	publish := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"slackChannels": stringList,
+		},
+		"additionalProperties": false,
+	}
+	sections := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"title":       map[string]interface{}{"type": "string"},
+				"description": map[string]interface{}{"type": "string"},
+				"pageBreak":   map[string]interface{}{"type": "boolean", "default": true},
+				"charts":      charts,
+			},
+			"required":             []string{"title"},
+			"additionalProperties": false,
+		},
+	}
+	defaultsProperties := map[string]interface{}{}
+	for _, statsMethodDoc := range GetStatsMethodDocs() {
+		for _, parameterDoc := range append(commonParameterDocs, statsMethodDoc.Parameters...) {
+			//The same parameter may differ in description and accepted values between stats methods
+			defaultsProperties[parameterDoc.Name] = getParameterSchema(ParameterDoc{Type: parameterDoc.Type})
+		}
+	}
+	delete(defaultsProperties, statsMethodNameConfigParameter)
+	delete(defaultsProperties, chartTitleTemplateConfigParameter)
+
+	schema := map[string]interface{}{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"title":   "analyst.yaml",
+		"type":    "object",
+		"properties": map[string]interface{}{
+			"registryName": map[string]interface{}{"type": "string"},
+			"asOf":         map[string]interface{}{"type": "string"},
+			"include": map[string]interface{}{
+				"oneOf": []interface{}{map[string]interface{}{"type": "string"}, stringList},
+			},
+			"defaults": map[string]interface{}{
+				"type":                 "object",
+				"properties":           defaultsProperties,
+				"additionalProperties": false,
+			},
+			"charts":   charts,
+			"sections": sections,
+			"publish":  publish,
+			"reports": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"name":       map[string]interface{}{"type": "string"},
+						"title":      map[string]interface{}{"type": "string"},
+						"outputFile": map[string]interface{}{"type": "string", "pattern": "^[^/]+\\.pdf$"},
+						"publish":    publish,
+						"charts":     charts,
+						"sections":   sections,
+					},
+					"required":             []string{"name"},
+					"additionalProperties": false,
+				},
+			},
+			"userClassification": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"automationNamePatterns":    stringList,
+					"automationMinPushesPerDay": map[string]interface{}{"type": "integer", "minimum": 0},
+					"automationMinHoursOfDay":   map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 24},
+				},
+				"additionalProperties": false,
+			},
+			"teamMapping": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"file": map[string]interface{}{"type": "string"},
+					"teams": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name":               map[string]interface{}{"type": "string"},
+								"projects":           stringList,
+								"repositoryPatterns": stringList,
+								"users":              stringList,
+							},
+							"required":             []string{"name"},
+							"additionalProperties": false,
+						},
+					},
+				},
+				"additionalProperties": false,
+			},
+		},
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schema)
+
+}
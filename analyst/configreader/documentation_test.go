@@ -0,0 +1,98 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package configreader
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetParameterDefault(t *testing.T) {
+
+	parametersStructType := reflect.TypeOf(struct {
+		NoDefault     int
+		IntDefault    int      `default:"7"`
+		BoolDefault   bool     `default:"true"`
+		StringDefault string   `default:"push"`
+		ListDefault   []string `default:"[a, b]"`
+		WrongType     int      `default:"seven"`
+		BrokenDefault int      `default:"[7"`
+	}{})
+
+	testCases := []struct {
+		fieldName      string
+		wantValue      interface{}
+		wantHasDefault bool
+		wantErr        bool
+	}{
+		{"NoDefault", nil, false, false},
+		{"IntDefault", 7, true, false},
+		{"BoolDefault", true, true, false},
+		{"StringDefault", "push", true, false},
+		{"ListDefault", []string{"a", "b"}, true, false},
+		{"WrongType", nil, true, true},
+		{"BrokenDefault", nil, true, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.fieldName, func(t *testing.T) {
+			field, _ := parametersStructType.FieldByName(testCase.fieldName)
+			value, hasDefault, err := getParameterDefault(field)
+			if hasDefault != testCase.wantHasDefault || (err != nil) != testCase.wantErr {
+				t.Fatalf("getParameterDefault() = %t, %v, want %t and an error: %t", hasDefault, err, testCase.wantHasDefault, testCase.wantErr)
+			}
+			if testCase.wantValue != nil && !reflect.DeepEqual(value.Interface(), testCase.wantValue) {
+				t.Errorf("getParameterDefault() = %v, want %v", value.Interface(), testCase.wantValue)
+			}
+		})
+	}
+
+}
+
+func TestGetStatsMethodDocsDefaults(t *testing.T) {
+
+	getDefault := func(t *testing.T, statsMethodName string, parameterName string) interface{} {
+		for _, statsMethodDoc := range GetStatsMethodDocs() {
+			if statsMethodDoc.Name != statsMethodName {
+				continue
+			}
+			for _, parameterDoc := range statsMethodDoc.Parameters {
+				if parameterDoc.Name == parameterName {
+					return parameterDoc.Default
+				}
+			}
+		}
+		t.Fatalf("stats method %s has no parameter %s", statsMethodName, parameterName)
+		return nil
+	}
+
+	testCases := []struct {
+		statsMethodName string
+		parameterName   string
+		want            interface{}
+	}{
+		//The zero value is rejected by the stats method
+		{"GetMostPushedToRepositories", "MaxNumberOfElements", nil},
+		{"GetUserActivityProfiles", "NumberOfUsers", nil},
+		{"GetActivityAnomalies", "BaselineWindowInDays", nil},
+		{"GetRepositoriesByOverwriteRate", "MinNumberOfPushes", nil},
+		{"GetMostPushedToRepositories", "GroupBy", "repository"},
+		//Parameters not given are false
+		{"GetMostPushedToRepositories", "CompareWithPreviousPeriod", false},
+		{"GetMostPushingUsers", "SplitByUserClass", false},
+		{"GetMostPushedToRepositories", "RepositoriesToIgnore", []string(nil)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.statsMethodName+"."+testCase.parameterName, func(t *testing.T) {
+			if got := getDefault(t, testCase.statsMethodName, testCase.parameterName); !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("default is %#v, want %#v", got, testCase.want)
+			}
+		})
+	}
+
+}
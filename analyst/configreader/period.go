@@ -0,0 +1,207 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package configreader
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+const (
+	startDateConfigParameter = "startDate"
+	endDateConfigParameter   = "endDate"
+	periodConfigParameter    = "period"
+)
+
+/*periodConfigParameters are the config parameters of a charts item
+which determine the period analysed by its stats method.*/
+var periodConfigParameters = []string{
+	timePeriodInDatsConfigParameter,
+	startDateConfigParameter,
+	endDateConfigParameter,
+	periodConfigParameter,
+}
+
+/*isPeriodConfigParameter checks whether the given config parameter
+is one of the periodConfigParameters.*/
+func isPeriodConfigParameter(configParameter string) bool {
+	for _, periodConfigParameter := range periodConfigParameters {
+		if configParameter == periodConfigParameter {
+			return true
+		}
+	}
+	return false
+}
+
+/*relativePeriodDirections and relativePeriodUnits are the two parts
+of a relative period expression, e.g. "last calendar month".
+"last" and "previous" are synonyms.*/
+var (
+	relativePeriodDirections = []string{"current", "last", "previous"}
+	relativePeriodUnits      = []string{"ISO week", "calendar month", "calendar quarter", "calendar year"}
+)
+
+/*getRelativePeriodExpressions returns all valid relative period expressions.*/
+func getRelativePeriodExpressions() []string {
+	var expressions []string
+	for _, direction := range relativePeriodDirections {
+		for _, unit := range relativePeriodUnits {
+			expressions = append(expressions, fmt.Sprintf("%s %s", direction, unit))
+		}
+	}
+	return expressions
+}
+
+/*getRelativePeriod returns the start (inclusive) and end (exclusive) of
+the period described by the given relative expression, e.g. "last calendar month"
+or "previous ISO week", relative to the given referenceDate.
+A current period ends at the referenceDate.*/
+func getRelativePeriod(expression string, referenceDate time.Time) (time.Time, time.Time, error) {
+
+	year, month, day := referenceDate.Date()
+	referenceDay := time.Date(year, month, day, 0, 0, 0, 0, referenceDate.Location())
+
+	words := strings.SplitN(strings.TrimSpace(expression), " ", 2)
+	if len(words) == 2 {
+
+		var currentStart time.Time
+		var months, days int
+		switch words[1] {
+		case "ISO week":
+			//time.Weekday starts counting on Sunday
+			currentStart = referenceDay.AddDate(0, 0, -1*((int(referenceDay.Weekday())+6)%7))
+			days = 7
+		case "calendar month":
+			currentStart = time.Date(year, month, 1, 0, 0, 0, 0, referenceDate.Location())
+			months = 1
+		case "calendar quarter":
+			currentStart = time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, referenceDate.Location())
+			months = 3
+		case "calendar year":
+			currentStart = time.Date(year, time.January, 1, 0, 0, 0, 0, referenceDate.Location())
+			months = 12
+		}
+
+		if !currentStart.IsZero() {
+			switch words[0] {
+			case "current":
+				return currentStart, referenceDate, nil
+			case "last", "previous":
+				return currentStart.AddDate(0, -1*months, -1*days), currentStart, nil
+			}
+		}
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("\"%s\" is not of the form \"<%s> <%s>\"",
+		expression, strings.Join(relativePeriodDirections, "|"), strings.Join(relativePeriodUnits, "|"))
+
+}
+
+/*parseConfigDate parses a date given as YYYY-MM-DD in the config file,
+quoted or not, in the location of the given referenceDate.*/
+func parseConfigDate(valueNode *yaml.Node, referenceDate time.Time) (time.Time, error) {
+	if !isScalarOfTag(valueNode, "!!str") && !isScalarOfTag(valueNode, "!!timestamp") {
+		return time.Time{}, fmt.Errorf("must be a date of the form YYYY-MM-DD")
+	}
+	date, err := time.ParseInLocation(timeFormat, valueNode.Value, referenceDate.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be a date of the form YYYY-MM-DD")
+	}
+	return date, nil
+}
+
+/*getPeriod returns the start (inclusive) and end (exclusive) of the period
+configured by the given period config parameter nodes of a charts item
+(keyed by parameter name), relative to the given referenceDate:
+ - period: a relative period expression (see getRelativePeriod),
+   which cannot be combined with the other parameters
+ - endDate: the last day of the period, by default the period ends at the referenceDate
+ - startDate: the first day of the period
+ - timePeriodInDays: the number of days before the end of the period it starts at
+   (instead of a startDate)
+Without startDate and timePeriodInDays, the period starts at the beginning of the data.*/
+func getPeriod(periodNodes map[string]*yaml.Node, referenceDate time.Time) (time.Time, time.Time, ConfigErrors) {
+
+	var configErrors ConfigErrors
+	newConfigError := func(node *yaml.Node, message string) ConfigError {
+		return newNodeConfigError(node, message)
+	}
+	//validatePeriod reports an empty period at the given node
+	validatePeriod := func(node *yaml.Node, startDate time.Time, endDate time.Time) ConfigErrors {
+		if startDate.Before(endDate) {
+			return nil
+		}
+		return ConfigErrors{newConfigError(node, fmt.Sprintf("The analysed period starting at %s and ending before %s is empty",
+			startDate.Format(timeFormat), endDate.Format(timeFormat)))}
+	}
+
+	if periodNode, ok := periodNodes[periodConfigParameter]; ok {
+		if len(periodNodes) > 1 {
+			return time.Time{}, time.Time{}, ConfigErrors{newConfigError(periodNode, fmt.Sprintf("Parameter %s cannot be combined with %s, %s or %s",
+				periodConfigParameter, timePeriodInDatsConfigParameter, startDateConfigParameter, endDateConfigParameter))}
+		}
+		if !isScalarOfTag(periodNode, "!!str") {
+			return time.Time{}, time.Time{}, ConfigErrors{newConfigError(periodNode, fmt.Sprintf("Parameter %s must be of type string", periodConfigParameter))}
+		}
+		startDate, endDate, err := getRelativePeriod(periodNode.Value, referenceDate)
+		if err != nil {
+			return time.Time{}, time.Time{}, ConfigErrors{newConfigError(periodNode, fmt.Sprintf("Parameter %s %v", periodConfigParameter, err))}
+		}
+		log.Printf("\nSet period %s to %s - %s", periodNode.Value, startDate, endDate)
+		//A current period is empty if the referenceDate is its very beginning
+		return startDate, endDate, validatePeriod(periodNode, startDate, endDate)
+	}
+
+	endDate := referenceDate
+	if endDateNode, ok := periodNodes[endDateConfigParameter]; ok {
+		lastDay, err := parseConfigDate(endDateNode, referenceDate)
+		if err != nil {
+			configErrors = append(configErrors, newConfigError(endDateNode, fmt.Sprintf("Parameter %s %v", endDateConfigParameter, err)))
+		}
+		//The endDate is the last day of the period
+		endDate = lastDay.AddDate(0, 0, 1)
+	}
+
+	var startDate time.Time
+	startDateNode, hasStartDate := periodNodes[startDateConfigParameter]
+	timePeriodInDaysNode, hasTimePeriodInDays := periodNodes[timePeriodInDatsConfigParameter]
+	switch {
+	case hasStartDate && hasTimePeriodInDays:
+		configErrors = append(configErrors, newConfigError(startDateNode, fmt.Sprintf("Parameter %s cannot be combined with %s",
+			startDateConfigParameter, timePeriodInDatsConfigParameter)))
+	case hasStartDate:
+		var err error
+		if startDate, err = parseConfigDate(startDateNode, referenceDate); err != nil {
+			configErrors = append(configErrors, newConfigError(startDateNode, fmt.Sprintf("Parameter %s %v", startDateConfigParameter, err)))
+		}
+	case hasTimePeriodInDays:
+		var periodInDays int
+		if !isScalarOfTag(timePeriodInDaysNode, "!!int") || timePeriodInDaysNode.Decode(&periodInDays) != nil {
+			configErrors = append(configErrors, newConfigError(timePeriodInDaysNode, fmt.Sprintf("Parameter %s must be of type integer", timePeriodInDatsConfigParameter)))
+		}
+		//Go back 0 years, 0 months and <periodInDays> days
+		startDate = endDate.AddDate(0, 0, -1*periodInDays)
+	}
+
+	if len(configErrors) == 0 {
+		node := startDateNode
+		if !hasStartDate {
+			node = timePeriodInDaysNode
+		}
+		if node == nil {
+			node = periodNodes[endDateConfigParameter]
+		}
+		configErrors = validatePeriod(node, startDate, endDate)
+	}
+
+	return startDate, endDate, configErrors
+
+}
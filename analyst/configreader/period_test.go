@@ -0,0 +1,147 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package configreader
+
+import (
+	"testing"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestGetRelativePeriod(t *testing.T) {
+
+	//A Wednesday in the middle of the second quarter
+	referenceDate := time.Date(2017, time.May, 17, 15, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		expression    string
+		wantStartDate string
+		wantEndDate   string
+		wantErr       bool
+	}{
+		{"current ISO week", "2017-05-15 00:00", "2017-05-17 15:30", false},
+		{"last ISO week", "2017-05-08 00:00", "2017-05-15 00:00", false},
+		{"previous calendar month", "2017-04-01 00:00", "2017-05-01 00:00", false},
+		{"current calendar quarter", "2017-04-01 00:00", "2017-05-17 15:30", false},
+		{"last calendar quarter", "2017-01-01 00:00", "2017-04-01 00:00", false},
+		{"last calendar year", "2016-01-01 00:00", "2017-01-01 00:00", false},
+		{" last calendar month ", "2017-04-01 00:00", "2017-05-01 00:00", false},
+		{"next calendar month", "", "", true},
+		{"last fortnight", "", "", true},
+		{"last", "", "", true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.expression, func(t *testing.T) {
+			startDate, endDate, err := getRelativePeriod(testCase.expression, referenceDate)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("getRelativePeriod() failed with %v, want an error: %t", err, testCase.wantErr)
+			}
+			if testCase.wantErr {
+				return
+			}
+			if got := startDate.Format("2006-01-02 15:04"); got != testCase.wantStartDate {
+				t.Errorf("getRelativePeriod() starts at %s, want %s", got, testCase.wantStartDate)
+			}
+			if got := endDate.Format("2006-01-02 15:04"); got != testCase.wantEndDate {
+				t.Errorf("getRelativePeriod() ends at %s, want %s", got, testCase.wantEndDate)
+			}
+		})
+	}
+
+}
+
+/*getTestPeriodNodes returns the value nodes of the given YAML mapping
+keyed by their parameter name.*/
+func getTestPeriodNodes(t *testing.T, yamlMapping string) map[string]*yaml.Node {
+	var rootNode yaml.Node
+	if err := yaml.Unmarshal([]byte(yamlMapping), &rootNode); err != nil {
+		t.Fatalf("invalid YAML %q: %v", yamlMapping, err)
+	}
+	periodNodes := map[string]*yaml.Node{}
+	if len(rootNode.Content) == 0 {
+		return periodNodes
+	}
+	mappingNode := rootNode.Content[0]
+	for idx := 0; idx+1 < len(mappingNode.Content); idx += 2 {
+		periodNodes[mappingNode.Content[idx].Value] = mappingNode.Content[idx+1]
+	}
+	return periodNodes
+}
+
+func TestGetPeriod(t *testing.T) {
+
+	referenceDate := time.Date(2017, time.October, 30, 8, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		yamlMapping   string
+		wantStartDate string
+		wantEndDate   string
+		wantErrLine   int
+	}{
+		{"all data", "", "0001-01-01 00:00", "2017-10-30 08:00", 0},
+		{"start date", "startDate: 2017-10-01", "2017-10-01 00:00", "2017-10-30 08:00", 0},
+		{"quoted start date", "startDate: \"2017-10-01\"", "2017-10-01 00:00", "2017-10-30 08:00", 0},
+		{"end date", "startDate: 2017-10-01\nendDate: 2017-10-07", "2017-10-01 00:00", "2017-10-08 00:00", 0},
+		{"time period in days", "timePeriodInDays: 7", "2017-10-23 08:00", "2017-10-30 08:00", 0},
+		{"time period in days before end date", "timePeriodInDays: 7\nendDate: 2017-10-07", "2017-10-01 00:00", "2017-10-08 00:00", 0},
+		{"relative period", "period: last ISO week", "2017-10-23 00:00", "2017-10-30 00:00", 0},
+		{"relative period with start date", "startDate: 2017-10-01\nperiod: last ISO week", "", "", 2},
+		{"start date and time period in days", "timePeriodInDays: 7\nstartDate: 2017-10-01", "", "", 2},
+		{"invalid start date", "startDate: 2017-13-01", "", "", 1},
+		{"time period in days of the wrong type", "timePeriodInDays: seven", "", "", 1},
+		{"empty period", "timePeriodInDays: 0", "", "", 1},
+		{"start date after end date", "startDate: 2017-10-08\nendDate: 2017-10-01", "", "", 1},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			startDate, endDate, configErrors := getPeriod(getTestPeriodNodes(t, testCase.yamlMapping), referenceDate)
+			if testCase.wantErrLine != 0 {
+				if len(configErrors) != 1 || configErrors[0].Line != testCase.wantErrLine {
+					t.Errorf("getPeriod() failed with %v, want an error in line %d", configErrors, testCase.wantErrLine)
+				}
+				return
+			}
+			if len(configErrors) != 0 {
+				t.Fatalf("getPeriod() failed with %v", configErrors)
+			}
+			if got := startDate.Format("2006-01-02 15:04"); got != testCase.wantStartDate {
+				t.Errorf("getPeriod() starts at %s, want %s", got, testCase.wantStartDate)
+			}
+			if got := endDate.Format("2006-01-02 15:04"); got != testCase.wantEndDate {
+				t.Errorf("getPeriod() ends at %s, want %s", got, testCase.wantEndDate)
+			}
+		})
+	}
+
+}
+
+func TestGetPeriodAtPeriodBoundary(t *testing.T) {
+
+	//The very beginning of a Monday
+	referenceDate := time.Date(2017, time.October, 30, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		yamlMapping string
+		wantErr     bool
+	}{
+		{"period: current ISO week", true},
+		{"period: last ISO week", false},
+		{"period: current calendar month", false},
+	}
+
+	for _, testCase := range testCases {
+		_, _, configErrors := getPeriod(getTestPeriodNodes(t, testCase.yamlMapping), referenceDate)
+		if (len(configErrors) != 0) != testCase.wantErr {
+			t.Errorf("getPeriod(%s) failed with %v, want an error: %t", testCase.yamlMapping, configErrors, testCase.wantErr)
+		}
+	}
+
+}
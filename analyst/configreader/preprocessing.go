@@ -0,0 +1,343 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package configreader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/demonware/harbor-analytics/analyst/registry"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+const (
+	includeConfigElement  = "include"
+	defaultsConfigElement = "defaults"
+	chartsConfigElement   = "charts"
+)
+
+/*environmentVariablePattern matches references to environment variables
+in config values, i.e. ${NAME} or ${NAME:-default}.
+A reference preceded by another $ is escaped, i.e. $${NAME} stands for ${NAME}.*/
+var environmentVariablePattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+/*nodeSourceFiles maps the nodes read from included files to the path of their file,
+so that problems in included files are reported at the right file.*/
+type nodeSourceFiles map[*yaml.Node]string
+
+/*getFile returns the path of the file the given node was read from,
+which is the given configFilePath unless the node was included.
+A node decoded into a yaml.Node value is a copy of the original node
+but shares its content, the first key or item of which is looked up instead.*/
+func (n nodeSourceFiles) getFile(node *yaml.Node, configFilePath string) string {
+	if node == nil {
+		return configFilePath
+	}
+	if file, ok := n[node]; ok {
+		return file
+	}
+	if len(node.Content) > 0 {
+		if file, ok := n[node.Content[0]]; ok {
+			return file
+		}
+	}
+	return configFilePath
+}
+
+/*add maps the given node and all nodes below it to the given file path.*/
+func (n nodeSourceFiles) add(node *yaml.Node, filePath string) {
+	n[node] = filePath
+	for _, childNode := range node.Content {
+		n.add(childNode, filePath)
+	}
+}
+
+/*interpolateEnvironmentVariables replaces all references to environment variables
+(see environmentVariablePattern) in the scalar values below the given node
+by the values of the variables. A reference to a variable which is not set
+and has no default is a ConfigError. Unquoted values are resolved anew
+after the replacement, e.g. timePeriodInDays: ${DAYS} is an integer.*/
+func interpolateEnvironmentVariables(node *yaml.Node) ConfigErrors {
+
+	var configErrors ConfigErrors
+
+	if node.Kind == yaml.ScalarNode {
+		value := environmentVariablePattern.ReplaceAllStringFunc(node.Value, func(reference string) string {
+			if strings.HasPrefix(reference, "$$") {
+				return reference[1:]
+			}
+			match := environmentVariablePattern.FindStringSubmatch(reference)
+			if variableValue, isSet := os.LookupEnv(match[1]); isSet {
+				return variableValue
+			}
+			if match[2] != "" {
+				return match[3]
+			}
+			configErrors = append(configErrors, newNodeConfigError(node, fmt.Sprintf("Environment variable %s is not set", match[1])))
+			return reference
+		})
+		if value != node.Value {
+			node.Value = value
+			if node.Style&(yaml.TaggedStyle|yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+				node.Tag = ""
+			}
+		}
+	}
+
+	for _, childNode := range node.Content {
+		configErrors = append(configErrors, interpolateEnvironmentVariables(childNode)...)
+	}
+
+	return configErrors
+
+}
+
+/*mergeNodes merges the given override node into the given base node
+and returns the result: mappings are merged key by key, lists are concatenated
+(base items first) and all other values of the override node replace the base value.
+The override node is modified and returned unless the base node is nil.*/
+func mergeNodes(base *yaml.Node, override *yaml.Node) *yaml.Node {
+
+	if base == nil {
+		return override
+	}
+
+	switch {
+	case base.Kind == yaml.MappingNode && override.Kind == yaml.MappingNode:
+		//The keys of the override node come first, the
+		//source file of a mapping is told by its first key
+		content := make([]*yaml.Node, 0, len(base.Content)+len(override.Content))
+		for idx := 0; idx+1 < len(override.Content); idx += 2 {
+			keyNode, valueNode := override.Content[idx], override.Content[idx+1]
+			if _, baseValueNode := getMappingValue(base, keyNode.Value); baseValueNode != nil {
+				valueNode = mergeNodes(baseValueNode, valueNode)
+			}
+			content = append(content, keyNode, valueNode)
+		}
+		for idx := 0; idx+1 < len(base.Content); idx += 2 {
+			if overrideKeyNode, _ := getMappingValue(override, base.Content[idx].Value); overrideKeyNode == nil {
+				content = append(content, base.Content[idx], base.Content[idx+1])
+			}
+		}
+		override.Content = content
+	case base.Kind == yaml.SequenceNode && override.Kind == yaml.SequenceNode:
+		override.Content = append(append([]*yaml.Node{}, base.Content...), override.Content...)
+	}
+
+	return override
+
+}
+
+/*resolveIncludes merges the files listed in the include item of the given mapping
+(the root of the config file at the given path) into the mapping, as if they
+were written before it (see mergeNodes), and removes the include item.
+An included file may be given relative to the including file, may include
+further files and may refer to environment variables, but must not include itself.
+The nodes of included files are added to the given sourceFiles.*/
+func resolveIncludes(mappingNode *yaml.Node, filePath string, sourceFiles nodeSourceFiles, includingFilePaths []string) ConfigErrors {
+
+	var configErrors ConfigErrors
+
+	var includeNode *yaml.Node
+	for idx := 0; idx+1 < len(mappingNode.Content); idx += 2 {
+		if mappingNode.Content[idx].Value == includeConfigElement {
+			includeNode = mappingNode.Content[idx+1]
+			mappingNode.Content = append(mappingNode.Content[:idx:idx], mappingNode.Content[idx+2:]...)
+			break
+		}
+	}
+	if includeNode == nil {
+		return nil
+	}
+
+	includePathNodes := []*yaml.Node{includeNode}
+	if includeNode.Kind == yaml.SequenceNode {
+		includePathNodes = includeNode.Content
+	}
+
+	includingFilePaths = append(includingFilePaths, filepath.Clean(filePath))
+	var includedNode *yaml.Node
+
+	for _, includePathNode := range includePathNodes {
+
+		if !isScalarOfTag(includePathNode, "!!str") {
+			configErrors = append(configErrors, newNodeConfigError(includePathNode, fmt.Sprintf("%s must be a file name or a list of file names", includeConfigElement)))
+			continue
+		}
+		includePath := includePathNode.Value
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(filePath), includePath)
+		}
+
+		isCircular := false
+		for _, includingFilePath := range includingFilePaths {
+			isCircular = isCircular || filepath.Clean(includePath) == includingFilePath
+		}
+		if isCircular {
+			configErrors = append(configErrors, newNodeConfigError(includePathNode, fmt.Sprintf("File %s includes itself", includePath)))
+			continue
+		}
+
+		yamlFile, err := ioutil.ReadFile(includePath)
+		if err != nil {
+			configErrors = append(configErrors, newNodeConfigError(includePathNode, fmt.Sprintf("Failed to read included file: %v", err)))
+			continue
+		}
+		var rootNode yaml.Node
+		if err := yaml.Unmarshal(yamlFile, &rootNode); err != nil {
+			configErrors = append(configErrors, newConfigErrorsFromYAML(includePath, err)...)
+			continue
+		}
+		if len(rootNode.Content) == 0 {
+			continue
+		}
+		if rootNode.Content[0].Kind != yaml.MappingNode {
+			configErrors = append(configErrors, newNodeConfigError(includePathNode, fmt.Sprintf("Included file %s is not a mapping", includePath)))
+			continue
+		}
+		sourceFiles.add(rootNode.Content[0], includePath)
+		configErrors = append(configErrors, interpolateEnvironmentVariables(rootNode.Content[0])...)
+		//The types are checked file by file, as the line numbers
+		//of yaml errors do not tell the file in the merged config
+		if err := rootNode.Content[0].Decode(&AnalystConfig{}); err != nil {
+			configErrors = append(configErrors, newConfigErrorsFromYAML(includePath, err)...)
+			continue
+		}
+		configErrors = append(configErrors, resolveIncludes(rootNode.Content[0], includePath, sourceFiles, includingFilePaths)...)
+		includedNode = mergeNodes(includedNode, rootNode.Content[0])
+	}
+
+	mergeNodes(includedNode, mappingNode)
+	return configErrors
+
+}
+
+/*getChartNodes returns the charts items of the given mapping
+(the root of the config file or a reports item) and of its sections items.*/
+func getChartNodes(mappingNode *yaml.Node) []*yaml.Node {
+
+	var chartNodes []*yaml.Node
+	appendChartNodes := func(node *yaml.Node) {
+		if _, chartsNode := getMappingValue(node, chartsConfigElement); chartsNode != nil && chartsNode.Kind == yaml.SequenceNode {
+			chartNodes = append(chartNodes, chartsNode.Content...)
+		}
+	}
+
+	appendChartNodes(mappingNode)
+	if _, sectionsNode := getMappingValue(mappingNode, sectionsConfigElement); sectionsNode != nil && sectionsNode.Kind == yaml.SequenceNode {
+		for _, sectionNode := range sectionsNode.Content {
+			appendChartNodes(sectionNode)
+		}
+	}
+
+	return chartNodes
+
+}
+
+/*isStatsMethodParameter checks whether the given config parameter
+is a parameter of the given stats method or of any registered one if nil.*/
+func isStatsMethodParameter(configParameter string, statsMethod *registry.StatsMethod) bool {
+
+	statsMethods := []registry.StatsMethod{}
+	if statsMethod != nil {
+		statsMethods = append(statsMethods, *statsMethod)
+	} else {
+		statsMethods = registry.GetStatsMethods()
+	}
+
+	for _, statsMethod := range statsMethods {
+		//Only exported fields of the parameters struct can be configured
+		field, ok := reflect.TypeOf(statsMethod.NewParameters()).Elem().FieldByName(configParameter)
+		if ok && field.PkgPath == "" {
+			return true
+		}
+	}
+	return false
+
+}
+
+/*applyDefaults adds the parameters of the defaults item of the given root mapping
+to all charts items of the config file which do not set them themselves.
+A default applies only to the charts items whose stats method has that parameter.
+A list set by a charts item replaces the default list (in contrast to the lists
+of included files, see mergeNodes, the lists are not concatenated).
+Period parameters (see periodConfigParameters) are not applied
+to charts items which set any period parameter themselves.
+Problems with a default value are reported at the defaults item
+once the parameters of the charts items are bound.*/
+func applyDefaults(rootNode *yaml.Node) ConfigErrors {
+
+	_, defaultsNode := getMappingValue(rootNode, defaultsConfigElement)
+	if defaultsNode == nil {
+		return nil
+	}
+	if defaultsNode.Kind != yaml.MappingNode {
+		return ConfigErrors{newNodeConfigError(defaultsNode, fmt.Sprintf("%s must be a mapping of charts item parameters", defaultsConfigElement))}
+	}
+
+	var configErrors ConfigErrors
+	var defaultNodes []*yaml.Node
+	for idx := 0; idx+1 < len(defaultsNode.Content); idx += 2 {
+		keyNode := defaultsNode.Content[idx]
+		switch {
+		case keyNode.Value == statsMethodNameConfigParameter || keyNode.Value == chartTitleTemplateConfigParameter:
+			configErrors = append(configErrors, newNodeConfigError(keyNode, fmt.Sprintf("Parameter %s cannot have a default", keyNode.Value)))
+		case !isPeriodConfigParameter(keyNode.Value) && !isStatsMethodParameter(keyNode.Value, nil):
+			configErrors = append(configErrors, newNodeConfigError(keyNode, fmt.Sprintf("Unknown parameter %s in %s", keyNode.Value, defaultsConfigElement)))
+		default:
+			defaultNodes = append(defaultNodes, keyNode, defaultsNode.Content[idx+1])
+		}
+	}
+
+	chartNodes := getChartNodes(rootNode)
+	if _, reportsNode := getMappingValue(rootNode, reportsConfigElement); reportsNode != nil && reportsNode.Kind == yaml.SequenceNode {
+		for _, reportNode := range reportsNode.Content {
+			chartNodes = append(chartNodes, getChartNodes(reportNode)...)
+		}
+	}
+
+	for _, chartNode := range chartNodes {
+
+		//Problems with the charts item itself are reported once its parameters are bound
+		_, statsMethodNameNode := getMappingValue(chartNode, statsMethodNameConfigParameter)
+		if statsMethodNameNode == nil {
+			continue
+		}
+		statsMethod, err := registry.LookupStatsMethod(statsMethodNameNode.Value)
+		if err != nil {
+			continue
+		}
+
+		hasPeriod := false
+		for idx := 0; idx+1 < len(chartNode.Content); idx += 2 {
+			hasPeriod = hasPeriod || isPeriodConfigParameter(chartNode.Content[idx].Value)
+		}
+
+		for idx := 0; idx+1 < len(defaultNodes); idx += 2 {
+			configParameter := defaultNodes[idx].Value
+			if keyNode, _ := getMappingValue(chartNode, configParameter); keyNode != nil {
+				continue
+			}
+			if isPeriodConfigParameter(configParameter) && hasPeriod {
+				continue
+			}
+			if !isPeriodConfigParameter(configParameter) && !isStatsMethodParameter(configParameter, &statsMethod) {
+				continue
+			}
+			chartNode.Content = append(chartNode.Content, defaultNodes[idx], defaultNodes[idx+1])
+		}
+	}
+
+	return configErrors
+
+}
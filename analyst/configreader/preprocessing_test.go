@@ -0,0 +1,309 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package configreader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+/*parseTestMapping parses the given YAML mapping
+and returns its node.*/
+func parseTestMapping(t *testing.T, yamlMapping string) *yaml.Node {
+	var rootNode yaml.Node
+	if err := yaml.Unmarshal([]byte(yamlMapping), &rootNode); err != nil {
+		t.Fatalf("invalid YAML %q: %v", yamlMapping, err)
+	}
+	return rootNode.Content[0]
+}
+
+/*formatTestNode returns the given node as YAML in flow style,
+e.g. {a: 1, b: [x, y]}.*/
+func formatTestNode(t *testing.T, node *yaml.Node) string {
+	var setFlowStyle func(node *yaml.Node)
+	setFlowStyle = func(node *yaml.Node) {
+		node.Style |= yaml.FlowStyle
+		for _, childNode := range node.Content {
+			setFlowStyle(childNode)
+		}
+	}
+	setFlowStyle(node)
+	yamlValue, err := yaml.Marshal(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(yamlValue))
+}
+
+func TestInterpolateEnvironmentVariables(t *testing.T) {
+
+	os.Setenv("ANALYST_TEST_DAYS", "7")
+	os.Setenv("ANALYST_TEST_EMPTY", "")
+	os.Unsetenv("ANALYST_TEST_UNSET")
+	defer os.Unsetenv("ANALYST_TEST_DAYS")
+	defer os.Unsetenv("ANALYST_TEST_EMPTY")
+
+	testCases := []struct {
+		name        string
+		yamlMapping string
+		want        string
+		wantTag     string
+		wantErrLine int
+	}{
+		{"variable", "value: ${ANALYST_TEST_DAYS}", "7", "!!int", 0},
+		{"quoted variable", "value: \"${ANALYST_TEST_DAYS}\"", "7", "!!str", 0},
+		{"within a value", "value: last ${ANALYST_TEST_DAYS} days", "last 7 days", "!!str", 0},
+		{"default", "value: ${ANALYST_TEST_UNSET:-30}", "30", "!!int", 0},
+		{"empty default", "value: x${ANALYST_TEST_UNSET:-}", "x", "!!str", 0},
+		{"default of a set variable", "value: ${ANALYST_TEST_DAYS:-30}", "7", "!!int", 0},
+		{"empty variable", "value: x${ANALYST_TEST_EMPTY:-30}", "x", "!!str", 0},
+		{"escaped reference", "value: $${ANALYST_TEST_DAYS}", "${ANALYST_TEST_DAYS}", "!!str", 0},
+		{"escaped and resolved reference", "value: $${ANALYST_TEST_DAYS}${ANALYST_TEST_DAYS}", "${ANALYST_TEST_DAYS}7", "!!str", 0},
+		{"unset variable", "value: ${ANALYST_TEST_UNSET}", "", "", 1},
+		{"no reference", "value: $ANALYST_TEST_DAYS", "$ANALYST_TEST_DAYS", "!!str", 0},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mappingNode := parseTestMapping(t, testCase.yamlMapping)
+			configErrors := interpolateEnvironmentVariables(mappingNode)
+			if testCase.wantErrLine != 0 {
+				if len(configErrors) != 1 || configErrors[0].Line != testCase.wantErrLine {
+					t.Errorf("interpolateEnvironmentVariables() failed with %v, want an error in line %d", configErrors, testCase.wantErrLine)
+				}
+				return
+			}
+			if len(configErrors) != 0 {
+				t.Fatalf("interpolateEnvironmentVariables() failed with %v", configErrors)
+			}
+			_, valueNode := getMappingValue(mappingNode, "value")
+			if valueNode.Value != testCase.want || valueNode.ShortTag() != testCase.wantTag {
+				t.Errorf("value is %s %q, want %s %q", valueNode.ShortTag(), valueNode.Value, testCase.wantTag, testCase.want)
+			}
+		})
+	}
+
+}
+
+func TestMergeNodes(t *testing.T) {
+
+	testCases := []struct {
+		name     string
+		base     string
+		override string
+		want     string
+	}{
+		{"disjoint mappings", "a: 1", "b: 2", "{b: 2, a: 1}"},
+		{"scalar", "a: 1\nb: 2", "a: 3", "{a: 3, b: 2}"},
+		{"lists", "a: [x, y]", "a: [z]", "{a: [x, y, z]}"},
+		{"nested mappings", "a: {b: [x], c: 1}", "a: {b: [y], d: 2}", "{a: {b: [x, y], d: 2, c: 1}}"},
+		{"list replaced by scalar", "a: [x]", "a: y", "{a: y}"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			merged := mergeNodes(parseTestMapping(t, testCase.base), parseTestMapping(t, testCase.override))
+			if got := formatTestNode(t, merged); got != testCase.want {
+				t.Errorf("mergeNodes() = %s, want %s", got, testCase.want)
+			}
+		})
+	}
+
+	override := parseTestMapping(t, "a: 1")
+	if merged := mergeNodes(nil, override); merged != override {
+		t.Errorf("mergeNodes(nil, override) = %v, want the override node", merged)
+	}
+
+}
+
+func TestResolveIncludes(t *testing.T) {
+
+	os.Setenv("ANALYST_TEST_COMMON", "common.yaml")
+	defer os.Unsetenv("ANALYST_TEST_COMMON")
+
+	dirPath := writeTestConfigFiles(t, map[string]string{
+		"common.yaml":     "include: ignore.yaml\nregistryName: Common\ndefaults: {timePeriodInDays: 7}",
+		"ignore.yaml":     "defaults: {RepositoriesToIgnore: [meta/*]}",
+		"variable.yaml":   "include: ${ANALYST_TEST_COMMON}",
+		"self.yaml":       "include: self.yaml",
+		"cycle.yaml":      "include: cycle-back.yaml",
+		"cycle-back.yaml": "include: cycle.yaml\nregistryName: Cycle",
+		"list.yaml":       "- a",
+		"wrong.yaml":      "registryName: [a]",
+	})
+	defer os.RemoveAll(dirPath)
+
+	testCases := []struct {
+		name        string
+		yamlMapping string
+		want        string
+		wantErrFile string
+	}{
+		{
+			name:        "no include",
+			yamlMapping: "registryName: Harbor",
+			want:        "{registryName: Harbor}",
+		},
+		{
+			name:        "nested includes",
+			yamlMapping: "include: common.yaml\nregistryName: Harbor\ndefaults: {RepositoriesToIgnore: [tmp/*]}",
+			want:        "{registryName: Harbor, defaults: {RepositoriesToIgnore: [meta/*, tmp/*], timePeriodInDays: 7}}",
+		},
+		{
+			name:        "list of includes",
+			yamlMapping: "include: [ignore.yaml, common.yaml]",
+			want:        "{registryName: Common, defaults: {timePeriodInDays: 7, RepositoriesToIgnore: [meta/*, meta/*]}}",
+		},
+		{
+			name:        "file name from an environment variable",
+			yamlMapping: "include: variable.yaml",
+			want:        "{registryName: Common, defaults: {timePeriodInDays: 7, RepositoriesToIgnore: [meta/*]}}",
+		},
+		{
+			name:        "self include",
+			yamlMapping: "include: self.yaml",
+			wantErrFile: "self.yaml",
+		},
+		{
+			name:        "include cycle",
+			yamlMapping: "include: cycle.yaml",
+			wantErrFile: "cycle-back.yaml",
+		},
+		{
+			name:        "missing file",
+			yamlMapping: "include: missing.yaml",
+			wantErrFile: "analyst.yaml",
+		},
+		{
+			name:        "file without a mapping",
+			yamlMapping: "include: list.yaml",
+			wantErrFile: "analyst.yaml",
+		},
+		{
+			name:        "value of the wrong type",
+			yamlMapping: "include: wrong.yaml",
+			wantErrFile: "wrong.yaml",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			configFilePath := filepath.Join(dirPath, "analyst.yaml")
+			mappingNode := parseTestMapping(t, testCase.yamlMapping)
+			sourceFiles := nodeSourceFiles{}
+			configErrors := resolveIncludes(mappingNode, configFilePath, sourceFiles, nil).withFiles(sourceFiles, configFilePath)
+
+			if testCase.wantErrFile != "" {
+				if len(configErrors) != 1 || configErrors[0].File != filepath.Join(dirPath, testCase.wantErrFile) {
+					t.Errorf("resolveIncludes() failed with %v, want an error in %s", configErrors, testCase.wantErrFile)
+				}
+				return
+			}
+			if len(configErrors) != 0 {
+				t.Fatalf("resolveIncludes() failed with %v", configErrors)
+			}
+			if got := formatTestNode(t, mappingNode); got != testCase.want {
+				t.Errorf("resolveIncludes() = %s, want %s", got, testCase.want)
+			}
+
+		})
+	}
+
+}
+
+func TestApplyDefaults(t *testing.T) {
+
+	testCases := []struct {
+		name        string
+		yamlMapping string
+		want        string
+		wantErrLine int
+	}{
+		{
+			name:        "no defaults",
+			yamlMapping: "charts: [{statsMethodName: GetActivityOverTime}]",
+			want:        "{charts: [{statsMethodName: GetActivityOverTime}]}",
+		},
+		{
+			name: "charts of sections and reports",
+			yamlMapping: "defaults: {Operation: push}\n" +
+				"sections: [{charts: [{statsMethodName: GetActivityOverTime}]}]\n" +
+				"reports: [{charts: [{statsMethodName: GetBurstWindows}]}]",
+			want: "{defaults: {Operation: push}, " +
+				"sections: [{charts: [{statsMethodName: GetActivityOverTime, Operation: push}]}], " +
+				"reports: [{charts: [{statsMethodName: GetBurstWindows, Operation: push}]}]}",
+		},
+		{
+			name:        "parameter set by the charts item",
+			yamlMapping: "defaults: {Operation: push}\ncharts: [{statsMethodName: GetActivityOverTime, Operation: pull}]",
+			want:        "{defaults: {Operation: push}, charts: [{statsMethodName: GetActivityOverTime, Operation: pull}]}",
+		},
+		{
+			name: "list set by the charts item",
+			yamlMapping: "defaults: {UsersToIgnore: [robot]}\n" +
+				"charts: [{statsMethodName: GetActivityOverTime, UsersToIgnore: [ci]}, {statsMethodName: GetActivityOverTime}]",
+			want: "{defaults: {UsersToIgnore: [robot]}, " +
+				"charts: [{statsMethodName: GetActivityOverTime, UsersToIgnore: [ci]}, " +
+				"{statsMethodName: GetActivityOverTime, UsersToIgnore: [robot]}]}",
+		},
+		{
+			name:        "parameter of other stats methods only",
+			yamlMapping: "defaults: {Operation: push}\ncharts: [{statsMethodName: GetPushesPerDaytimes}]",
+			want:        "{defaults: {Operation: push}, charts: [{statsMethodName: GetPushesPerDaytimes}]}",
+		},
+		{
+			name:        "period",
+			yamlMapping: "defaults: {timePeriodInDays: 7}\ncharts: [{statsMethodName: GetPushesPerDaytimes}, {statsMethodName: GetPushesPerDaytimes, period: last ISO week}]",
+			want:        "{defaults: {timePeriodInDays: 7}, charts: [{statsMethodName: GetPushesPerDaytimes, timePeriodInDays: 7}, {statsMethodName: GetPushesPerDaytimes, period: last ISO week}]}",
+		},
+		{
+			name:        "unknown stats method",
+			yamlMapping: "defaults: {Operation: push}\ncharts: [{statsMethodName: GetNothing}]",
+			want:        "{defaults: {Operation: push}, charts: [{statsMethodName: GetNothing}]}",
+		},
+		{
+			name:        "unknown parameter",
+			yamlMapping: "defaults:\n  Unknown: 1\ncharts: []",
+			wantErrLine: 2,
+		},
+		{
+			name:        "title",
+			yamlMapping: "defaults:\n  titleTemplate: Title\ncharts: []",
+			wantErrLine: 2,
+		},
+		{
+			name:        "defaults that are no mapping",
+			yamlMapping: "defaults: [Operation]",
+			wantErrLine: 1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mappingNode := parseTestMapping(t, testCase.yamlMapping)
+			configErrors := applyDefaults(mappingNode)
+			if testCase.wantErrLine != 0 {
+				if len(configErrors) != 1 || configErrors[0].Line != testCase.wantErrLine {
+					t.Errorf("applyDefaults() failed with %v, want an error in line %d", configErrors, testCase.wantErrLine)
+				}
+				return
+			}
+			if len(configErrors) != 0 {
+				t.Fatalf("applyDefaults() failed with %v", configErrors)
+			}
+			if got := formatTestNode(t, mappingNode); got != testCase.want {
+				t.Errorf("applyDefaults() = %s, want %s", got, testCase.want)
+			}
+		})
+	}
+
+}
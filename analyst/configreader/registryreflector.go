@@ -10,60 +10,220 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"text/template"
 	"time"
 
 	"github.com/demonware/harbor-analytics/analyst/outputgen"
 	"github.com/demonware/harbor-analytics/analyst/registry"
+
+	yaml "gopkg.in/yaml.v3"
 )
 
 /*ChartStatsMethod defines the structure of the container
-in which references to statistical methods of the regitry.Registry type
-and their parameters can be stored.
-The struct contains the reference to the callable wrapper method of
-a concrete statistical method and a parameter of interface type
-registry.StatsMethodParameters.*/
+in which statistical methods registered in the registry package,
+the registry they are to be computed on and their parameters can be stored.*/
 type ChartStatsMethod struct {
-	methodWrapper func(registry.StatsMethodParameters) outputgen.BarChartable
+	statsMethod   registry.StatsMethod
+	registry      *registry.Registry
 	parameters    registry.StatsMethodParameters
-	title         string
+	titleTemplate *template.Template
+	referenceDate time.Time
 }
 
 /*Call implements the call of a method stored in a ChartStatsMethod struct.
-When executed, it will call the wrapper method stored in the struct
-and hand the given parameter wrapper to it.
-It will also set the title of the chartable.*/
-func (c ChartStatsMethod) Call() outputgen.BarChartable {
-	barChartable := c.methodWrapper(c.parameters)
-	barChartable.SetTitle(c.title)
-	return barChartable
+When executed, it will compute the stats method stored in the struct
+on the registry and hand the given parameters to it.
+It will also render the title of the chartable, which may
+refer to the computed values (see parseTitleTemplate).*/
+func (c ChartStatsMethod) Call() outputgen.Chartable {
+	chartable := c.statsMethod.Compute(c.registry, c.parameters)
+	title, err := renderTitle(c.titleTemplate, getTitleData(c.registry, c.parameters, chartable, c.referenceDate))
+	if err != nil {
+		log.Fatalf("\nFailed to render title of %s :: %v", c.statsMethod.Name, err)
+	}
+	log.Printf("\nSet Title of stat to %s", title)
+	chartable.SetTitle(title)
+	return chartable
+}
+
+/*configValueTypeName returns a human-readable name of the type
+of config values accepted for a parameter field of the given type
+or false if config values cannot be applied to such a field.*/
+func configValueTypeName(fieldType reflect.Type) (string, bool) {
+	switch {
+	case fieldType.Kind() == reflect.Int:
+		return "integer", true
+	case fieldType.Kind() == reflect.String:
+		return "string", true
+	case fieldType.Kind() == reflect.Bool:
+		return "boolean", true
+	case fieldType == reflect.TypeOf([]string{}):
+		return "list of strings", true
+	case fieldType == reflect.TypeOf([]map[string]string{}):
+		return "list of single-entry mappings of strings", true
+	}
+	return "", false
+}
+
+/*isScalarOfTag checks whether the given node is a scalar
+with the given yaml tag (e.g. "!!int").*/
+func isScalarOfTag(node *yaml.Node, tag string) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == tag
+}
+
+/*setParameterField applies the config value of the given node to the given
+parameter field. Only values of exactly the type of the field are accepted,
+all other values result in a ConfigError at the position of the value.*/
+func setParameterField(parameterField reflect.Value, configParameter string, valueNode *yaml.Node) *ConfigError {
+
+	typeName, isSupported := configValueTypeName(parameterField.Type())
+	newConfigError := func(node *yaml.Node, message string) *ConfigError {
+		configError := newNodeConfigError(node, message)
+		return &configError
+	}
+	if !isSupported {
+		return newConfigError(valueNode, fmt.Sprintf("Parameter %s cannot be configured", configParameter))
+	}
+	wrongTypeError := newConfigError(valueNode, fmt.Sprintf("Parameter %s must be of type %s", configParameter, typeName))
+
+	switch parameterField.Kind() {
+	case reflect.Int:
+		var configValueInt int
+		if !isScalarOfTag(valueNode, "!!int") || valueNode.Decode(&configValueInt) != nil {
+			return wrongTypeError
+		}
+		log.Printf("\nSet Int Value %d on field %s", configValueInt, configParameter)
+		parameterField.SetInt(int64(configValueInt))
+		return nil
+	case reflect.String:
+		if !isScalarOfTag(valueNode, "!!str") {
+			return wrongTypeError
+		}
+		log.Printf("\nSet String Value %s on field %s", valueNode.Value, configParameter)
+		parameterField.SetString(valueNode.Value)
+		return nil
+	case reflect.Bool:
+		var configValueBool bool
+		if !isScalarOfTag(valueNode, "!!bool") || valueNode.Decode(&configValueBool) != nil {
+			return wrongTypeError
+		}
+		log.Printf("\nSet Bool Value %t on field %s", configValueBool, configParameter)
+		parameterField.SetBool(configValueBool)
+		return nil
+	}
+
+	if valueNode.Kind != yaml.SequenceNode {
+		return wrongTypeError
+	}
+
+	if parameterField.Type() == reflect.TypeOf([]map[string]string{}) {
+		//A list of single-entry mappings keeps the order of the
+		//entries in contrast to a plain mapping
+		var configValueMapList []map[string]string
+		for _, listElement := range valueNode.Content {
+			if listElement.Kind != yaml.MappingNode || len(listElement.Content) != 2 {
+				return newConfigError(listElement, fmt.Sprintf("Value in configuration list of parameter %s must be a mapping with exactly one entry", configParameter))
+			}
+			key, value := listElement.Content[0], listElement.Content[1]
+			if !isScalarOfTag(key, "!!str") || !isScalarOfTag(value, "!!str") {
+				return newConfigError(listElement, fmt.Sprintf("Entry in configuration list of parameter %s must map a string to a string", configParameter))
+			}
+			configValueMapList = append(configValueMapList, map[string]string{key.Value: value.Value})
+		}
+		log.Printf("\nSet List Value %v on field %s", configValueMapList, configParameter)
+		parameterField.Set(reflect.ValueOf(configValueMapList))
+		return nil
+	}
+
+	var configValueStringList []string
+	for _, listElement := range valueNode.Content {
+		if !isScalarOfTag(listElement, "!!str") {
+			return newConfigError(listElement, fmt.Sprintf("Value in configuration list of parameter %s must be a string", configParameter))
+		}
+		configValueStringList = append(configValueStringList, listElement.Value)
+	}
+	log.Printf("\nSet List Value %v on field %s", configValueStringList, configParameter)
+	parameterField.Set(reflect.ValueOf(configValueStringList))
+	return nil
+
+}
+
+/*getParameterDefault returns the value of the "default" tag of the given
+parameter field converted to the type of the field.
+If the field has no such tag, false is returned, and if the tag is
+no valid value for the field, an error is returned.
+Fields without a default keep the zero value of their type
+unless they are configured.*/
+func getParameterDefault(field reflect.StructField) (reflect.Value, bool, error) {
+
+	defaultTag, hasDefault := field.Tag.Lookup("default")
+	if !hasDefault {
+		return reflect.Value{}, false, nil
+	}
+
+	var rootNode yaml.Node
+	if err := yaml.Unmarshal([]byte(defaultTag), &rootNode); err != nil || len(rootNode.Content) != 1 {
+		return reflect.Value{}, true, fmt.Errorf("default \"%s\" of parameter %s is not a YAML value", defaultTag, field.Name)
+	}
+	defaultValue := reflect.New(field.Type).Elem()
+	if configError := setParameterField(defaultValue, field.Name, rootNode.Content[0]); configError != nil {
+		return reflect.Value{}, true, fmt.Errorf("default \"%s\" of parameter %s is invalid: %s", defaultTag, field.Name, configError.Message)
+	}
+	return defaultValue, true, nil
+
 }
 
 /*getChartStatsMethod converts one given statistical method configuration
-into an actual method reference incl. parameters wrapped in a method wrapper
+into the registered stats method incl. parameters wrapped in a container
 of type ChartStatsMethod.
+Instead of stopping at the first problem in the configuration,
+all problems are returned as ConfigErrors (without the file name set),
+e.g. an unknown stats method name (listing the registered ones),
+unknown parameters, values of the wrong type or invalid parameters.
+The analysed period is relative to the given referenceDate (see getPeriod).
 
-This method will perform a number of reflections on the registry package
-and specifically the methods implemented on the registry.Registry type.*/
-func getChartStatsMethod(concreteRegistry registry.Registry, chartConfig map[string]interface{}) ChartStatsMethod {
+This method will perform a number of reflections on the parameters
+struct of the stats method to apply the configuration to it.*/
+func getChartStatsMethod(concreteRegistry *registry.Registry, chartNode *yaml.Node, referenceDate time.Time) (ChartStatsMethod, ConfigErrors) {
+
+	var configErrors ConfigErrors
+	newConfigError := func(node *yaml.Node, message string) ConfigError {
+		return newNodeConfigError(node, message)
+	}
+
+	if chartNode.Kind != yaml.MappingNode {
+		return ChartStatsMethod{}, ConfigErrors{newConfigError(chartNode, "charts item must be a mapping")}
+	}
 
-	callableName := chartConfig[statsMethodNameConfigParameter].(string)
-	callable := reflect.ValueOf(&concreteRegistry).MethodByName(callableName)
-	if !callable.IsValid() {
-		log.Fatalf("\nFailed to find Registry method with name \"%s\".", callableName)
+	_, statsMethodNameNode := getMappingValue(chartNode, statsMethodNameConfigParameter)
+	if statsMethodNameNode == nil {
+		return ChartStatsMethod{}, ConfigErrors{newConfigError(chartNode, fmt.Sprintf("charts item has no %s", statsMethodNameConfigParameter))}
 	}
+	statsMethod, err := registry.LookupStatsMethod(statsMethodNameNode.Value)
+	if err != nil {
+		return ChartStatsMethod{}, ConfigErrors{newConfigError(statsMethodNameNode, err.Error())}
+	}
+
+	parameters := statsMethod.NewParameters()
+	//The parameters of a stats method will be a pointer-type
+	callableParametersStruct := reflect.ValueOf(parameters).Elem()
+	periodNodes := map[string]*yaml.Node{}
 
-	callableWrapperName := fmt.Sprintf("%sWrapper", callableName)
-	callableWrapper := reflect.ValueOf(&concreteRegistry).MethodByName(callableWrapperName)
-	if !callableWrapper.IsValid() {
-		log.Fatalf("\nFailed to find Registry method with name \"%s\".", callableWrapperName)
+	//Configured parameters override the defaults of the parameters struct
+	for _, field := range getParameterFields(callableParametersStruct.Type()) {
+		defaultValue, hasDefault, err := getParameterDefault(field)
+		if err != nil {
+			log.Fatalf("\nParameters struct of stats method %s is broken :: %v", statsMethod.Name, err)
+		}
+		if hasDefault {
+			callableParametersStruct.FieldByName(field.Name).Set(defaultValue)
+		}
 	}
 
-	callableParametersStructType := callable.Type().In(0).Elem()
-	//The type of the parameter handed to a stats function will be a pointer-type
-	callableParametersStructPointer := reflect.New(callableParametersStructType)
-	callableParametersStruct := callableParametersStructPointer.Elem()
+	for idx := 0; idx+1 < len(chartNode.Content); idx += 2 {
 
-	for configParameter, configValue := range chartConfig {
+		keyNode, valueNode := chartNode.Content[idx], chartNode.Content[idx+1]
+		configParameter := keyNode.Value
 
 		if configParameter == statsMethodNameConfigParameter {
 			continue
@@ -77,78 +237,88 @@ func getChartStatsMethod(concreteRegistry registry.Registry, chartConfig map[str
 			continue
 		}
 
-		if configParameter == timePeriodInDatsConfigParameter {
-			if periodInDays, isInt := configValue.(int); isInt {
-				log.Printf("\nSet StartDate on parameter struct from %s", timePeriodInDatsConfigParameter)
-				callableParametersStructPointer.Interface().(registry.StatsMethodParameters).SetStartDate(getStartDateFromPeriod(periodInDays))
-				continue
-			}
-			log.Fatalf("\nParameter %s must be of type integer.", timePeriodInDatsConfigParameter)
-		}
-
-		parameterField := callableParametersStruct.FieldByName(configParameter)
-		if !parameterField.IsValid() {
-			log.Fatalf("Field %s in struct is invalid", configParameter)
-		}
-
-		if configValueInt, isInt := configValue.(int); isInt {
-			log.Printf("\nSet Int Value %d on field %s", configValueInt, parameterField)
-			parameterField.SetInt(int64(configValueInt))
+		if isPeriodConfigParameter(configParameter) {
+			//Will be set after loop over config parameters
+			//as the parameters depend on each other
+			periodNodes[configParameter] = valueNode
 			continue
 		}
 
-		if configValueString, isString := configValue.(string); isString {
-			log.Printf("\nSet String Value %s on field %s", configValueString, parameterField)
-			parameterField.SetString(configValueString)
+		//Only exported fields of the parameters struct can be configured
+		parameterField := callableParametersStruct.FieldByName(configParameter)
+		if !parameterField.IsValid() || !parameterField.CanSet() {
+			configErrors = append(configErrors, newConfigError(keyNode, fmt.Sprintf("Unknown parameter %s for stats method %s", configParameter, statsMethod.Name)))
 			continue
 		}
 
-		if configValueList, isList := configValue.([]interface{}); isList {
-			var configValueStringList []string
-			for _, listElement := range configValueList {
-				listElementString, isStringable := listElement.(string)
-				if !isStringable {
-					log.Fatalf("Value %s in configuration list of parameter %s must be a string", listElement, configParameter)
-				}
-				configValueStringList = append(configValueStringList, listElementString)
-			}
-			log.Printf("\nSet List Value %v on field %s", configValueStringList, parameterField)
-			parameterField.Set(reflect.ValueOf(configValueStringList))
-			continue
+		if configError := setParameterField(parameterField, configParameter, valueNode); configError != nil {
+			configErrors = append(configErrors, *configError)
 		}
 
 	}
 
-	//Set chart title
-	var concreteTitle string
-	if titleTemplate, isString := chartConfig[chartTitleTemplateConfigParameter].(string); isString {
-		concreteTitle = formatTemplateString(titleTemplate, callableParametersStructPointer.Interface().(registry.StatsMethodParameters).StartDate())
-		log.Printf("\nSet Title of stat to %s", concreteTitle)
+	startDate, endDate, periodConfigErrors := getPeriod(periodNodes, referenceDate)
+	configErrors = append(configErrors, periodConfigErrors...)
+	log.Printf("\nSet StartDate and EndDate on parameter struct to %s and %s", startDate, endDate)
+	parameters.SetStartDate(startDate)
+	parameters.SetEndDate(endDate)
+
+	//Parse the chart title, which is rendered once the chart is computed.
+	//Rendering it with the parameters alone already reveals
+	//references to unknown keys or fields.
+	var titleTemplate *template.Template
+	_, titleTemplateNode := getMappingValue(chartNode, chartTitleTemplateConfigParameter)
+	if titleTemplateNode != nil && isScalarOfTag(titleTemplateNode, "!!str") {
+		titleTemplate, err = parseTitleTemplate(titleTemplateNode.Value)
+		if err == nil {
+			_, err = renderTitle(titleTemplate, getTitleData(concreteRegistry, parameters, nil, referenceDate))
+		}
+		if err != nil {
+			configErrors = append(configErrors, newConfigError(titleTemplateNode, fmt.Sprintf("Parameter %s is not a valid template: %v", chartTitleTemplateConfigParameter, err)))
+		}
+	} else if titleTemplateNode == nil {
+		configErrors = append(configErrors, newConfigError(chartNode, fmt.Sprintf("charts item has no %s", chartTitleTemplateConfigParameter)))
 	} else {
-		log.Fatalf("\nTitle value must be of type string but is: %s", chartConfig[chartTitleTemplateConfigParameter])
+		configErrors = append(configErrors, newConfigError(titleTemplateNode, fmt.Sprintf("Parameter %s must be of type string", chartTitleTemplateConfigParameter)))
 	}
 
-	return ChartStatsMethod{
-		title:         concreteTitle,
-		methodWrapper: callableWrapper.Interface().(func(registry.StatsMethodParameters) outputgen.BarChartable),
-		parameters:    callableParametersStructPointer.Interface().(registry.StatsMethodParameters),
+	//Only check the parameters as a whole if all of them could be applied
+	if len(configErrors) == 0 {
+		if isValid, reason := parameters.IsValid(); !isValid {
+			configErrors = append(configErrors, newConfigError(statsMethodNameNode, fmt.Sprintf("Parameters of %s are invalid: %s", statsMethod.Name, reason)))
+		}
 	}
 
+	return ChartStatsMethod{
+		statsMethod:   statsMethod,
+		registry:      concreteRegistry,
+		parameters:    parameters,
+		titleTemplate: titleTemplate,
+		referenceDate: referenceDate,
+	}, configErrors
+
 }
 
-/*GetAllChartStatsMethods will read the analyst.yaml configuration file
-and map the inhere defined statistical method references and their parameters
-to the actual implementations of the metods in the registry package.
+/*GetAllChartStatsMethods will map the given charts items
+and their parameters to the stats methods registered
+in the registry package.
 The methods will be returned in the form of a slice of method container
 structs of type ChartStatsMethod. Each method can then be invoked by
-calling the .Call() method on the wrapper struct.*/
-func getAllChartStatsMethods(registry registry.Registry, config *AnalystConfig, perioToDateConverter func(int) time.Time) []ChartStatsMethod {
+calling the .Call() method on the wrapper struct.
+The problems found in all charts items are returned together.*/
+func getAllChartStatsMethods(registry *registry.Registry, chartNodes []yaml.Node, referenceDate time.Time) ([]ChartStatsMethod, ConfigErrors) {
 
 	var chartStatsMethods []ChartStatsMethod
-	for _, chartConfig := range config.Charts {
-		chartStatsMethods = append(chartStatsMethods, getChartStatsMethod(registry, chartConfig))
+	var configErrors ConfigErrors
+	for idx := range chartNodes {
+		chartStatsMethod, chartConfigErrors := getChartStatsMethod(registry, &chartNodes[idx], referenceDate)
+		if len(chartConfigErrors) > 0 {
+			configErrors = append(configErrors, chartConfigErrors...)
+			continue
+		}
+		chartStatsMethods = append(chartStatsMethods, chartStatsMethod)
 	}
 
-	return chartStatsMethods
+	return chartStatsMethods, configErrors
 
 }
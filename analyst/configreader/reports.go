@@ -0,0 +1,166 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package configreader
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/registry"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+const (
+	reportsConfigElement = "reports"
+
+	//The default report consists of the top-level charts, sections and publish items
+	defaultReportName       = "report"
+	defaultReportTitle      = "Harbor Analytics Report"
+	reportOutputFileSuffix  = ".pdf"
+	reportOutputFileDefault = defaultReportName + reportOutputFileSuffix
+)
+
+/*ReportConfig is the representation of a single report
+in the reports item of the analyst.yaml config file.
+The title defaults to the title of the default report and
+the outputFile (within the output directory) to the name of the report.*/
+type ReportConfig struct {
+	Name       string          `yaml:"name"`
+	Title      string          `yaml:"title"`
+	OutputFile string          `yaml:"outputFile"`
+	Publish    PublishConfig   `yaml:"publish"`
+	Charts     []yaml.Node     `yaml:"charts"`
+	Sections   []SectionConfig `yaml:"sections"`
+}
+
+/*PublishConfig is the representation of the publish item
+of a report, i.e. where the report is published to.*/
+type PublishConfig struct {
+	SlackChannels []string `yaml:"slackChannels"`
+}
+
+/*Report is an analytics report configured in the config file:
+its name, title, output file and publishing targets,
+its sections with the stats methods of their charts and the reference date
+all relative periods are computed from, i.e. the date the report is created as of.*/
+type Report struct {
+	Name          string
+	Title         string
+	OutputFile    string
+	SlackChannels []string
+	AsOf          time.Time
+	Sections      []ReportSection
+}
+
+/*getReports converts the default report (i.e. the top-level charts,
+sections and publish items) and the reports items of the given configuration into reports.
+The default report is left out if there are reports items but no
+top-level charts or sections. The given rootNode is the root
+mapping of the config file, which problems are reported at.*/
+func getReports(concreteRegistry *registry.Registry, config *AnalystConfig, rootNode *yaml.Node, referenceDate time.Time) ([]Report, ConfigErrors) {
+
+	var reports []Report
+	var configErrors ConfigErrors
+	newConfigError := func(node *yaml.Node, message string) ConfigError {
+		return newNodeConfigError(node, message)
+	}
+
+	if len(config.Reports) == 0 || len(config.Charts) > 0 || len(config.Sections) > 0 {
+		_, sectionsNode := getMappingValue(rootNode, sectionsConfigElement)
+		reportSections, sectionConfigErrors := getReportSections(concreteRegistry, config.Charts, config.Sections, sectionsNode, referenceDate)
+		configErrors = append(configErrors, sectionConfigErrors...)
+		reports = append(reports, Report{
+			Name:          defaultReportName,
+			Title:         defaultReportTitle,
+			OutputFile:    reportOutputFileDefault,
+			SlackChannels: config.Publish.SlackChannels,
+			AsOf:          referenceDate,
+			Sections:      reportSections,
+		})
+	}
+
+	_, reportsNode := getMappingValue(rootNode, reportsConfigElement)
+	for idx, reportConfig := range config.Reports {
+
+		reportNode := reportsNode.Content[idx]
+		report := Report{
+			Name:          reportConfig.Name,
+			Title:         reportConfig.Title,
+			OutputFile:    reportConfig.OutputFile,
+			SlackChannels: reportConfig.Publish.SlackChannels,
+			AsOf:          referenceDate,
+		}
+		if report.Title == "" {
+			report.Title = defaultReportTitle
+		}
+		if report.OutputFile == "" {
+			report.OutputFile = report.Name + reportOutputFileSuffix
+		}
+
+		if report.Name == "" {
+			configErrors = append(configErrors, newConfigError(reportNode, fmt.Sprintf("%s item has no name", reportsConfigElement)))
+		}
+		//Reports are written to the output directory only
+		if filepath.Base(report.OutputFile) != report.OutputFile || !strings.HasSuffix(report.OutputFile, reportOutputFileSuffix) {
+			configErrors = append(configErrors, newConfigError(reportNode, fmt.Sprintf("outputFile \"%s\" of report %s is not a file name ending with %s",
+				report.OutputFile, report.Name, reportOutputFileSuffix)))
+		}
+		for _, otherReport := range reports {
+			if otherReport.Name == report.Name {
+				configErrors = append(configErrors, newConfigError(reportNode, fmt.Sprintf("Report name %s is not unique", report.Name)))
+			}
+			if otherReport.OutputFile == report.OutputFile {
+				configErrors = append(configErrors, newConfigError(reportNode, fmt.Sprintf("outputFile %s of report %s is also used by report %s",
+					report.OutputFile, report.Name, otherReport.Name)))
+			}
+		}
+
+		_, sectionsNode := getMappingValue(reportNode, sectionsConfigElement)
+		reportSections, sectionConfigErrors := getReportSections(concreteRegistry, reportConfig.Charts, reportConfig.Sections, sectionsNode, referenceDate)
+		configErrors = append(configErrors, sectionConfigErrors...)
+		report.Sections = reportSections
+
+		reports = append(reports, report)
+	}
+
+	return reports, configErrors
+
+}
+
+/*selectReports returns the reports with the given names
+in the order of the config file or all reports if no names are given.
+An unknown name results in an error listing all report names.*/
+func selectReports(reports []Report, reportNames []string) ([]Report, error) {
+
+	if len(reportNames) == 0 {
+		return reports, nil
+	}
+
+	var selectedReports []Report
+	var allReportNames []string
+	isSelected := map[string]bool{}
+	for _, reportName := range reportNames {
+		isSelected[reportName] = true
+	}
+	for _, report := range reports {
+		allReportNames = append(allReportNames, report.Name)
+		if isSelected[report.Name] {
+			selectedReports = append(selectedReports, report)
+			delete(isSelected, report.Name)
+		}
+	}
+
+	for reportName := range isSelected {
+		return nil, fmt.Errorf("unknown report \"%s\", must be one of: %s", reportName, strings.Join(allReportNames, ", "))
+	}
+
+	return selectedReports, nil
+
+}
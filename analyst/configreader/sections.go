@@ -0,0 +1,76 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package configreader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/registry"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+const sectionsConfigElement = "sections"
+
+/*SectionConfig is the representation of a single section
+in the sections item of the analyst.yaml config file.
+The description may use a basic subset of markdown
+(see outputgen.PDFSection). A section starts on a new page
+unless pageBreak is set to false.*/
+type SectionConfig struct {
+	Title       string      `yaml:"title"`
+	Description string      `yaml:"description"`
+	PageBreak   *bool       `yaml:"pageBreak"`
+	Charts      []yaml.Node `yaml:"charts"`
+}
+
+/*ReportSection is a section of the report with its title,
+its description and the stats methods of its charts.
+The section without a title contains the charts
+configured outside of any section.*/
+type ReportSection struct {
+	Title       string
+	Description string
+	PageBreak   bool
+	Charts      []ChartStatsMethod
+}
+
+/*getReportSections converts the given charts and sections items of a report
+into the sections of the report, where the charts outside of
+any section form a first, untitled section. The given sectionsNode is
+the sections item of the config file, which problems are reported at.*/
+func getReportSections(concreteRegistry *registry.Registry, chartNodes []yaml.Node, sectionConfigs []SectionConfig, sectionsNode *yaml.Node, referenceDate time.Time) ([]ReportSection, ConfigErrors) {
+
+	var reportSections []ReportSection
+
+	chartStatsMethods, configErrors := getAllChartStatsMethods(concreteRegistry, chartNodes, referenceDate)
+	if len(chartStatsMethods) > 0 {
+		reportSections = append(reportSections, ReportSection{Charts: chartStatsMethods})
+	}
+
+	for idx, sectionConfig := range sectionConfigs {
+
+		if sectionConfig.Title == "" {
+			sectionNode := sectionsNode.Content[idx]
+			configErrors = append(configErrors, newNodeConfigError(sectionNode, fmt.Sprintf("%s item has no title", sectionsConfigElement)))
+		}
+
+		sectionChartStatsMethods, chartConfigErrors := getAllChartStatsMethods(concreteRegistry, sectionConfig.Charts, referenceDate)
+		configErrors = append(configErrors, chartConfigErrors...)
+
+		reportSections = append(reportSections, ReportSection{
+			Title:       sectionConfig.Title,
+			Description: sectionConfig.Description,
+			PageBreak:   sectionConfig.PageBreak == nil || *sectionConfig.PageBreak,
+			Charts:      sectionChartStatsMethods,
+		})
+	}
+
+	return reportSections, configErrors
+
+}
@@ -0,0 +1,111 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package configreader
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"text/template"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+	"github.com/demonware/harbor-analytics/analyst/registry"
+)
+
+/*legacyTitlePlaceholders maps the placeholders of titleTemplates
+from before titles were rendered with text/template (with any whitespace
+within the braces, e.g. "{{startDate}}") to their equivalent.*/
+var legacyTitlePlaceholders = map[*regexp.Regexp]string{
+	regexp.MustCompile(`\{\{\s*startDate\s*\}\}`): "{{ isoDate .startDate }}",
+}
+
+/*titleTemplateFuncs are the functions available in titleTemplates
+in addition to the builtin functions of text/template.*/
+var titleTemplateFuncs = template.FuncMap{
+	//formatDate formats a date with a Go layout, e.g. "Jan 2"
+	"formatDate": func(layout string, date time.Time) string {
+		return date.Format(layout)
+	},
+	//isoDate formats a date like 2017-10-30
+	"isoDate": func(date time.Time) string {
+		return date.Format(timeFormat)
+	},
+	//isoWeek formats the ISO week of a date like 2017-W44
+	"isoWeek": func(date time.Time) string {
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	},
+	//monthYear formats the month of a date like October 2017
+	"monthYear": func(date time.Time) string {
+		return date.Format("January 2006")
+	},
+}
+
+/*parseTitleTemplate parses the given titleTemplate with text/template.
+The data context the template is rendered with is a map with the keys:
+ - startDate, endDate, now: the first and last moment of the analysed period
+   and the time the report is created as of (as time.Time, see titleTemplateFuncs to format them)
+ - periodDays: the number of days between startDate and endDate
+   (0 if the period has no startDate and covers all data)
+ - registryName: the registryName item of the config file
+ - elements, total: the number of elements and the sum of all values
+   shown in the chart (see outputgen.ChartableSummary)
+ - params: the parameters struct of the stats method (e.g. .params.Operation)
+Referencing any other key is an error.*/
+func parseTitleTemplate(titleTemplate string) (*template.Template, error) {
+
+	for legacyPlaceholder, placeholder := range legacyTitlePlaceholders {
+		titleTemplate = legacyPlaceholder.ReplaceAllLiteralString(titleTemplate, placeholder)
+	}
+
+	return template.New("title").Funcs(titleTemplateFuncs).Option("missingkey=error").Parse(titleTemplate)
+
+}
+
+/*getTitleData returns the data context a titleTemplate is rendered with
+for the given parameters and the chartdata computed with them
+as of the given referenceDate (see getReferenceDate).
+The chartdata may be nil if not computed yet.*/
+func getTitleData(concreteRegistry *registry.Registry, parameters registry.StatsMethodParameters, chartable outputgen.Chartable, referenceDate time.Time) map[string]interface{} {
+
+	startDate := parameters.StartDate()
+	endDate := parameters.EndDate()
+
+	var summary outputgen.ChartableSummary
+	if chartable != nil {
+		summary = outputgen.Summarize(chartable)
+	}
+
+	//Without a startDate the period starts at the zero time
+	periodDays := 0
+	if !startDate.IsZero() {
+		periodDays = int(math.Floor(endDate.Sub(startDate).Hours()/24 + 0.5))
+	}
+
+	return map[string]interface{}{
+		"startDate":    startDate,
+		"endDate":      endDate.Add(-1 * time.Nanosecond),
+		"now":          referenceDate,
+		"periodDays":   periodDays,
+		"registryName": concreteRegistry.Name,
+		"elements":     summary.Elements,
+		"total":        summary.Total,
+		"params":       parameters,
+	}
+
+}
+
+/*renderTitle renders the given titleTemplate with the given data context.*/
+func renderTitle(titleTemplate *template.Template, data map[string]interface{}) (string, error) {
+	var title bytes.Buffer
+	if err := titleTemplate.Execute(&title, data); err != nil {
+		return "", err
+	}
+	return title.String(), nil
+}
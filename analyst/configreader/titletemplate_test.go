@@ -0,0 +1,108 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package configreader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+	"github.com/demonware/harbor-analytics/analyst/registry"
+)
+
+type testLineChartable struct {
+	seriesList outputgen.LineChartableSeriesList
+}
+
+func (t *testLineChartable) GetLineChartSeries() outputgen.LineChartableSeriesList {
+	return t.seriesList
+}
+
+func (t *testLineChartable) SetTitle(title string) {}
+
+func (t *testLineChartable) Title() string {
+	return "Test"
+}
+
+func TestRenderTitle(t *testing.T) {
+
+	parameters := &registry.GetActivityOverTimeParameters{Operation: "push", Interval: "week"}
+	parameters.SetStartDate(time.Date(2017, time.October, 23, 0, 0, 0, 0, time.UTC))
+	parameters.SetEndDate(time.Date(2017, time.October, 30, 0, 0, 0, 0, time.UTC))
+	referenceDate := time.Date(2017, time.October, 30, 8, 0, 0, 0, time.UTC)
+	chartable := &testLineChartable{outputgen.LineChartableSeriesList{
+		{Name: "Pushes", Values: []float64{1, 2}},
+		{Name: "Pulls", Values: []float64{3}},
+	}}
+
+	testCases := []struct {
+		name          string
+		titleTemplate string
+		chartable     outputgen.Chartable
+		want          string
+		wantErr       bool
+	}{
+		{"plain text", "Pushes per week", nil, "Pushes per week", false},
+		{"legacy placeholder", "Pushes since {{ startDate }}", nil, "Pushes since 2017-10-23", false},
+		{"legacy placeholder without spaces", "Pushes since {{startDate}} ({{  startDate\t}})", nil, "Pushes since 2017-10-23 (2017-10-23)", false},
+		{"key of the same name", "Pushes since {{ .startDate.Year }}", nil, "Pushes since 2017", false},
+		{"last day of the period", "Until {{ isoDate .endDate }}", nil, "Until 2017-10-29", false},
+		{"format functions", "{{ isoWeek .startDate }}, {{ monthYear .now }}, {{ formatDate \"Jan 2\" .now }}", nil, "2017-W43, October 2017, Oct 30", false},
+		{"period and registry", "{{ .registryName }}: last {{ .periodDays }} days", nil, "Harbor: last 7 days", false},
+		{"parameters", "{{ .params.Operation }}es per {{ .params.Interval }}", nil, "pushes per week", false},
+		{"summary", "{{ .total }} accesses in {{ .elements }} series", chartable, "6 accesses in 2 series", false},
+		{"summary before computing", "{{ .total }} accesses", nil, "0 accesses", false},
+		{"unknown key", "{{ .unknown }}", nil, "", true},
+		{"unknown parameter", "{{ .params.Unknown }}", nil, "", true},
+		{"unknown function", "{{ isoYear .startDate }}", nil, "", true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			titleTemplate, err := parseTitleTemplate(testCase.titleTemplate)
+			var got string
+			if err == nil {
+				got, err = renderTitle(titleTemplate, getTitleData(&registry.Registry{Name: "Harbor"}, parameters, testCase.chartable, referenceDate))
+			}
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("rendering %q failed with %v, want an error: %t", testCase.titleTemplate, err, testCase.wantErr)
+			}
+			if got != testCase.want {
+				t.Errorf("rendering %q = %q, want %q", testCase.titleTemplate, got, testCase.want)
+			}
+		})
+	}
+
+}
+
+func TestGetTitleDataPeriodDays(t *testing.T) {
+
+	testCases := []struct {
+		name      string
+		startDate time.Time
+		endDate   time.Time
+		want      int
+	}{
+		{"no start date", time.Time{}, time.Date(2017, time.October, 30, 0, 0, 0, 0, time.UTC), 0},
+		{"single day", time.Date(2017, time.October, 29, 0, 0, 0, 0, time.UTC), time.Date(2017, time.October, 30, 0, 0, 0, 0, time.UTC), 1},
+		//The end of daylight saving time makes a day 25 hours long
+		{"daylight saving time", time.Date(2017, time.October, 23, 0, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+			time.Date(2017, time.October, 30, 0, 0, 0, 0, time.FixedZone("CET", 60*60)), 7},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			parameters := &registry.GetActivityOverTimeParameters{}
+			parameters.SetStartDate(testCase.startDate)
+			parameters.SetEndDate(testCase.endDate)
+			if got := getTitleData(&registry.Registry{}, parameters, nil, testCase.endDate)["periodDays"]; got != testCase.want {
+				t.Errorf("periodDays = %v, want %d", got, testCase.want)
+			}
+		})
+	}
+
+}
@@ -0,0 +1,123 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package configreader
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/demonware/harbor-analytics/analyst/registry"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+/*ConfigError is a problem found in the config file
+at the given line and column (both starting at one).
+A line or column of zero means that it is unknown.*/
+type ConfigError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+
+	//node is the node the problem was found at,
+	//which tells the file of an included node
+	node *yaml.Node
+}
+
+/*newNodeConfigError creates a ConfigError at the position of the given node.
+The file is set once all problems in the config file are found.*/
+func newNodeConfigError(node *yaml.Node, message string) ConfigError {
+	return ConfigError{Line: node.Line, Column: node.Column, Message: message, node: node}
+}
+
+/*Error formats the ConfigError like compilers do, i.e.
+"<file>:<line>:<column>: <message>".*/
+func (c ConfigError) Error() string {
+	if c.Line == 0 {
+		return fmt.Sprintf("%s: %s", c.File, c.Message)
+	}
+	if c.Column == 0 {
+		return fmt.Sprintf("%s:%d: %s", c.File, c.Line, c.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", c.File, c.Line, c.Column, c.Message)
+}
+
+/*ConfigErrors is a list of all problems found in the config file.*/
+type ConfigErrors []ConfigError
+
+/*Error lists all problems in the ConfigErrors, one per line.*/
+func (c ConfigErrors) Error() string {
+	var messages []string
+	for _, configError := range c {
+		messages = append(messages, configError.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+/*withFiles sets the file of all ConfigErrors without one
+to the file their node was read from (see nodeSourceFiles).*/
+func (c ConfigErrors) withFiles(sourceFiles nodeSourceFiles, configFilePath string) ConfigErrors {
+	for idx := range c {
+		if c[idx].File == "" {
+			c[idx].File = sourceFiles.getFile(c[idx].node, configFilePath)
+		}
+	}
+	return c
+}
+
+/*yamlErrorLinePattern matches the line numbers
+yaml prefixes its error messages with.*/
+var yamlErrorLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+/*newConfigErrorsFromYAML converts an error returned by yaml
+into ConfigErrors, keeping the line numbers where given.*/
+func newConfigErrorsFromYAML(configFilePath string, err error) ConfigErrors {
+
+	messages := []string{err.Error()}
+	if typeError, isTypeError := err.(*yaml.TypeError); isTypeError {
+		messages = typeError.Errors
+	}
+
+	var configErrors ConfigErrors
+	for _, message := range messages {
+		configError := ConfigError{File: configFilePath, Message: message}
+		if match := yamlErrorLinePattern.FindStringSubmatch(message); match != nil {
+			configError.Line, _ = strconv.Atoi(match[1])
+			configError.Message = match[2]
+		}
+		configErrors = append(configErrors, configError)
+	}
+
+	return configErrors
+
+}
+
+/*getMappingValue returns the key and value node of the given key
+within the given mapping node or nil if the key is not contained.*/
+func getMappingValue(mappingNode *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mappingNode == nil || mappingNode.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for idx := 0; idx+1 < len(mappingNode.Content); idx += 2 {
+		if mappingNode.Content[idx].Value == key {
+			return mappingNode.Content[idx], mappingNode.Content[idx+1]
+		}
+	}
+	return nil, nil
+}
+
+/*ValidateConfigFile checks the config file at the given path as a whole:
+its syntax, the userClassification and teamMapping items and every
+charts item against the parameters of its stats method.
+All problems found are returned, an empty list means the config is valid.*/
+func ValidateConfigFile(configFilePath string) ConfigErrors {
+	_, configErrors := readConfigFile(configFilePath, &registry.Registry{}, "")
+	return configErrors
+}
@@ -7,16 +7,125 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/Demonware/harbor-analytics/analyst/configreader"
 	"github.com/Demonware/harbor-analytics/analyst/outputgen"
 	"github.com/Demonware/harbor-analytics/analyst/parser"
 )
 
+const (
+	validateCommand  = "validate"
+	listStatsCommand = "list-stats"
+)
+
+/*listStats prints all available stats methods and their parameters
+or, if requested by the -json-schema flag, a JSON Schema of the config file.*/
+func listStats(args []string) {
+
+	flags := flag.NewFlagSet(listStatsCommand, flag.ExitOnError)
+	jsonSchema := flags.Bool("json-schema", false, "print a JSON Schema of analyst.yaml instead")
+	flags.Parse(args)
+
+	if *jsonSchema {
+		if err := configreader.WriteConfigJSONSchema(os.Stdout); err != nil {
+			log.Fatal(err.Error())
+		}
+		return
+	}
+	configreader.WriteStatsMethodList(os.Stdout)
+
+}
+
+/*validate checks the config file at the given path (or the default
+config file if none is given), prints all problems found in it
+and exits with a non-zero status if there are any.*/
+func validate(args []string) {
+
+	configFilePath := configreader.DefaultConfigFile
+	if len(args) > 0 {
+		configFilePath = args[0]
+	}
+
+	configErrors := configreader.ValidateConfigFile(configFilePath)
+	for _, configError := range configErrors {
+		fmt.Fprintln(os.Stderr, configError.Error())
+	}
+	if len(configErrors) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problem(s) found in %s\n", len(configErrors), configFilePath)
+		os.Exit(1)
+	}
+	fmt.Printf("%s is valid\n", configFilePath)
+
+}
+
+/*buildReport computes the charts of the given report,
+builds them and returns the PDF report to be generated of them.*/
+func buildReport(report configreader.Report) outputgen.PDFReport {
+
+	pdfReport := outputgen.PDFReport{
+		FileName:      report.OutputFile,
+		Title:         report.Title,
+		AsOf:          report.AsOf,
+		SlackChannels: report.SlackChannels,
+	}
+
+	log.Printf("\nCreate report %s", report.Name)
+	for _, reportSection := range report.Sections {
+		//Charts outside of any section are not preceded by a title
+		if reportSection.Title != "" {
+			pdfReport.Sections = append(pdfReport.Sections, outputgen.PDFSection{
+				Title:       reportSection.Title,
+				Description: reportSection.Description,
+				PageBreak:   reportSection.PageBreak,
+			})
+		}
+		for _, chartStatsFunction := range reportSection.Charts {
+			chartable := chartStatsFunction.Call()
+			chartSections, err := outputgen.BuildSections(chartable)
+			//A chart that cannot be generated is left out
+			//so that the rest of the report is still generated
+			if err != nil {
+				log.Printf("\nFailed to generate chart %s, leave it out :: %s", chartable.Title(), err.Error())
+				continue
+			}
+			//Sections of a chart are nested in the section of the report
+			for idx := range chartSections {
+				if reportSection.Title != "" {
+					chartSections[idx].Level++
+				}
+			}
+			pdfReport.Sections = append(pdfReport.Sections, chartSections...)
+		}
+	}
+
+	return pdfReport
+
+}
+
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == validateCommand {
+		//Log output of the stats methods is not of interest here
+		log.SetOutput(ioutil.Discard)
+		validate(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == listStatsCommand {
+		listStats(os.Args[2:])
+		return
+	}
+
+	asOf := flag.String("as-of", "", "create the reports as of the given date (YYYY-MM-DD or YYYY-MM-DD HH:MM) instead of now")
+	reports := flag.String("reports", "", "comma-separated names of the reports to create instead of all")
+	flag.Parse()
+
 	registry, err := parser.CSVsToRegistry()
 	if err != nil {
 		log.Fatal(err.Error())
@@ -27,20 +136,24 @@ func main() {
 		os.Mkdir(outputgen.OutDir, outputgen.OutDirMode)
 	}
 
-	var chartsPaths []string
-	chartStatsFunctions := configreader.GetStatsMethodsFromConfig(registry)
-	for _, chartStatsFunction := range chartStatsFunctions {
-		chartPath, err := outputgen.BuildBarChart(chartStatsFunction.Call())
-		if err != nil {
-			log.Fatalf("\nFailed to generate chart :: %s", err.Error())
-		}
-		chartsPaths = append(chartsPaths, chartPath)
+	var reportNames []string
+	if *reports != "" {
+		reportNames = strings.Split(*reports, ",")
+	}
+	configReports, err := configreader.GetReportsFromConfig(registry, *asOf, reportNames)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	var pdfReports []outputgen.PDFReport
+	for _, configReport := range configReports {
+		pdfReport := buildReport(configReport)
+		outputgen.BuildPDF(pdfReport)
+		pdfReports = append(pdfReports, pdfReport)
 	}
 
-	outputgen.BuildPDF(outputgen.PDFSection{
-		Title:       "",
-		Description: "",
-		ChartFiles:  chartsPaths,
-	})
+	if err := outputgen.WritePublishList(pdfReports); err != nil {
+		log.Fatal(err.Error())
+	}
 
 }
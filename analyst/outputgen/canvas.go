@@ -0,0 +1,186 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package outputgen
+
+import (
+	"os"
+	"strings"
+
+	chart "github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/drawing"
+)
+
+const (
+	canvasPadding       = 20
+	canvasFontSize      = 10.0
+	canvasTitleFontSize = chart.DefaultTitleFontSize
+)
+
+/*canvas is a thin layer on top of a go-chart PNG renderer.
+It is used by chart generators for chart types which
+go-chart does not provide (e.g. heat maps) and which therefore
+have to be drawn box by box.*/
+type canvas struct {
+	renderer chart.Renderer
+	width    int
+	height   int
+}
+
+/*newCanvas creates a white canvas of the given size
+with the default go-chart font set.*/
+func newCanvas(width int, height int) (*canvas, error) {
+
+	renderer, err := chart.PNG(width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return nil, err
+	}
+	renderer.SetFont(font)
+
+	c := canvas{
+		renderer: renderer,
+		width:    width,
+		height:   height,
+	}
+	c.fillBox(chart.NewBox(0, 0, width, height), chart.ColorWhite)
+
+	return &c, nil
+
+}
+
+/*fillBox fills the given box with the given color.*/
+func (c *canvas) fillBox(box chart.Box, color drawing.Color) {
+	c.renderer.SetFillColor(color)
+	c.renderer.MoveTo(box.Left, box.Top)
+	c.renderer.LineTo(box.Right, box.Top)
+	c.renderer.LineTo(box.Right, box.Bottom)
+	c.renderer.LineTo(box.Left, box.Bottom)
+	c.renderer.Close()
+	c.renderer.Fill()
+}
+
+/*measureText returns the box the given text
+takes up when written in the given font size.*/
+func (c *canvas) measureText(text string, fontSize float64) chart.Box {
+	c.renderer.SetFontSize(fontSize)
+	return c.renderer.MeasureText(text)
+}
+
+/*textWithin writes the given text centered (horizontally and
+vertically) into the given box.*/
+func (c *canvas) textWithin(text string, box chart.Box, fontSize float64, color drawing.Color) {
+	c.renderer.SetFontSize(fontSize)
+	c.renderer.SetFontColor(color)
+	textBox := c.renderer.MeasureText(text)
+	x := box.Left + (box.Width()-textBox.Width())/2
+	y := box.Top + (box.Height()+textBox.Height())/2
+	c.renderer.Text(text, x, y)
+}
+
+/*textRightAligned writes the given text into the given box so
+that it ends at the right border of the box.*/
+func (c *canvas) textRightAligned(text string, box chart.Box, fontSize float64, color drawing.Color) {
+	c.renderer.SetFontSize(fontSize)
+	c.renderer.SetFontColor(color)
+	textBox := c.renderer.MeasureText(text)
+	x := box.Right - textBox.Width()
+	y := box.Top + (box.Height()+textBox.Height())/2
+	c.renderer.Text(text, x, y)
+}
+
+/*textLeftAligned writes the given text into the given box so
+that it starts at the left border of the box.*/
+func (c *canvas) textLeftAligned(text string, box chart.Box, fontSize float64, color drawing.Color) {
+	c.renderer.SetFontSize(fontSize)
+	c.renderer.SetFontColor(color)
+	textBox := c.renderer.MeasureText(text)
+	y := box.Top + (box.Height()+textBox.Height())/2
+	c.renderer.Text(text, box.Left, y)
+}
+
+/*textWrappedWithin writes the given text horizontally centered
+into the given box, starting at the top of the box.
+The text is broken into lines at spaces wherever it would
+exceed the width of the box.*/
+func (c *canvas) textWrappedWithin(text string, box chart.Box, fontSize float64, color drawing.Color) {
+
+	c.renderer.SetFontSize(fontSize)
+
+	var lines []string
+	for _, word := range strings.Fields(text) {
+		if len(lines) > 0 {
+			extendedLine := lines[len(lines)-1] + " " + word
+			if c.renderer.MeasureText(extendedLine).Width() <= box.Width() {
+				lines[len(lines)-1] = extendedLine
+				continue
+			}
+		}
+		lines = append(lines, word)
+	}
+
+	lineTop := box.Top
+	for _, line := range lines {
+		lineHeight := c.renderer.MeasureText(line).Height() + chart.DefaultLineSpacing
+		if lineTop+lineHeight > box.Bottom {
+			break
+		}
+		c.textWithin(line, chart.NewBox(lineTop, box.Left, box.Right, lineTop+lineHeight), fontSize, color)
+		lineTop += lineHeight
+	}
+
+}
+
+/*title writes the given title centered at the top of the canvas
+and returns the y position underneath it at which content can be placed.*/
+func (c *canvas) title(title string) int {
+	c.renderer.SetFontSize(canvasTitleFontSize)
+	titleHeight := c.renderer.MeasureText(title).Height()
+	titleBox := chart.NewBox(chart.DefaultTitleTop, 0, c.width, chart.DefaultTitleTop+titleHeight)
+	c.textWithin(title, titleBox, canvasTitleFontSize, chart.DefaultTextColor)
+	return titleBox.Bottom + canvasPadding
+}
+
+/*save writes the canvas as PNG to the given path.*/
+func (c *canvas) save(outFilePath string) error {
+
+	outFile, err := os.Create(outFilePath)
+	if err != nil {
+		return err
+	}
+
+	defer outFile.Close()
+
+	return c.renderer.Save(outFile)
+
+}
+
+/*buildNoDataChart creates a placeholder for a chart with the given title
+that has nothing to show (e.g. a bar chart without any bars),
+exports it to PNG, saves it to the outDir (according to the given
+chart name) and returns the path to the png.*/
+func buildNoDataChart(title string) (string, error) {
+
+	c, err := newCanvas(chart.DefaultChartWidth, chart.DefaultChartHeight/2)
+	if err != nil {
+		return "", err
+	}
+
+	contentTop := c.title(title)
+	c.textWithin("No data", chart.NewBox(contentTop, 0, c.width, c.height-canvasPadding), canvasTitleFontSize, chart.ColorAlternateGray)
+
+	outFilePath := chartFilePath(title)
+	if err := c.save(outFilePath); err != nil {
+		return "", err
+	}
+
+	return outFilePath, nil
+
+}
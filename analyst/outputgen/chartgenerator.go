@@ -23,10 +23,8 @@ const (
 will be used as the chartdata input
 of a BarChart generator.*/
 type BarChartable interface {
+	Chartable
 	GetOrderedBarChartValues() BarChartableValuesOrdered
-	SetTitle(string)
-	// Should raise an error if title is empty
-	Title() string
 }
 
 /*BarChartableValuesOrdered is a slice of structs that will be
@@ -36,10 +34,21 @@ The bars will be rendered on the order of appearance in this slice.*/
 type BarChartableValuesOrdered []BarChartableValue
 
 /*BarChartableValue Is a value containing a label and value which can
-be transformed into a printable bar of a bar chart.*/
+be transformed into a printable bar of a bar chart.
+Highlighted bars will be drawn in a signal color
+to draw attention to them.*/
 type BarChartableValue struct {
-	Value int
-	Label string
+	Value       int
+	Label       string
+	Highlighted bool
+}
+
+/*intValueFormatter formats chart axis values as integers.*/
+func intValueFormatter(v interface{}) string {
+	if typed, isTyped := v.(float64); isTyped {
+		return fmt.Sprintf("%d", int(typed))
+	}
+	return ""
 }
 
 func toPrintableChartValues(barChartable BarChartable) []chart.Value {
@@ -47,9 +56,17 @@ func toPrintableChartValues(barChartable BarChartable) []chart.Value {
 	var chartvalues []chart.Value
 
 	for _, barChartValue := range barChartable.GetOrderedBarChartValues() {
+		var style chart.Style
+		if barChartValue.Highlighted {
+			style = chart.Style{
+				FillColor:   chart.ColorRed,
+				StrokeColor: chart.ColorRed,
+			}
+		}
 		chartvalues = append(chartvalues, chart.Value{
 			Value: float64(barChartValue.Value),
 			Label: strings.Replace(barChartValue.Label, "/", "/ ", -1),
+			Style: style,
 		},
 		)
 	}
@@ -60,13 +77,29 @@ func toPrintableChartValues(barChartable BarChartable) []chart.Value {
 
 /*BuildBarChart creates a bar chart from the given chartdata,
 exports it to PNG, saves it to the outDir (according to the given
-chart name) and returns the path to the png.*/
+chart name) and returns the path to the png.
+If there are no bars, a placeholder is built instead.*/
 func BuildBarChart(chartable BarChartable) (string, error) {
 
+	bars := toPrintableChartValues(chartable)
+	if len(bars) == 0 {
+		log.Printf("\nBar chart %s has no bars. Build a placeholder.", chartable.Title())
+		return buildNoDataChart(chartable.Title())
+	}
+
+	//The y axis always starts at zero. This also makes sure
+	//that the y range is valid if all bars are of the same height.
+	yRangeMax := 1.0
+	for _, bar := range bars {
+		if bar.Value*1.1 > yRangeMax {
+			yRangeMax = bar.Value * 1.1
+		}
+	}
+
 	graph := chart.BarChart{
 		Title:      chartable.Title(),
 		TitleStyle: chart.StyleShow(),
-		Bars:       toPrintableChartValues(chartable),
+		Bars:       bars,
 		XAxis: chart.Style{
 			Show:                true,
 			FontSize:            10,
@@ -77,21 +110,19 @@ func BuildBarChart(chartable BarChartable) (string, error) {
 			Style: chart.Style{
 				Show: true,
 			},
-			// Format y axis values to int
-			ValueFormatter: func(v interface{}) string {
-				if typed, isTyped := v.(float64); isTyped {
-					return fmt.Sprintf("%d", int(typed))
-				}
-				return ""
+			Range: &chart.ContinuousRange{
+				Min: 0,
+				Max: yRangeMax,
 			},
+			// Format y axis values to int
+			ValueFormatter: intValueFormatter,
 		},
 	}
 
-	outFilePath := fmt.Sprintf("%s/%s.png", OutDir, strings.Replace(strings.ToLower(chartable.Title()), " ", "_", -1))
+	outFilePath := chartFilePath(chartable.Title())
 
 	outFile, err := os.Create(outFilePath)
 	if err != nil {
-		log.Fatal(err)
 		return "", err
 	}
 
@@ -99,7 +130,6 @@ func BuildBarChart(chartable BarChartable) (string, error) {
 
 	err = graph.Render(chart.PNG, outFile)
 	if err != nil {
-		log.Fatal(err.Error())
 		return "", err
 	}
 
@@ -0,0 +1,143 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package outputgen
+
+import (
+	"fmt"
+	"os"
+
+	chart "github.com/wcharczuk/go-chart"
+)
+
+/*CurveChartable must be implemented by any type that
+will be used as the chartdata input
+of a CurveChart generator.*/
+type CurveChartable interface {
+	Chartable
+	GetCurveChartValues() CurveChartableValues
+}
+
+/*CurveChartableValues is a set of named curves that will be
+generated by a GetCurveChartValues() method implementation
+of any struct that implements CurveChartable.
+In contrast to a line chart, the x axis of a curve chart is
+a continuous numeric axis rather than a time axis.
+The x axis ranges from XRange[0] to XRange[1],
+the y axis from YRange[0] to YRange[1].
+If AxisFormat is non-empty, the values on both axes will be
+formatted accordingly (e.g. "%.0f%%").*/
+type CurveChartableValues struct {
+	XAxisName  string
+	YAxisName  string
+	XRange     [2]float64
+	YRange     [2]float64
+	AxisFormat string
+	Curves     []CurveChartableCurve
+}
+
+/*CurveChartableCurve is a named curve which passes through the points
+given by XValues and YValues. XValues and YValues must be of the same length
+and XValues must be in ascending order.*/
+type CurveChartableCurve struct {
+	Name    string
+	XValues []float64
+	YValues []float64
+}
+
+/*curveChartTicks divides the given axis range into ten equal steps
+so that the ticks are placed at round values.*/
+func curveChartTicks(axisRange [2]float64, valueFormatter chart.ValueFormatter) []chart.Tick {
+	var ticks []chart.Tick
+	for step := 0; step <= 10; step++ {
+		value := axisRange[0] + float64(step)*(axisRange[1]-axisRange[0])/10
+		ticks = append(ticks, chart.Tick{Value: value, Label: valueFormatter(value)})
+	}
+	return ticks
+}
+
+/*BuildCurveChart creates a curve chart from the given chartdata,
+exports it to PNG, saves it to the outDir (according to the given
+chart name) and returns the path to the png.*/
+func BuildCurveChart(chartable CurveChartable) (string, error) {
+
+	curveChartValues := chartable.GetCurveChartValues()
+
+	var series []chart.Series
+	for curveIdx, curve := range curveChartValues.Curves {
+		if len(curve.XValues) != len(curve.YValues) {
+			err := fmt.Errorf("curve %s has %d x values but %d y values", curve.Name, len(curve.XValues), len(curve.YValues))
+			return "", err
+		}
+		series = append(series, chart.ContinuousSeries{
+			Name: curve.Name,
+			Style: chart.Style{
+				Show:        true,
+				StrokeColor: chart.GetDefaultColor(curveIdx),
+				StrokeWidth: 2.0,
+			},
+			XValues: curve.XValues,
+			YValues: curve.YValues,
+		})
+	}
+
+	valueFormatter := chart.FloatValueFormatter
+	if len(curveChartValues.AxisFormat) > 0 {
+		valueFormatter = func(v interface{}) string {
+			return fmt.Sprintf(curveChartValues.AxisFormat, v.(float64))
+		}
+	}
+
+	graph := chart.Chart{
+		Title:      chartable.Title(),
+		TitleStyle: chart.StyleShow(),
+		//Leave space between the title and the legend
+		Background: chart.Style{
+			Padding: chart.Box{Top: 50},
+		},
+		Series: series,
+		XAxis: chart.XAxis{
+			Name:      curveChartValues.XAxisName,
+			NameStyle: chart.StyleShow(),
+			Style:     chart.StyleShow(),
+			Range: &chart.ContinuousRange{
+				Min: curveChartValues.XRange[0],
+				Max: curveChartValues.XRange[1],
+			},
+			Ticks:          curveChartTicks(curveChartValues.XRange, valueFormatter),
+			ValueFormatter: valueFormatter,
+		},
+		YAxis: chart.YAxis{
+			Name:      curveChartValues.YAxisName,
+			NameStyle: chart.StyleShow(),
+			Style:     chart.StyleShow(),
+			Range: &chart.ContinuousRange{
+				Min: curveChartValues.YRange[0],
+				Max: curveChartValues.YRange[1],
+			},
+			Ticks:          curveChartTicks(curveChartValues.YRange, valueFormatter),
+			ValueFormatter: valueFormatter,
+		},
+	}
+	graph.Elements = []chart.Renderable{chart.Legend(&graph)}
+
+	outFilePath := chartFilePath(chartable.Title())
+
+	outFile, err := os.Create(outFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	defer outFile.Close()
+
+	err = graph.Render(chart.PNG, outFile)
+	if err != nil {
+		return "", err
+	}
+
+	return outFilePath, nil
+
+}
@@ -0,0 +1,156 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package outputgen
+
+import (
+	"fmt"
+	"strings"
+
+	chart "github.com/wcharczuk/go-chart"
+)
+
+const (
+	groupedBarChartWidth       = chart.DefaultChartWidth
+	groupedBarChartHeight      = chart.DefaultChartHeight
+	groupedBarChartLegendSize  = 20
+	groupedBarChartLabelHeight = 40
+	groupedBarChartValueHeight = 15
+	groupedBarChartGroupFill   = 0.8
+)
+
+/*GroupedBarChartable must be implemented by any type that
+will be used as the chartdata input
+of a GroupedBarChart generator.*/
+type GroupedBarChartable interface {
+	Chartable
+	GetGroupedBarChartValues() GroupedBarChartableValues
+}
+
+/*GroupedBarChartableValues is a set of groups of bars that will be
+generated by a GetGroupedBarChartValues() method implementation
+of any struct that implements GroupedBarChartable.
+Each group consists of one bar per series, i.e. the Values of
+every group must be ordered and of the same length as SeriesNames.
+The groups will be rendered in the order of appearance in Groups.*/
+type GroupedBarChartableValues struct {
+	SeriesNames []string
+	Groups      []GroupedBarChartableGroup
+}
+
+/*GroupedBarChartableGroup is a label and the values
+which will be rendered as a cluster of bars next to each other.*/
+type GroupedBarChartableGroup struct {
+	Label  string
+	Values []int
+}
+
+func (g GroupedBarChartableValues) validate() error {
+	for _, group := range g.Groups {
+		if len(group.Values) != len(g.SeriesNames) {
+			return fmt.Errorf("group %s has %d values but there are %d series", group.Label, len(group.Values), len(g.SeriesNames))
+		}
+	}
+	return nil
+}
+
+func (g GroupedBarChartableValues) maxValue() int {
+	maxValue := 0
+	for _, group := range g.Groups {
+		for _, value := range group.Values {
+			if value > maxValue {
+				maxValue = value
+			}
+		}
+	}
+	return maxValue
+}
+
+/*BuildGroupedBarChart creates a bar chart from the given chartdata
+on which the bars of every group are clustered next to each other,
+exports it to PNG, saves it to the outDir (according to the given
+chart name) and returns the path to the png.*/
+func BuildGroupedBarChart(chartable GroupedBarChartable) (string, error) {
+
+	groupedBarChartValues := chartable.GetGroupedBarChartValues()
+	if err := groupedBarChartValues.validate(); err != nil {
+		return "", err
+	}
+
+	groupedBarChart, err := newCanvas(groupedBarChartWidth, groupedBarChartHeight)
+	if err != nil {
+		return "", err
+	}
+
+	legendTop := groupedBarChart.title(chartable.Title())
+	legendLeft := canvasPadding
+	for seriesIdx, seriesName := range groupedBarChartValues.SeriesNames {
+		groupedBarChart.fillBox(
+			chart.NewBox(legendTop+5, legendLeft, legendLeft+groupedBarChartLegendSize-10, legendTop+groupedBarChartLegendSize-5),
+			chart.GetDefaultColor(seriesIdx))
+		legendLeft += groupedBarChartLegendSize
+		legendLabelWidth := 150
+		groupedBarChart.textLeftAligned(
+			seriesName,
+			chart.NewBox(legendTop, legendLeft, legendLeft+legendLabelWidth, legendTop+groupedBarChartLegendSize),
+			canvasFontSize, chart.DefaultTextColor)
+		legendLeft += legendLabelWidth
+	}
+
+	plotTop := legendTop + groupedBarChartLegendSize + groupedBarChartValueHeight
+	plotBottom := groupedBarChartHeight - canvasPadding - groupedBarChartLabelHeight
+	plotLeft := canvasPadding
+	plotRight := groupedBarChartWidth - canvasPadding
+
+	numberOfGroups := len(groupedBarChartValues.Groups)
+	numberOfSeries := len(groupedBarChartValues.SeriesNames)
+	maxValue := groupedBarChartValues.maxValue()
+	if numberOfGroups > 0 && numberOfSeries > 0 {
+
+		groupWidth := (plotRight - plotLeft) / numberOfGroups
+		barWidth := int(float64(groupWidth)*groupedBarChartGroupFill) / numberOfSeries
+		groupPadding := (groupWidth - barWidth*numberOfSeries) / 2
+
+		for groupIdx, group := range groupedBarChartValues.Groups {
+
+			groupLeft := plotLeft + groupIdx*groupWidth
+
+			for seriesIdx, value := range group.Values {
+				barHeight := 0
+				if maxValue > 0 {
+					barHeight = (plotBottom - plotTop) * value / maxValue
+				}
+				barBox := chart.NewBox(
+					plotBottom-barHeight,
+					groupLeft+groupPadding+seriesIdx*barWidth,
+					groupLeft+groupPadding+(seriesIdx+1)*barWidth-1,
+					plotBottom)
+				groupedBarChart.fillBox(barBox, chart.GetDefaultColor(seriesIdx))
+				groupedBarChart.textWithin(
+					fmt.Sprintf("%d", value),
+					chart.NewBox(barBox.Top-groupedBarChartValueHeight, barBox.Left, barBox.Right, barBox.Top),
+					canvasFontSize, chart.DefaultTextColor)
+			}
+
+			//Allow line breaks within repository names
+			groupLabel := strings.Replace(group.Label, "/", "/ ", -1)
+			groupedBarChart.textWrappedWithin(
+				groupLabel,
+				chart.NewBox(plotBottom+5, groupLeft, groupLeft+groupWidth, plotBottom+groupedBarChartLabelHeight),
+				canvasFontSize, chart.DefaultTextColor)
+		}
+	}
+
+	outFilePath := chartFilePath(chartable.Title())
+
+	err = groupedBarChart.save(outFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	return outFilePath, nil
+
+}
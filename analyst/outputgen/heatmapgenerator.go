@@ -0,0 +1,211 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package outputgen
+
+import (
+	"fmt"
+	"math"
+
+	chart "github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/drawing"
+)
+
+const (
+	heatMapWidth       = chart.DefaultChartWidth
+	heatMapHeight      = chart.DefaultChartHeight
+	heatMapLabelWidth  = 80
+	heatMapLabelHeight = 20
+)
+
+var (
+	heatMapColdColor = chart.ColorWhite
+	heatMapHotColor  = chart.ColorBlue
+)
+
+/*HeatMapChartable must be implemented by any type that
+will be used as the chartdata input
+of a HeatMap generator.*/
+type HeatMapChartable interface {
+	Chartable
+	GetHeatMapValues() HeatMapValues
+}
+
+/*HeatMapValues is a two-dimensional grid of values that will be
+generated by a GetHeatMapValues() method implementation
+of any struct that implements HeatMapChartable.
+Values is indexed by row first and by column second
+i.e. Values[rowIdx][columnIdx], whereas the rows will be rendered
+top-down in the order of RowLabels and the columns left to right
+in the order of ColumnLabels.
+If CellFormat is non-empty, every cell will be labeled with its value
+formatted accordingly (e.g. "%.0f").
+Cells with a value of NaN (e.g. because the value is not known yet)
+will be left blank.*/
+type HeatMapValues struct {
+	RowLabels    []string
+	ColumnLabels []string
+	Values       [][]float64
+	CellFormat   string
+}
+
+func (h HeatMapValues) validate() error {
+	if len(h.Values) != len(h.RowLabels) {
+		return fmt.Errorf("heat map has %d rows but %d row labels", len(h.Values), len(h.RowLabels))
+	}
+	for rowIdx, row := range h.Values {
+		if len(row) != len(h.ColumnLabels) {
+			return fmt.Errorf("heat map row %s has %d columns but there are %d column labels", h.RowLabels[rowIdx], len(row), len(h.ColumnLabels))
+		}
+	}
+	return nil
+}
+
+func (h HeatMapValues) maxValue() float64 {
+	maxValue := 0.0
+	for _, row := range h.Values {
+		for _, value := range row {
+			if !math.IsNaN(value) && value > maxValue {
+				maxValue = value
+			}
+		}
+	}
+	return maxValue
+}
+
+/*heatMapColor interpolates linearly between the cold and the hot
+color according to the intensity (0 to 1) given.*/
+func heatMapColor(intensity float64) drawing.Color {
+	interpolate := func(cold uint8, hot uint8) uint8 {
+		return uint8(float64(cold) + intensity*(float64(hot)-float64(cold)))
+	}
+	return drawing.Color{
+		R: interpolate(heatMapColdColor.R, heatMapHotColor.R),
+		G: interpolate(heatMapColdColor.G, heatMapHotColor.G),
+		B: interpolate(heatMapColdColor.B, heatMapHotColor.B),
+		A: 255,
+	}
+}
+
+/*heatMapGrid describes the layout of the cells of a heat map
+between the given top, left, bottom and right pixel coordinates.
+Every cell is equally sized; remainders of the division
+of the grid among the cells are left blank at the bottom and right.*/
+type heatMapGrid struct {
+	top             int
+	left            int
+	bottom          int
+	right           int
+	numberOfRows    int
+	numberOfColumns int
+}
+
+func (h heatMapGrid) cellHeight() int {
+	return (h.bottom - h.top) / h.numberOfRows
+}
+
+func (h heatMapGrid) cellWidth() int {
+	return (h.right - h.left) / h.numberOfColumns
+}
+
+/*cellBox returns the box of the cell in the given row and column,
+leaving one pixel between neighbouring cells.*/
+func (h heatMapGrid) cellBox(rowIdx int, columnIdx int) chart.Box {
+	rowTop := h.top + rowIdx*h.cellHeight()
+	return chart.NewBox(
+		rowTop,
+		h.left+columnIdx*h.cellWidth(),
+		h.left+(columnIdx+1)*h.cellWidth()-1,
+		rowTop+h.cellHeight()-1)
+}
+
+/*rowLabelBox returns the box left of the grid
+the label of the given row is right-aligned within.*/
+func (h heatMapGrid) rowLabelBox(rowIdx int) chart.Box {
+	rowTop := h.top + rowIdx*h.cellHeight()
+	return chart.NewBox(rowTop, canvasPadding, h.left-canvasPadding/2, rowTop+h.cellHeight())
+}
+
+/*columnLabelBox returns the box below the last row
+the label of the given column is centered within.*/
+func (h heatMapGrid) columnLabelBox(columnIdx int) chart.Box {
+	labelsTop := h.top + h.numberOfRows*h.cellHeight()
+	return chart.NewBox(labelsTop, h.left+columnIdx*h.cellWidth(), h.left+(columnIdx+1)*h.cellWidth(), labelsTop+heatMapLabelHeight)
+}
+
+/*BuildHeatMap creates a heat map from the given chartdata,
+exports it to PNG, saves it to the outDir (according to the given
+chart name) and returns the path to the png.
+The cell with the highest value will be drawn in the hot color,
+cells with a value of zero will be drawn in the cold color.*/
+func BuildHeatMap(chartable HeatMapChartable) (string, error) {
+
+	heatMapValues := chartable.GetHeatMapValues()
+	if err := heatMapValues.validate(); err != nil {
+		return "", err
+	}
+
+	heatMap, err := newCanvas(heatMapWidth, heatMapHeight)
+	if err != nil {
+		return "", err
+	}
+
+	grid := heatMapGrid{
+		top:             heatMap.title(chartable.Title()),
+		left:            canvasPadding + heatMapLabelWidth,
+		bottom:          heatMapHeight - canvasPadding - heatMapLabelHeight,
+		right:           heatMapWidth - canvasPadding,
+		numberOfRows:    len(heatMapValues.RowLabels),
+		numberOfColumns: len(heatMapValues.ColumnLabels),
+	}
+	if grid.numberOfRows > 0 && grid.numberOfColumns > 0 {
+
+		maxValue := heatMapValues.maxValue()
+
+		for rowIdx, rowLabel := range heatMapValues.RowLabels {
+
+			heatMap.textRightAligned(rowLabel, grid.rowLabelBox(rowIdx), canvasFontSize, chart.DefaultTextColor)
+
+			for columnIdx, value := range heatMapValues.Values[rowIdx] {
+
+				if math.IsNaN(value) {
+					continue
+				}
+
+				intensity := 0.0
+				if maxValue > 0 {
+					intensity = value / maxValue
+				}
+
+				cellBox := grid.cellBox(rowIdx, columnIdx)
+				heatMap.fillBox(cellBox, heatMapColor(intensity))
+
+				if len(heatMapValues.CellFormat) > 0 {
+					//Make sure the label stays readable on dark cells
+					labelColor := chart.DefaultTextColor
+					if intensity > 0.5 {
+						labelColor = chart.ColorWhite
+					}
+					heatMap.textWithin(fmt.Sprintf(heatMapValues.CellFormat, value), cellBox, canvasFontSize, labelColor)
+				}
+			}
+		}
+
+		for columnIdx, columnLabel := range heatMapValues.ColumnLabels {
+			heatMap.textWithin(columnLabel, grid.columnLabelBox(columnIdx), canvasFontSize, chart.DefaultTextColor)
+		}
+	}
+
+	outFilePath := chartFilePath(chartable.Title())
+
+	err = heatMap.save(outFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	return outFilePath, nil
+
+}
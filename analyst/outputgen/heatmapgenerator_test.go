@@ -0,0 +1,121 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package outputgen
+
+import (
+	"math"
+	"testing"
+
+	chart "github.com/wcharczuk/go-chart"
+)
+
+func TestHeatMapValues(t *testing.T) {
+
+	testCases := []struct {
+		name         string
+		values       HeatMapValues
+		wantErr      bool
+		wantMaxValue float64
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "grid",
+			values: HeatMapValues{
+				RowLabels:    []string{"Monday", "Tuesday"},
+				ColumnLabels: []string{"0h", "1h", "2h"},
+				Values:       [][]float64{{0, 3, 1}, {7, math.NaN(), 2}},
+			},
+			wantMaxValue: 7,
+		},
+		{
+			name: "only unknown values",
+			values: HeatMapValues{
+				RowLabels:    []string{"Monday"},
+				ColumnLabels: []string{"0h"},
+				Values:       [][]float64{{math.NaN()}},
+			},
+			wantMaxValue: 0,
+		},
+		{
+			name: "missing row label",
+			values: HeatMapValues{
+				RowLabels:    []string{"Monday"},
+				ColumnLabels: []string{"0h"},
+				Values:       [][]float64{{1}, {2}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing column label",
+			values: HeatMapValues{
+				RowLabels:    []string{"Monday", "Tuesday"},
+				ColumnLabels: []string{"0h"},
+				Values:       [][]float64{{1}, {2, 3}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.values.validate()
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("validate() = %v, want an error: %t", err, testCase.wantErr)
+			}
+			if testCase.wantErr {
+				return
+			}
+			if got := testCase.values.maxValue(); got != testCase.wantMaxValue {
+				t.Errorf("maxValue() = %v, want %v", got, testCase.wantMaxValue)
+			}
+		})
+	}
+
+}
+
+func TestHeatMapColor(t *testing.T) {
+
+	if got := heatMapColor(0); got != heatMapColdColor {
+		t.Errorf("heatMapColor(0) = %v, want the cold color %v", got, heatMapColdColor)
+	}
+	if got := heatMapColor(1); got.R != heatMapHotColor.R || got.G != heatMapHotColor.G || got.B != heatMapHotColor.B {
+		t.Errorf("heatMapColor(1) = %v, want the hot color %v", got, heatMapHotColor)
+	}
+	if got := heatMapColor(0.5); got.R >= heatMapColdColor.R || got.R <= heatMapHotColor.R || got.A != 255 {
+		t.Errorf("heatMapColor(0.5) = %v, want an opaque color between %v and %v", got, heatMapColdColor, heatMapHotColor)
+	}
+
+}
+
+func TestHeatMapGrid(t *testing.T) {
+
+	//Two rows of 70 and three columns of 80 pixels,
+	//the remaining pixel at the bottom is left blank
+	grid := heatMapGrid{top: 40, left: 100, bottom: 181, right: 340, numberOfRows: 2, numberOfColumns: 3}
+
+	testCases := []struct {
+		name string
+		box  chart.Box
+		want chart.Box
+	}{
+		{"first cell", grid.cellBox(0, 0), chart.NewBox(40, 100, 179, 109)},
+		{"last cell", grid.cellBox(1, 2), chart.NewBox(110, 260, 339, 179)},
+		{"row label", grid.rowLabelBox(1), chart.NewBox(110, canvasPadding, 100-canvasPadding/2, 180)},
+		{"column label", grid.columnLabelBox(1), chart.NewBox(180, 180, 260, 180+heatMapLabelHeight)},
+	}
+
+	for _, testCase := range testCases {
+		got := testCase.box
+		if got.Top != testCase.want.Top || got.Left != testCase.want.Left ||
+			got.Right != testCase.want.Right || got.Bottom != testCase.want.Bottom {
+			t.Errorf("%s is %v, want %v", testCase.name, got, testCase.want)
+		}
+	}
+
+}
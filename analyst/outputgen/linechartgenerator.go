@@ -0,0 +1,187 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package outputgen
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	chart "github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/util"
+)
+
+/*LineChartable must be implemented by any type that
+will be used as the chartdata input
+of a LineChart generator.*/
+type LineChartable interface {
+	Chartable
+	GetLineChartSeries() LineChartableSeriesList
+}
+
+/*LineChartableSeriesList is a slice of series that will be
+generated by a GetLineChartSeries() method implementation
+of any struct that implements LineChartable.
+Each series will be rendered as one line of the chart.*/
+type LineChartableSeriesList []LineChartableSeries
+
+/*LineChartableSeries is a named series of points in time
+and the value at each of these points.
+Times and Values must be of the same length and Times must
+be in ascending order.
+Dashed series (e.g. projections) are drawn with a dashed line.*/
+type LineChartableSeries struct {
+	Name   string
+	Times  []time.Time
+	Values []float64
+	Dashed bool
+}
+
+/*maxValue returns the highest value of any of the series.*/
+func (l LineChartableSeriesList) maxValue() float64 {
+	maxValue := 0.0
+	for _, series := range l {
+		for _, value := range series.Values {
+			if value > maxValue {
+				maxValue = value
+			}
+		}
+	}
+	return maxValue
+}
+
+/*timeRange returns the earliest and the latest point in time of any
+of the series. If none of the series has any points, ok is false.*/
+func (l LineChartableSeriesList) timeRange() (from time.Time, until time.Time, ok bool) {
+	for _, series := range l {
+		for _, pointInTime := range series.Times {
+			if !ok || pointInTime.Before(from) {
+				from = pointInTime
+			}
+			if !ok || pointInTime.After(until) {
+				until = pointInTime
+			}
+			ok = true
+		}
+	}
+	return from, until, ok
+}
+
+func toPrintableChartSeries(lineChartable LineChartable) ([]chart.Series, error) {
+
+	var chartSeries []chart.Series
+
+	for seriesIdx, series := range lineChartable.GetLineChartSeries() {
+		if len(series.Times) != len(series.Values) {
+			return nil, fmt.Errorf("series %s has %d points in time but %d values", series.Name, len(series.Times), len(series.Values))
+		}
+		//go-chart does not accept series without points
+		if len(series.Times) == 0 {
+			log.Printf("\nSeries %s of chart %s has no points. Leave it out.", series.Name, lineChartable.Title())
+			continue
+		}
+		style := chart.Style{
+			Show:        true,
+			StrokeColor: chart.GetDefaultColor(seriesIdx),
+			StrokeWidth: 2.0,
+		}
+		if series.Dashed {
+			style.StrokeDashArray = []float64{5.0, 5.0}
+		}
+		//A single point does not make a line, so it is drawn as a dot
+		if len(series.Times) == 1 {
+			style.DotColor = style.StrokeColor
+			style.DotWidth = 4.0
+		}
+		chartSeries = append(chartSeries, chart.TimeSeries{
+			Name:    series.Name,
+			Style:   style,
+			XValues: series.Times,
+			YValues: series.Values,
+		})
+	}
+
+	return chartSeries, nil
+
+}
+
+/*BuildLineChart creates a line chart from the given chartdata,
+exports it to PNG, saves it to the outDir (according to the given
+chart name) and returns the path to the png.
+The x axis of the chart is a time axis.
+If none of the series has any points, a placeholder is built instead.*/
+func BuildLineChart(chartable LineChartable) (string, error) {
+
+	from, until, ok := chartable.GetLineChartSeries().timeRange()
+	if !ok {
+		log.Printf("\nLine chart %s has no points. Build a placeholder.", chartable.Title())
+		return buildNoDataChart(chartable.Title())
+	}
+
+	series, err := toPrintableChartSeries(chartable)
+	if err != nil {
+		return "", err
+	}
+
+	//The y axis always starts at zero. This also makes sure
+	//that the y range is valid if all values are the same.
+	yRangeMax := chartable.GetLineChartSeries().maxValue() * 1.1
+	if yRangeMax == 0 {
+		yRangeMax = 1
+	}
+
+	graph := chart.Chart{
+		Title:      chartable.Title(),
+		TitleStyle: chart.StyleShow(),
+		//Leave space between the title and the legend
+		Background: chart.Style{
+			Padding: chart.Box{Top: 50},
+		},
+		Series: series,
+		XAxis: chart.XAxis{
+			Style:          chart.StyleShow(),
+			ValueFormatter: chart.TimeDateValueFormatter,
+		},
+		YAxis: chart.YAxis{
+			Style: chart.StyleShow(),
+			Range: &chart.ContinuousRange{
+				Min: 0,
+				Max: yRangeMax,
+			},
+			// Format y axis values to int
+			ValueFormatter: intValueFormatter,
+		},
+	}
+	graph.Elements = []chart.Renderable{chart.Legend(&graph)}
+
+	//If all points are at the same point in time (e.g. a period covering
+	//a single interval), the x axis is widened by three days to either side.
+	if from.Equal(until) {
+		graph.XAxis.Range = &chart.ContinuousRange{
+			Min: util.Time.ToFloat64(from.AddDate(0, 0, -3)),
+			Max: util.Time.ToFloat64(until.AddDate(0, 0, 3)),
+		}
+	}
+
+	outFilePath := chartFilePath(chartable.Title())
+
+	outFile, err := os.Create(outFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	defer outFile.Close()
+
+	err = graph.Render(chart.PNG, outFile)
+	if err != nil {
+		return "", err
+	}
+
+	return outFilePath, nil
+
+}
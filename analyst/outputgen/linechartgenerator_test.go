@@ -0,0 +1,70 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package outputgen
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeRange(t *testing.T) {
+
+	day := func(dayOfMonth int) time.Time {
+		return time.Date(2017, time.October, dayOfMonth, 0, 0, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name      string
+		series    LineChartableSeriesList
+		wantFrom  time.Time
+		wantUntil time.Time
+		wantOk    bool
+	}{
+		{
+			name:   "no series",
+			series: LineChartableSeriesList{},
+		},
+		{
+			name: "series without points",
+			series: LineChartableSeriesList{
+				{Name: "a"},
+				{Name: "b"},
+			},
+		},
+		{
+			name: "single point",
+			series: LineChartableSeriesList{
+				{Name: "a", Times: []time.Time{day(2)}, Values: []float64{1}},
+			},
+			wantFrom:  day(2),
+			wantUntil: day(2),
+			wantOk:    true,
+		},
+		{
+			name: "several series",
+			series: LineChartableSeriesList{
+				{Name: "a", Times: []time.Time{day(3), day(4)}, Values: []float64{1, 2}},
+				{Name: "b"},
+				{Name: "c", Times: []time.Time{day(1), day(2)}, Values: []float64{3, 4}},
+			},
+			wantFrom:  day(1),
+			wantUntil: day(4),
+			wantOk:    true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			from, until, ok := testCase.series.timeRange()
+			if ok != testCase.wantOk || !from.Equal(testCase.wantFrom) || !until.Equal(testCase.wantUntil) {
+				t.Errorf("timeRange() = %s, %s, %t, want %s, %s, %t",
+					from, until, ok, testCase.wantFrom, testCase.wantUntil, testCase.wantOk)
+			}
+		})
+	}
+
+}
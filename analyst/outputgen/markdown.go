@@ -0,0 +1,216 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package outputgen
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	markdownFontName       = "Arial"
+	markdownFontSize       = 10.0
+	markdownLineHeight     = 5.0
+	markdownListIndent     = 6.0
+	markdownBullet         = "\x95" //bullet in the cp1252 encoding of the core fonts
+	markdownHeadingPattern = `^(#{1,3})\s+(.*)$`
+	markdownBulletPattern  = `^[-*+]\s+(.*)$`
+	markdownNumberPattern  = `^(\d+\.)\s+(.*)$`
+)
+
+var (
+	markdownHeadingRegexp = regexp.MustCompile(markdownHeadingPattern)
+	markdownBulletRegexp  = regexp.MustCompile(markdownBulletPattern)
+	markdownNumberRegexp  = regexp.MustCompile(markdownNumberPattern)
+
+	//markdownInlineReplacements converts inline markdown into
+	//the basic HTML supported by gofpdf, in this order
+	markdownInlineReplacements = []struct {
+		pattern     *regexp.Regexp
+		replacement string
+	}{
+		{regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`), `<a href="$2">$1</a>`},
+		{regexp.MustCompile(`\*\*([^*]+)\*\*`), `<b>$1</b>`},
+		{regexp.MustCompile(`__([^_]+)__`), `<b>$1</b>`},
+		{regexp.MustCompile(`\*([^*]+)\*`), `<i>$1</i>`},
+		{regexp.MustCompile(`\b_([^_]+)_\b`), `<i>$1</i>`},
+		{regexp.MustCompile("`([^`]+)`"), `$1`},
+	}
+)
+
+/*markdownHeadingFontSizes are the font sizes of
+headings of level one, two and three.*/
+var markdownHeadingFontSizes = []float64{13, 12, 11}
+
+/*markdownLinkColor is the color of links, as used by gofpdf for HTML.*/
+var markdownLinkColor = []int{0, 0, 128}
+
+/*markdownInlineToHTML converts the inline markdown of a single
+block (i.e. links, bold, italic and code) into basic HTML.
+The text is escaped beforehand, so that e.g. "latency < 5m"
+is not taken for a tag, see writeBasicHTML.*/
+func markdownInlineToHTML(text string) string {
+	text = html.EscapeString(text)
+	for _, inlineReplacement := range markdownInlineReplacements {
+		text = inlineReplacement.pattern.ReplaceAllString(text, inlineReplacement.replacement)
+	}
+	return text
+}
+
+/*writeBasicHTML writes the given basic HTML (as created by markdownInlineToHTML)
+to the PDF at the current position. In contrast to the HTML support of gofpdf,
+the escaped text between the tags is unescaped before writing it.
+Only bold, italic and links are supported.*/
+func writeBasicHTML(pdf *gofpdf.Fpdf, lineHeight float64, basicHTML string) {
+
+	boldLevel, italicLevel := 0, 0
+	linkURL := ""
+
+	setStyle := func(isLink bool) {
+		style := ""
+		if boldLevel > 0 {
+			style += "B"
+		}
+		if italicLevel > 0 {
+			style += "I"
+		}
+		if isLink {
+			style += "U"
+		}
+		pdf.SetFont("", style, 0)
+	}
+
+	for _, segment := range gofpdf.HTMLBasicTokenize(basicHTML) {
+		switch segment.Cat {
+		case 'T':
+			text := html.UnescapeString(segment.Str)
+			if linkURL == "" {
+				pdf.Write(lineHeight, text)
+				continue
+			}
+			textR, textG, textB := pdf.GetTextColor()
+			pdf.SetTextColor(markdownLinkColor[0], markdownLinkColor[1], markdownLinkColor[2])
+			setStyle(true)
+			pdf.WriteLinkString(lineHeight, text, linkURL)
+			setStyle(false)
+			pdf.SetTextColor(textR, textG, textB)
+			linkURL = ""
+		case 'O':
+			switch segment.Str {
+			case "b":
+				boldLevel++
+			case "i":
+				italicLevel++
+			case "a":
+				linkURL = html.UnescapeString(segment.Attr["href"])
+			}
+			setStyle(false)
+		case 'C':
+			switch segment.Str {
+			case "b":
+				boldLevel--
+			case "i":
+				italicLevel--
+			}
+			setStyle(false)
+		}
+	}
+
+}
+
+/*markdownBlock is a paragraph, heading or list item of a markdown text.
+The marker of a list item is its bullet or number.*/
+type markdownBlock struct {
+	headingLevel int
+	marker       string
+	text         string
+}
+
+/*parseMarkdownBlocks splits the given markdown text into blocks.
+Consecutive lines form one block unless separated by an empty line
+or starting a heading or list item.*/
+func parseMarkdownBlocks(markdown string) []markdownBlock {
+
+	var blocks []markdownBlock
+	isBlockOpen := false
+
+	for _, line := range strings.Split(markdown, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			isBlockOpen = false
+			continue
+		}
+
+		if match := markdownHeadingRegexp.FindStringSubmatch(line); match != nil {
+			blocks = append(blocks, markdownBlock{headingLevel: len(match[1]), text: match[2]})
+			isBlockOpen = false
+			continue
+		}
+
+		if match := markdownBulletRegexp.FindStringSubmatch(line); match != nil {
+			blocks = append(blocks, markdownBlock{marker: markdownBullet, text: match[1]})
+			isBlockOpen = true
+			continue
+		}
+
+		if match := markdownNumberRegexp.FindStringSubmatch(line); match != nil {
+			blocks = append(blocks, markdownBlock{marker: match[1], text: match[2]})
+			isBlockOpen = true
+			continue
+		}
+
+		if isBlockOpen {
+			blocks[len(blocks)-1].text += " " + line
+			continue
+		}
+		blocks = append(blocks, markdownBlock{text: line})
+		isBlockOpen = true
+	}
+
+	return blocks
+
+}
+
+/*writeMarkdown writes the given text to the PDF at the current position.
+The text may use a basic subset of markdown: paragraphs, headings (#, ##, ###),
+bulleted and numbered lists, links, bold, italic and code.*/
+func writeMarkdown(pdf *gofpdf.Fpdf, markdown string) {
+
+	leftMargin, _, _, _ := pdf.GetMargins()
+
+	for _, block := range parseMarkdownBlocks(markdown) {
+
+		pdf.SetFont(markdownFontName, "", markdownFontSize)
+
+		switch {
+		case block.headingLevel > 0:
+			pdf.Ln(2)
+			pdf.SetFont(markdownFontName, "B", markdownHeadingFontSizes[block.headingLevel-1])
+			writeBasicHTML(pdf, markdownLineHeight+1, markdownInlineToHTML(block.text))
+		case block.marker != "":
+			pdf.SetX(leftMargin)
+			pdf.Write(markdownLineHeight, block.marker)
+			//Wrapped lines of a list item are indented
+			pdf.SetLeftMargin(leftMargin + markdownListIndent)
+			pdf.SetX(leftMargin + markdownListIndent)
+			writeBasicHTML(pdf, markdownLineHeight, markdownInlineToHTML(block.text))
+			pdf.SetLeftMargin(leftMargin)
+		default:
+			writeBasicHTML(pdf, markdownLineHeight, markdownInlineToHTML(block.text))
+		}
+
+		pdf.Ln(markdownLineHeight)
+		if block.marker == "" {
+			pdf.Ln(1)
+		}
+	}
+
+}
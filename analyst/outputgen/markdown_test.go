@@ -0,0 +1,167 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package outputgen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkdownBlocks(t *testing.T) {
+
+	testCases := []struct {
+		name     string
+		markdown string
+		want     []markdownBlock
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:     "only blank lines",
+			markdown: "\n  \n\n",
+		},
+		{
+			name:     "paragraph lines are joined",
+			markdown: "First line\n  second line\n\nNext paragraph",
+			want: []markdownBlock{
+				{text: "First line second line"},
+				{text: "Next paragraph"},
+			},
+		},
+		{
+			name:     "headings",
+			markdown: "# One\n## Two\n### Three\n#### Four",
+			want: []markdownBlock{
+				{headingLevel: 1, text: "One"},
+				{headingLevel: 2, text: "Two"},
+				{headingLevel: 3, text: "Three"},
+				{text: "#### Four"},
+			},
+		},
+		{
+			name:     "heading ends a paragraph",
+			markdown: "Some text\n# Heading\nMore text",
+			want: []markdownBlock{
+				{text: "Some text"},
+				{headingLevel: 1, text: "Heading"},
+				{text: "More text"},
+			},
+		},
+		{
+			name:     "bullets",
+			markdown: "- dash\n* star\n  continued\n+ plus",
+			want: []markdownBlock{
+				{marker: markdownBullet, text: "dash"},
+				{marker: markdownBullet, text: "star continued"},
+				{marker: markdownBullet, text: "plus"},
+			},
+		},
+		{
+			name:     "numbers",
+			markdown: "1. first\n2. second\n\n10. tenth",
+			want: []markdownBlock{
+				{marker: "1.", text: "first"},
+				{marker: "2.", text: "second"},
+				{marker: "10.", text: "tenth"},
+			},
+		},
+		{
+			name:     "paragraph after a list",
+			markdown: "- item\n\nParagraph",
+			want: []markdownBlock{
+				{marker: markdownBullet, text: "item"},
+				{text: "Paragraph"},
+			},
+		},
+		{
+			name:     "markers need a space",
+			markdown: "-dash\n1.first\n#heading",
+			want: []markdownBlock{
+				{text: "-dash 1.first #heading"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := parseMarkdownBlocks(testCase.markdown); !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("parseMarkdownBlocks() = %#v, want %#v", got, testCase.want)
+			}
+		})
+	}
+
+}
+
+func TestMarkdownInlineToHTML(t *testing.T) {
+
+	testCases := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "plain text",
+			text: "Just text",
+			want: "Just text",
+		},
+		{
+			name: "bold",
+			text: "a **bold** and __bold__ word",
+			want: "a <b>bold</b> and <b>bold</b> word",
+		},
+		{
+			name: "italic",
+			text: "an *italic* and _italic_ word",
+			want: "an <i>italic</i> and <i>italic</i> word",
+		},
+		{
+			name: "underscores within words",
+			text: "user_name and project_name",
+			want: "user_name and project_name",
+		},
+		{
+			name: "code",
+			text: "run `analyst validate`",
+			want: "run analyst validate",
+		},
+		{
+			name: "link",
+			text: "see [the docs](https://example.com/docs)",
+			want: `see <a href="https://example.com/docs">the docs</a>`,
+		},
+		{
+			name: "link with a query",
+			text: "[report](https://example.com/?a=1&b=2)",
+			want: `<a href="https://example.com/?a=1&amp;b=2">report</a>`,
+		},
+		{
+			name: "less than",
+			text: "latency < 5m",
+			want: "latency &lt; 5m",
+		},
+		{
+			name: "tag-like text",
+			text: "<b>not bold</b> & <script>",
+			want: "&lt;b&gt;not bold&lt;/b&gt; &amp; &lt;script&gt;",
+		},
+		{
+			name: "escaped text in bold",
+			text: "**a < b**",
+			want: "<b>a &lt; b</b>",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := markdownInlineToHTML(testCase.text); got != testCase.want {
+				t.Errorf("markdownInlineToHTML() = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+
+}
@@ -6,6 +6,12 @@
 
 package outputgen
 
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
 const (
 
 	//OutDirMode is the file mode of the directory
@@ -17,3 +23,161 @@ const (
 	//to which output artifacts will be written
 	OutDir = "../out"
 )
+
+/*Chartable must be implemented by any type that
+will be used as the chartdata input of a chart generator.
+Every chart generator requires a more specific interface
+(e.g. BarChartable or HeatMapChartable) which embeds Chartable.*/
+type Chartable interface {
+	SetTitle(string)
+	// Should raise an error if title is empty
+	Title() string
+}
+
+/*BuildChart picks the chart generator matching the given chartdata,
+builds the chart and returns the path to the resulting png.*/
+func BuildChart(chartable Chartable) (string, error) {
+
+	switch typedChartable := chartable.(type) {
+	case HeatMapChartable:
+		return BuildHeatMap(typedChartable)
+	case LineChartable:
+		return BuildLineChart(typedChartable)
+	case CurveChartable:
+		return BuildCurveChart(typedChartable)
+	case GroupedBarChartable:
+		return BuildGroupedBarChart(typedChartable)
+	case TableChartable:
+		return BuildTable(typedChartable)
+	case BarChartable:
+		return BuildBarChart(typedChartable)
+	}
+
+	return "", fmt.Errorf("no chart generator found for chartable of type %T", chartable)
+
+}
+
+/*ChartableSummary contains key figures of the values of a chartable:
+the number of elements (i.e. bars, bar groups, series, curves, rows of a
+heat map or table) and the sum of all values shown (zero for curves and tables).*/
+type ChartableSummary struct {
+	Elements int
+	Total    float64
+}
+
+/*Summarize returns the key figures of the values of the given chartdata.
+A SectionChartable is not summarized as its sections
+require the title which the summary is usually used for.*/
+func Summarize(chartable Chartable) ChartableSummary {
+
+	var summary ChartableSummary
+
+	switch typedChartable := chartable.(type) {
+	case SectionChartable:
+		return summary
+	case HeatMapChartable:
+		heatMapValues := typedChartable.GetHeatMapValues()
+		summary.Elements = len(heatMapValues.Values)
+		for _, row := range heatMapValues.Values {
+			for _, value := range row {
+				summary.Total += value
+			}
+		}
+	case LineChartable:
+		seriesList := typedChartable.GetLineChartSeries()
+		summary.Elements = len(seriesList)
+		for _, series := range seriesList {
+			for _, value := range series.Values {
+				summary.Total += value
+			}
+		}
+	case CurveChartable:
+		summary.Elements = len(typedChartable.GetCurveChartValues().Curves)
+	case GroupedBarChartable:
+		groups := typedChartable.GetGroupedBarChartValues().Groups
+		summary.Elements = len(groups)
+		for _, group := range groups {
+			for _, value := range group.Values {
+				summary.Total += float64(value)
+			}
+		}
+	case TableChartable:
+		summary.Elements = len(typedChartable.GetTableValues().Rows)
+	case BarChartable:
+		barChartValues := typedChartable.GetOrderedBarChartValues()
+		summary.Elements = len(barChartValues)
+		for _, barChartValue := range barChartValues {
+			summary.Total += float64(barChartValue.Value)
+		}
+	}
+
+	return summary
+
+}
+
+/*SectionChartable must be implemented by any type that
+consists of several charts which are grouped into
+one or more sections of their own.
+The charts of a section must be titled already.*/
+type SectionChartable interface {
+	Chartable
+	GetSections() []ChartableSection
+}
+
+/*ChartableSection is a titled and described group of charts
+that will be generated by a GetSections() method implementation
+of any struct that implements SectionChartable.*/
+type ChartableSection struct {
+	Title       string
+	Description string
+	Charts      []Chartable
+}
+
+/*BuildSections builds the chart(s) of the given chartdata and returns
+the PDF sections they will be presented in. A SectionChartable results in
+one section per ChartableSection, any other chartable results in
+one untitled section containing its chart.*/
+func BuildSections(chartable Chartable) ([]PDFSection, error) {
+
+	sectionChartable, isSectionChartable := chartable.(SectionChartable)
+	if !isSectionChartable {
+		chartPath, err := BuildChart(chartable)
+		if err != nil {
+			return nil, err
+		}
+		return []PDFSection{{ChartFiles: []string{chartPath}}}, nil
+	}
+
+	var pdfSections []PDFSection
+	for _, section := range sectionChartable.GetSections() {
+		pdfSection := PDFSection{
+			Title:       section.Title,
+			Description: section.Description,
+		}
+		for _, sectionChart := range section.Charts {
+			chartPath, err := BuildChart(sectionChart)
+			if err != nil {
+				return nil, err
+			}
+			pdfSection.ChartFiles = append(pdfSection.ChartFiles, chartPath)
+		}
+		pdfSections = append(pdfSections, pdfSection)
+	}
+
+	return pdfSections, nil
+
+}
+
+/*chartFilePath returns the path within the OutDir
+to which the png of the chart with the given title is written.
+Every character of the title other than a letter, a digit, "." or "-"
+is replaced by "_" in the file name.*/
+func chartFilePath(title string) string {
+	fileName := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(title))
+	return fmt.Sprintf("%s/%s.png", OutDir, fileName)
+}
@@ -0,0 +1,32 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package outputgen
+
+import (
+	"testing"
+)
+
+func TestChartFilePath(t *testing.T) {
+
+	testCases := []struct {
+		title string
+		want  string
+	}{
+		{"Pushes per Repository", OutDir + "/pushes_per_repository.png"},
+		{"Tags of game/server", OutDir + "/tags_of_game_server.png"},
+		{"Profiles: alice: summary", OutDir + "/profiles__alice__summary.png"},
+		{"Latency (p90) of robot$ci", OutDir + "/latency__p90__of_robot_ci.png"},
+		{"Forecast 2017-10-02", OutDir + "/forecast_2017-10-02.png"},
+	}
+
+	for _, testCase := range testCases {
+		if got := chartFilePath(testCase.title); got != testCase.want {
+			t.Errorf("chartFilePath(%q) = %q, want %q", testCase.title, got, testCase.want)
+		}
+	}
+
+}
@@ -15,7 +15,6 @@ import (
 )
 
 const (
-	pdfOutFile     = OutDir + "/report.pdf"
 	pdfOrientation = "Portrait"
 	pdfUnit        = "mm" //millimeters
 	pdfSize        = "A4" //DIN
@@ -31,31 +30,60 @@ const (
  *in this order, (i.e. first the title, then the description then the charts),
  *whereas the charts (within one section)
  *will be printed below each other with nothing inbetween.
+ *The description may use a basic subset of markdown (see writeMarkdown).
+ *Sections of level 0 are the sections of the report, sections of
+ *higher levels are nested in the preceding section and get smaller titles.
+ *If PageBreak is set, the section starts on a new page.
  */
 type PDFSection struct {
 	Title       string
 	Description string
 	ChartFiles  []string
+	Level       int
+	PageBreak   bool
+}
+
+/*sectionTitleFontSizes are the font sizes of
+the titles of sections of level 0, 1 and higher.*/
+var sectionTitleFontSizes = []float64{14, 12}
+
+/*PDFReport describes an
+ *analytics report PDF to be generated:
+ *the name of its file (within the OutDir),
+ *its title, the date it is created as of,
+ *the slack channels it is published to
+ *and its sections.
+ */
+type PDFReport struct {
+	FileName      string
+	Title         string
+	AsOf          time.Time
+	SlackChannels []string
+	Sections      []PDFSection
 }
 
 /*BuildPDF creates a PDF file
- *with the given PDFSections.
+ *of the given PDFReport.
  *The resulting PDF will be written to the
  *outDir directory.
  */
-func BuildPDF(sections ...PDFSection) error {
+func BuildPDF(report PDFReport) error {
 
 	//Create new PDF and ass one page
 	pdf := gofpdf.New(pdfOrientation, pdfUnit, pdfSize, pdfFontDir)
 	pdf.AddPage()
-	setHeader(pdf)
+	setHeader(pdf, report.Title, report.AsOf)
 
-	for _, section := range sections {
+	for idx, section := range report.Sections {
+		//The first section shares the page with the header
+		if section.PageBreak && idx > 0 {
+			pdf.AddPage()
+		}
 		addSection(pdf, section)
 	}
 
 	//Write file
-	err := pdf.OutputFileAndClose(pdfOutFile)
+	err := pdf.OutputFileAndClose(fmt.Sprintf("%s/%s", OutDir, report.FileName))
 	if err != nil {
 		log.Fatal(err.Error())
 		return err
@@ -82,19 +110,35 @@ func addSection(pdf *gofpdf.Fpdf, section PDFSection) {
 	link := 0
 	linkString := ""
 
+	if len(section.Title) > 0 {
+		pdf.Ln(10)
+		sectionTitleFontName := "Arial"
+		sectionTitleFontWeigth := "B"
+		sectionTitleFontSize := sectionTitleFontSizes[len(sectionTitleFontSizes)-1]
+		if section.Level < len(sectionTitleFontSizes) {
+			sectionTitleFontSize = sectionTitleFontSizes[section.Level]
+		}
+		pdf.SetFont(sectionTitleFontName, sectionTitleFontWeigth, sectionTitleFontSize)
+		pdf.MultiCell(width, 8, section.Title, "", "L", false)
+	}
+
+	if len(section.Description) > 0 {
+		writeMarkdown(pdf, section.Description)
+	}
+
 	for _, chartFile := range section.ChartFiles {
 		pdf.ImageOptions(
 			chartFile, xPosition, yPosition, width, hight, flow, gofpdf.ImageOptions{}, link, linkString)
 	}
 }
 
-func setHeader(pdf *gofpdf.Fpdf) {
+func setHeader(pdf *gofpdf.Fpdf, title string, asOf time.Time) {
 
 	titleFontName := "Arial"
 	titleFontWeigth := "B"
 	titleFontSize := 16.0
 	pdf.SetFont(titleFontName, titleFontWeigth, titleFontSize)
-	pdf.Cell(40, 10, "Harbor Analytics Report")
+	pdf.Cell(40, 10, title)
 
 	//Linebreak
 	pdf.Ln(0)
@@ -104,5 +148,5 @@ func setHeader(pdf *gofpdf.Fpdf) {
 	subtitleFontSize := 12.0
 
 	pdf.SetFont(subtitleFontName, subtitleFontWeigth, subtitleFontSize)
-	pdf.Cell(40, 20, fmt.Sprintf("Date of Document Creation: %s", time.Now().Format("2006-01-02 15:04")))
+	pdf.Cell(40, 20, fmt.Sprintf("Report as of: %s", asOf.Format("2006-01-02 15:04")))
 }
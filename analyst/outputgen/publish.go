@@ -0,0 +1,39 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package outputgen
+
+import (
+	"fmt"
+	"os"
+)
+
+//publishListFile is the path to the file listing
+//where the generated reports are published to
+const publishListFile = OutDir + "/publish.txt"
+
+/*WritePublishList writes the list of publishing targets of the given
+reports to the OutDir, one line of "<file name> <slack channel>"
+per report and channel, which is read by slack/send_report.sh.*/
+func WritePublishList(reports []PDFReport) error {
+
+	file, err := os.Create(publishListFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for _, report := range reports {
+		for _, slackChannel := range report.SlackChannels {
+			if _, err := fmt.Fprintf(file, "%s %s\n", report.FileName, slackChannel); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+
+}
@@ -0,0 +1,141 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package outputgen
+
+import (
+	"fmt"
+
+	chart "github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/drawing"
+)
+
+const (
+	tableWidth         = chart.DefaultChartWidth
+	tableRowHeight     = 22
+	tableCellPadding   = 8
+	tableMinColumnSize = 40
+)
+
+var (
+	tableHeaderColor = chart.ColorLightGray
+	tableStripeColor = drawing.Color{R: 248, G: 248, B: 248, A: 255}
+)
+
+/*TableChartable must be implemented by any type that
+will be used as the chartdata input
+of a Table generator.*/
+type TableChartable interface {
+	Chartable
+	GetTableValues() TableValues
+}
+
+/*TableValues is a table of preformatted cells that will be
+generated by a GetTableValues() method implementation
+of any struct that implements TableChartable.
+Every row must have as many cells as there are ColumnNames.
+The rows will be rendered top-down in the order of appearance in Rows.*/
+type TableValues struct {
+	ColumnNames []string
+	Rows        [][]string
+}
+
+func (t TableValues) validate() error {
+	for rowIdx, row := range t.Rows {
+		if len(row) != len(t.ColumnNames) {
+			return fmt.Errorf("table row %d has %d cells but there are %d columns", rowIdx, len(row), len(t.ColumnNames))
+		}
+	}
+	return nil
+}
+
+/*columnWidths distributes the available width among the columns
+in proportion to the widest cell (or column name) of every column.*/
+func (t TableValues) columnWidths(table *canvas, availableWidth int) []int {
+
+	requiredWidths := make([]int, len(t.ColumnNames))
+	requiredTotal := 0
+	for columnIdx, columnName := range t.ColumnNames {
+		requiredWidths[columnIdx] = table.measureText(columnName, canvasFontSize).Width()
+		for _, row := range t.Rows {
+			if cellWidth := table.measureText(row[columnIdx], canvasFontSize).Width(); cellWidth > requiredWidths[columnIdx] {
+				requiredWidths[columnIdx] = cellWidth
+			}
+		}
+		requiredWidths[columnIdx] += 2 * tableCellPadding
+		if requiredWidths[columnIdx] < tableMinColumnSize {
+			requiredWidths[columnIdx] = tableMinColumnSize
+		}
+		requiredTotal += requiredWidths[columnIdx]
+	}
+
+	columnWidths := make([]int, len(t.ColumnNames))
+	for columnIdx, requiredWidth := range requiredWidths {
+		columnWidths[columnIdx] = requiredWidth * availableWidth / requiredTotal
+	}
+
+	return columnWidths
+
+}
+
+/*BuildTable creates a table from the given chartdata,
+exports it to PNG, saves it to the outDir (according to the given
+chart name) and returns the path to the png.
+The height of the PNG depends on the number of rows.*/
+func BuildTable(chartable TableChartable) (string, error) {
+
+	tableValues := chartable.GetTableValues()
+	if err := tableValues.validate(); err != nil {
+		return "", err
+	}
+
+	//The height of the title is not known before drawing it,
+	//so reserve the space of the title font size plus padding
+	headerTop := chart.DefaultTitleTop + int(canvasTitleFontSize*2) + canvasPadding
+	tableHeight := headerTop + (len(tableValues.Rows)+1)*tableRowHeight + canvasPadding
+
+	table, err := newCanvas(tableWidth, tableHeight)
+	if err != nil {
+		return "", err
+	}
+
+	table.title(chartable.Title())
+
+	if len(tableValues.ColumnNames) > 0 {
+
+		columnWidths := tableValues.columnWidths(table, tableWidth-2*canvasPadding)
+
+		drawRow := func(rowTop int, cells []string) {
+			cellLeft := canvasPadding
+			for columnIdx, cell := range cells {
+				cellBox := chart.NewBox(rowTop, cellLeft+tableCellPadding, cellLeft+columnWidths[columnIdx]-tableCellPadding, rowTop+tableRowHeight)
+				table.textLeftAligned(cell, cellBox, canvasFontSize, chart.DefaultTextColor)
+				cellLeft += columnWidths[columnIdx]
+			}
+		}
+
+		table.fillBox(chart.NewBox(headerTop, canvasPadding, tableWidth-canvasPadding, headerTop+tableRowHeight), tableHeaderColor)
+		drawRow(headerTop, tableValues.ColumnNames)
+
+		for rowIdx, row := range tableValues.Rows {
+			rowTop := headerTop + (rowIdx+1)*tableRowHeight
+			if rowIdx%2 == 1 {
+				table.fillBox(chart.NewBox(rowTop, canvasPadding, tableWidth-canvasPadding, rowTop+tableRowHeight), tableStripeColor)
+			}
+			drawRow(rowTop, row)
+		}
+	}
+
+	outFilePath := chartFilePath(chartable.Title())
+
+	err = table.save(outFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	return outFilePath, nil
+
+}
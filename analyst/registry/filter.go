@@ -0,0 +1,180 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"strings"
+)
+
+/*Filter selects the projects, repositories and users a stats method
+takes into account. It is embedded in the parameters struct of every
+stats method, so its fields are configured like any other parameter.
+
+Every entry is a pattern: an entry starting with "^" is a regular
+expression (e.g. "^robot\$" for all users whose name starts with "robot$"),
+any other entry a shell pattern matching the whole name (e.g. "meta/*"),
+so that a plain name matches exactly that name.
+Repositories are matched by their full name, i.e. "project/repository".
+
+If an include-list is given, only the projects, repositories or users
+matching any of its patterns are taken into account. Repositories and
+users matching any pattern of an ignore-list are never taken into account.
+An access (i.e. a push or pull) is only taken into account if
+both its repository and its user are.*/
+type Filter struct {
+	ProjectsToInclude     []string `doc:"Patterns of the only projects to take into account"`
+	RepositoriesToInclude []string `doc:"Patterns of the only repositories to take into account"`
+	RepositoriesToIgnore  []string `doc:"Patterns of repositories not to take into account"`
+	UsersToInclude        []string `doc:"Patterns of the only users to take into account"`
+	UsersToIgnore         []string `doc:"Patterns of users not to take into account"`
+}
+
+/*IsValid checks whether all entries of the Filter are valid patterns.
+If not valid, false and a reason string is returned.*/
+func (f Filter) IsValid() (bool, string) {
+	patternLists := []struct {
+		name     string
+		patterns []string
+	}{
+		{"ProjectsToInclude", f.ProjectsToInclude},
+		{"RepositoriesToInclude", f.RepositoriesToInclude},
+		{"RepositoriesToIgnore", f.RepositoriesToIgnore},
+		{"UsersToInclude", f.UsersToInclude},
+		{"UsersToIgnore", f.UsersToIgnore},
+	}
+	for _, patternList := range patternLists {
+		for _, pattern := range patternList.patterns {
+			if _, err := compileNamePattern(pattern); err != nil {
+				return false, fmt.Sprintf("%s entry \"%s\" is not a valid pattern: %v", patternList.name, pattern, err)
+			}
+		}
+	}
+	return true, ""
+}
+
+/*namePattern matches names either by a regular expression
+or by a shell pattern (see Filter).*/
+type namePattern struct {
+	shellPattern string
+	regexp       *regexp.Regexp
+}
+
+/*compileNamePattern converts the given Filter entry into a namePattern.*/
+func compileNamePattern(pattern string) (namePattern, error) {
+	if strings.HasPrefix(pattern, "^") {
+		compiledRegexp, err := regexp.Compile(pattern)
+		return namePattern{regexp: compiledRegexp}, err
+	}
+	_, err := path.Match(pattern, "")
+	return namePattern{shellPattern: pattern}, err
+}
+
+/*matches checks whether the given name matches the namePattern.*/
+func (n namePattern) matches(name string) bool {
+	if n.regexp != nil {
+		return n.regexp.MatchString(name)
+	}
+	isMatch, _ := path.Match(n.shellPattern, name)
+	return isMatch
+}
+
+/*compileNamePatterns converts the given Filter entries into namePatterns.
+Invalid entries are left out, the Filter is expected to be checked
+with IsValid beforehand.*/
+func compileNamePatterns(patterns []string) []namePattern {
+	var namePatterns []namePattern
+	for _, pattern := range patterns {
+		if namePattern, err := compileNamePattern(pattern); err == nil {
+			namePatterns = append(namePatterns, namePattern)
+		}
+	}
+	return namePatterns
+}
+
+/*matchesAny checks whether the given name matches any of the given namePatterns.*/
+func matchesAny(namePatterns []namePattern, name string) bool {
+	for _, namePattern := range namePatterns {
+		if namePattern.matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+/*compiledFilter is a Filter prepared for being applied in the loops
+of a stats method: its patterns are compiled once and whether a
+repository or user is taken into account is remembered per name.*/
+type compiledFilter struct {
+	projectsToInclude     []namePattern
+	repositoriesToInclude []namePattern
+	repositoriesToIgnore  []namePattern
+	usersToInclude        []namePattern
+	usersToIgnore         []namePattern
+
+	isRepositoryIncluded map[string]bool
+	isUserIncluded       map[string]bool
+}
+
+/*compile prepares the Filter for being applied, see compiledFilter.*/
+func (f Filter) compile() *compiledFilter {
+	return &compiledFilter{
+		projectsToInclude:     compileNamePatterns(f.ProjectsToInclude),
+		repositoriesToInclude: compileNamePatterns(f.RepositoriesToInclude),
+		repositoriesToIgnore:  compileNamePatterns(f.RepositoriesToIgnore),
+		usersToInclude:        compileNamePatterns(f.UsersToInclude),
+		usersToIgnore:         compileNamePatterns(f.UsersToIgnore),
+		isRepositoryIncluded:  map[string]bool{},
+		isUserIncluded:        map[string]bool{},
+	}
+}
+
+/*includesRepository checks whether the repository with the given
+(full) name within the project with the given name is taken into account.*/
+func (c *compiledFilter) includesRepository(projectName string, repositoryName string) bool {
+
+	if isIncluded, isKnown := c.isRepositoryIncluded[repositoryName]; isKnown {
+		return isIncluded
+	}
+
+	isIncluded := (len(c.projectsToInclude) == 0 || matchesAny(c.projectsToInclude, projectName)) &&
+		(len(c.repositoriesToInclude) == 0 || matchesAny(c.repositoriesToInclude, repositoryName)) &&
+		!matchesAny(c.repositoriesToIgnore, repositoryName)
+	if !isIncluded {
+		log.Printf("\nIgnore repository %s.", repositoryName)
+	}
+
+	c.isRepositoryIncluded[repositoryName] = isIncluded
+	return isIncluded
+
+}
+
+/*includesUser checks whether the given user is taken into account.
+An unknown (i.e. nil) user is only taken into account
+if there is no include-list for users.*/
+func (c *compiledFilter) includesUser(user *User) bool {
+
+	if user == nil {
+		return len(c.usersToInclude) == 0
+	}
+	if isIncluded, isKnown := c.isUserIncluded[user.Name]; isKnown {
+		return isIncluded
+	}
+
+	isIncluded := (len(c.usersToInclude) == 0 || matchesAny(c.usersToInclude, user.Name)) &&
+		!matchesAny(c.usersToIgnore, user.Name)
+	if !isIncluded {
+		log.Printf("\nIgnore user %s.", user.Name)
+	}
+
+	c.isUserIncluded[user.Name] = isIncluded
+	return isIncluded
+
+}
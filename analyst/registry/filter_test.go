@@ -0,0 +1,130 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNamePattern(t *testing.T) {
+
+	testCases := []struct {
+		pattern   string
+		name      string
+		wantMatch bool
+		wantErr   bool
+	}{
+		{"meta/healthcheck", "meta/healthcheck", true, false},
+		{"meta/healthcheck", "meta/healthcheck-img", false, false},
+		{"meta/*", "meta/healthcheck", true, false},
+		{"meta/*", "meta/sub/healthcheck", false, false},
+		{"*", "meta/healthcheck", false, false},
+		{"robot$*", "robot$ci", true, false},
+		{"web/front?nd", "web/frontend", true, false},
+		{"^robot\\$", "robot$ci", true, false},
+		{"^robot\\$", "myrobot$ci", false, false},
+		{"^meta/", "meta/sub/healthcheck", true, false},
+		{"meta/[", "meta/[", false, true},
+		{"^meta/(", "meta/(", false, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern+" "+testCase.name, func(t *testing.T) {
+			namePattern, err := compileNamePattern(testCase.pattern)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("compileNamePattern() failed with %v, want an error: %t", err, testCase.wantErr)
+			}
+			if testCase.wantErr {
+				return
+			}
+			if got := namePattern.matches(testCase.name); got != testCase.wantMatch {
+				t.Errorf("matches() = %t, want %t", got, testCase.wantMatch)
+			}
+		})
+	}
+
+}
+
+func TestCompiledFilter(t *testing.T) {
+
+	testCases := []struct {
+		name             string
+		filter           Filter
+		wantRepositories map[string]bool
+		wantUsers        map[string]bool
+		wantUnknownUser  bool
+		wantIsValid      bool
+	}{
+		{
+			name:             "no filter",
+			wantRepositories: map[string]bool{"game/server": true, "meta/healthcheck": true},
+			wantUsers:        map[string]bool{"alice": true, "robot$ci": true},
+			wantUnknownUser:  true,
+			wantIsValid:      true,
+		},
+		{
+			name: "ignore lists",
+			filter: Filter{
+				RepositoriesToIgnore: []string{"meta/healthcheck"},
+				UsersToIgnore:        []string{"^robot\\$"},
+			},
+			wantRepositories: map[string]bool{"game/server": true, "meta/healthcheck": false, "meta/healthcheck-img": true},
+			wantUsers:        map[string]bool{"alice": true, "robot$ci": false},
+			wantUnknownUser:  true,
+			wantIsValid:      true,
+		},
+		{
+			name: "include lists",
+			filter: Filter{
+				ProjectsToInclude:     []string{"game", "meta"},
+				RepositoriesToInclude: []string{"*/server", "meta/*"},
+				RepositoriesToIgnore:  []string{"meta/healthcheck"},
+				UsersToInclude:        []string{"alice"},
+			},
+			wantRepositories: map[string]bool{"game/server": true, "game/client": false, "tools/server": false, "meta/healthcheck": false, "meta/cache": true},
+			wantUsers:        map[string]bool{"alice": true, "robot$ci": false},
+			wantUnknownUser:  false,
+			wantIsValid:      true,
+		},
+		{
+			name:        "invalid pattern",
+			filter:      Filter{UsersToIgnore: []string{"^robot("}},
+			wantIsValid: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			if isValid, reason := testCase.filter.IsValid(); isValid != testCase.wantIsValid {
+				t.Fatalf("IsValid() = %t (%s), want %t", isValid, reason, testCase.wantIsValid)
+			}
+			if !testCase.wantIsValid {
+				return
+			}
+
+			compiledFilter := testCase.filter.compile()
+			for repositoryName, want := range testCase.wantRepositories {
+				projectName := strings.SplitN(repositoryName, "/", 2)[0]
+				if got := compiledFilter.includesRepository(projectName, repositoryName); got != want {
+					t.Errorf("includesRepository(%s) = %t, want %t", repositoryName, got, want)
+				}
+			}
+			for userName, want := range testCase.wantUsers {
+				if got := compiledFilter.includesUser(&User{Name: userName}); got != want {
+					t.Errorf("includesUser(%s) = %t, want %t", userName, got, want)
+				}
+			}
+			if got := compiledFilter.includesUser(nil); got != testCase.wantUnknownUser {
+				t.Errorf("includesUser(nil) = %t, want %t", got, testCase.wantUnknownUser)
+			}
+
+		})
+	}
+
+}
@@ -109,8 +109,14 @@ type Project struct {
 
 /*Registry represents the structure for all
 the date on the Harbor docker registry.
-It contains all projects of the registry mapped to
-their IDs.*/
+It contains the human-readable name of the registry,
+all projects of the registry mapped to
+their IDs, the configuration of how its users
+are classified as humans or automation and the
+mapping of its projects, repositories and users to teams.*/
 type Registry struct {
-	Projects map[int]*Project
+	Name               string
+	Projects           map[int]*Project
+	UserClassification UserClassification
+	TeamMapping        TeamMapping
 }
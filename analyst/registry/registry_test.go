@@ -0,0 +1,100 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"strings"
+	"time"
+)
+
+/*testAccess describes a push or pull of a tag
+of a repository (given by its full name) by a user
+at a time given as "YYYY-MM-DD HH:MM".*/
+type testAccess struct {
+	operation  string
+	repository string
+	tag        string
+	user       string
+	time       string
+}
+
+/*testTime parses a time given as "YYYY-MM-DD" or "YYYY-MM-DD HH:MM".*/
+func testTime(value string) time.Time {
+	layout := "2006-01-02 15:04"
+	if len(value) == len("2006-01-02") {
+		layout = "2006-01-02"
+	}
+	timestamp, err := time.Parse(layout, value)
+	if err != nil {
+		panic(err)
+	}
+	return timestamp
+}
+
+/*newTestRegistry creates a registry containing the given accesses.
+Projects, repositories, tags and users are created as they appear.*/
+func newTestRegistry(accesses []testAccess) *Registry {
+
+	registry := Registry{
+		Name:     "Test",
+		Projects: map[int]*Project{},
+	}
+	projects := map[string]*Project{}
+	users := map[string]*User{}
+
+	for logID, access := range accesses {
+
+		projectName := strings.SplitN(access.repository, "/", 2)[0]
+		project, ok := projects[projectName]
+		if !ok {
+			project = &Project{
+				ID:           len(projects) + 1,
+				Name:         projectName,
+				Repositories: map[string]*Repository{},
+			}
+			projects[projectName] = project
+			registry.Projects[project.ID] = project
+		}
+
+		repository, ok := project.Repositories[access.repository]
+		if !ok {
+			repository = &Repository{
+				ID:   len(project.Repositories) + 1,
+				Name: access.repository,
+				Tags: map[string]*Tag{},
+			}
+			project.Repositories[access.repository] = repository
+		}
+
+		tag, ok := repository.Tags[access.tag]
+		if !ok {
+			tag = &Tag{
+				Name:   access.tag,
+				Pulls:  map[int]*Pull{},
+				Pushes: map[int]*Push{},
+			}
+			repository.Tags[access.tag] = tag
+		}
+
+		user, ok := users[access.user]
+		if !ok {
+			user = &User{ID: len(users) + 1, Name: access.user}
+			users[access.user] = user
+		}
+
+		accessLog := Log{ID: logID, Timestamp: testTime(access.time), User: user}
+		if access.operation == pushOperation {
+			tag.Pushes[logID] = &Push{Log: accessLog}
+		} else {
+			tag.Pulls[logID] = &Pull{Log: accessLog}
+		}
+
+	}
+
+	return &registry
+
+}
@@ -0,0 +1,572 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetPeakAccessRates",
+		Description:   "Table of the peak number of pushes and/or pulls within sliding windows of 1, 5 and 60 minutes.",
+		NewParameters: func() StatsMethodParameters { return &GetPeakAccessRatesParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetPeakAccessRates(params.(*GetPeakAccessRatesParameters))
+		},
+	})
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetBurstWindows",
+		Description:   "Table of the windows of time with the most pushes and/or pulls and the repositories involved.",
+		NewParameters: func() StatsMethodParameters { return &GetBurstWindowsParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetBurstWindows(params.(*GetBurstWindowsParameters))
+		},
+	})
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetMaxAccessRatePerDay",
+		Description:   "Line chart of the highest number of pushes and/or pulls within a sliding window per day.",
+		NewParameters: func() StatsMethodParameters { return &GetMaxAccessRatePerDayParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetMaxAccessRatePerDay(params.(*GetMaxAccessRatePerDayParameters))
+		},
+	})
+}
+
+/*peakAccessRateWindowsInMinutes are the sizes of the sliding windows
+for which GetPeakAccessRates determines the peak number of accesses.*/
+var peakAccessRateWindowsInMinutes = []int{1, 5, 60}
+
+/*burstRepositoriesShown is the number of repositories listed
+per burst window, the remaining ones are summed up.*/
+const burstRepositoriesShown = 3
+
+/*accessEvent is a single push or pull of a tag of a repository.*/
+type accessEvent struct {
+	timestamp      time.Time
+	repositoryName string
+}
+
+/*getAccessEvents returns all pushes, pulls or both (depending on the
+given operation, i.e. "push", "pull" or "any") performed
+between <from> (inclusive) and <until> (exclusive), ordered by time.
+Only the repositories and accesses passing the given filter
+will be taken into account.*/
+func (registry *Registry) getAccessEvents(operation string, filter Filter, from time.Time, until time.Time) []accessEvent {
+
+	var accessEvents []accessEvent
+	compiledFilter := filter.compile()
+
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+
+			for _, tag := range repository.Tags {
+				for _, accessLog := range getLogsByOperation(tag, operation) {
+					if !compiledFilter.includesUser(accessLog.User) {
+						continue
+					}
+					if accessLog.Timestamp.Before(from) || !accessLog.Timestamp.Before(until) {
+						continue
+					}
+					accessEvents = append(accessEvents, accessEvent{
+						timestamp:      accessLog.Timestamp,
+						repositoryName: repository.Name,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(accessEvents, func(idxA, idxB int) bool {
+		return accessEvents[idxA].timestamp.Before(accessEvents[idxB].timestamp)
+	})
+
+	return accessEvents
+
+}
+
+/*accessWindow is a window of time starting with an access
+and the number of accesses within it.*/
+type accessWindow struct {
+	start       time.Time
+	accessCount int
+	//index of the first and (exclusive) last access of the window
+	firstIdx int
+	lastIdx  int
+}
+
+/*getAccessWindows slides a window of the given size over the given
+accesses (which must be ordered by time) and returns one window
+starting at every access. As the number of accesses within a window
+only increases at an access, the window starting at an access
+covers all possible peaks.*/
+func getAccessWindows(accessEvents []accessEvent, windowSize time.Duration) []accessWindow {
+
+	var accessWindows []accessWindow
+
+	lastIdx := 0
+	for firstIdx, firstAccess := range accessEvents {
+		windowEnd := firstAccess.timestamp.Add(windowSize)
+		for lastIdx < len(accessEvents) && accessEvents[lastIdx].timestamp.Before(windowEnd) {
+			lastIdx++
+		}
+		accessWindows = append(accessWindows, accessWindow{
+			start:       firstAccess.timestamp,
+			accessCount: lastIdx - firstIdx,
+			firstIdx:    firstIdx,
+			lastIdx:     lastIdx,
+		})
+	}
+
+	return accessWindows
+
+}
+
+/*getPeakAccessWindow returns the window of the given size with the most
+accesses within the given accesses (which must be ordered by time).
+If there are no accesses at all, false is returned.*/
+func getPeakAccessWindow(accessEvents []accessEvent, windowSize time.Duration) (accessWindow, bool) {
+
+	var peakWindow accessWindow
+	isFound := false
+	for _, window := range getAccessWindows(accessEvents, windowSize) {
+		if !isFound || window.accessCount > peakWindow.accessCount {
+			peakWindow = window
+			isFound = true
+		}
+	}
+
+	return peakWindow, isFound
+
+}
+
+/*PeakAccessRates is a slice of peakAccessRate structs
+containing the size of a sliding window and the highest number
+of accesses within any window of that size.*/
+type PeakAccessRates struct {
+	data      []peakAccessRate
+	operation string
+	title     string
+}
+
+type peakAccessRate struct {
+	windowInMinutes int
+	peakWindow      accessWindow
+}
+
+/*GetTableValues for the PeakAccessRates type converts the
+PeakAccessRates slice into a table of the peak number of accesses
+and the resulting rate per window size that can be used by a table generator.
+The output rows are guaranteed to be ordered.
+This method is a requirement of the outputgen.TableChartable interface.*/
+func (p *PeakAccessRates) GetTableValues() outputgen.TableValues {
+
+	tableValues := outputgen.TableValues{
+		ColumnNames: []string{"Window", fmt.Sprintf("Peak accesses (%s)", p.operation), "Per minute", "Per second", "Peak window start"},
+	}
+
+	for _, peakAccessRate := range p.data {
+		tableValues.Rows = append(tableValues.Rows, []string{
+			fmt.Sprintf("%d min", peakAccessRate.windowInMinutes),
+			fmt.Sprintf("%d", peakAccessRate.peakWindow.accessCount),
+			fmt.Sprintf("%.1f", float64(peakAccessRate.peakWindow.accessCount)/float64(peakAccessRate.windowInMinutes)),
+			fmt.Sprintf("%.2f", float64(peakAccessRate.peakWindow.accessCount)/float64(peakAccessRate.windowInMinutes*60)),
+			peakAccessRate.peakWindow.start.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	return tableValues
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (p *PeakAccessRates) SetTitle(title string) {
+	log.Printf("\nPeakAccessRates :: %v .SetTitle %s", p, title)
+	p.title = title
+	log.Printf("\nNewTitle::%s", p.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (p *PeakAccessRates) Title() string {
+	if len(p.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", p)
+	}
+	return p.title
+}
+
+/*GetPeakAccessRatesParameters is the type
+that provides a wrapper for the parameters passed to the
+GetPeakAccessRates stats function.
+Operation must be one of "push", "pull" or "any".
+This type implements the StatsMethodParameters interface type.*/
+type GetPeakAccessRatesParameters struct {
+	Period
+	Operation string `doc:"Operation to count, one of push, pull or any" enum:"push,pull,any"`
+	Filter
+}
+
+/*IsValid check whether all fields in the GetPeakAccessRatesParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetPeakAccessRatesParameters) IsValid() (bool, string) {
+	if !isValidOperation(g.Operation) {
+		return false, fmt.Sprintf("Operation \"%s\" is not one of push, pull or any", g.Operation)
+	}
+	return g.Filter.IsValid()
+}
+
+/*GetPeakAccessRates generates a struct containing the highest number
+of pushes, pulls or both (depending on <Operation>) performed within
+any sliding window of one, five and sixty minutes since <StartDate>
+according to the given CSV data.
+Check the GetPeakAccessRatesParameters struct for parameters.
+This method is registered as the "GetPeakAccessRates" stats method.*/
+func (registry *Registry) GetPeakAccessRates(params *GetPeakAccessRatesParameters) *PeakAccessRates {
+
+	log.Printf("\nAnalyse :: GetPeakAccessRates :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetPeakAccessRates :: params are invalid :: %s", reason)
+	}
+
+	accessEvents := registry.getAccessEvents(params.Operation, params.Filter, params.StartDate(), params.EndDate())
+
+	peakAccessRates := PeakAccessRates{
+		operation: params.Operation,
+	}
+	for _, windowInMinutes := range peakAccessRateWindowsInMinutes {
+		peakWindow, isFound := getPeakAccessWindow(accessEvents, time.Duration(windowInMinutes)*time.Minute)
+		if !isFound {
+			continue
+		}
+		peakAccessRates.data = append(peakAccessRates.data, peakAccessRate{
+			windowInMinutes: windowInMinutes,
+			peakWindow:      peakWindow,
+		})
+	}
+
+	return &peakAccessRates
+
+}
+
+/*BurstWindows is a slice of burstWindow structs containing
+the windows of time with the most accesses and the repositories
+accessed within them.*/
+type BurstWindows struct {
+	data            []burstWindow
+	windowInMinutes int
+	operation       string
+	title           string
+}
+
+type burstWindow struct {
+	accessWindow
+	accessesPerRepository map[string]int
+}
+
+/*GetTableValues for the BurstWindows type converts the
+BurstWindows slice into a table of the windows, their number
+of accesses and the repositories involved that can be used by a table generator.
+The output rows are guaranteed to be ordered.
+This method is a requirement of the outputgen.TableChartable interface.*/
+func (b *BurstWindows) GetTableValues() outputgen.TableValues {
+
+	tableValues := outputgen.TableValues{
+		ColumnNames: []string{"Window start", fmt.Sprintf("Accesses (%s)", b.operation), "Per minute", "Repositories"},
+	}
+
+	for _, burstWindow := range b.data {
+
+		var repositoryNames []string
+		for repositoryName := range burstWindow.accessesPerRepository {
+			repositoryNames = append(repositoryNames, repositoryName)
+		}
+		sort.Slice(repositoryNames, func(idxA, idxB int) bool {
+			accessCountA := burstWindow.accessesPerRepository[repositoryNames[idxA]]
+			accessCountB := burstWindow.accessesPerRepository[repositoryNames[idxB]]
+			if accessCountA == accessCountB {
+				return repositoryNames[idxA] < repositoryNames[idxB]
+			}
+			return accessCountA > accessCountB
+		})
+
+		var repositories []string
+		for idx, repositoryName := range repositoryNames {
+			if idx == burstRepositoriesShown {
+				repositories = append(repositories, fmt.Sprintf("%d more", len(repositoryNames)-burstRepositoriesShown))
+				break
+			}
+			repositories = append(repositories, fmt.Sprintf("%s (%d)", repositoryName, burstWindow.accessesPerRepository[repositoryName]))
+		}
+
+		tableValues.Rows = append(tableValues.Rows, []string{
+			burstWindow.start.Format("2006-01-02 15:04:05"),
+			fmt.Sprintf("%d", burstWindow.accessCount),
+			fmt.Sprintf("%.1f", float64(burstWindow.accessCount)/float64(b.windowInMinutes)),
+			strings.Join(repositories, ", "),
+		})
+	}
+
+	return tableValues
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (b *BurstWindows) SetTitle(title string) {
+	log.Printf("\nBurstWindows :: %v .SetTitle %s", b, title)
+	b.title = title
+	log.Printf("\nNewTitle::%s", b.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (b *BurstWindows) Title() string {
+	if len(b.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", b)
+	}
+	return b.title
+}
+
+/*GetBurstWindowsParameters is the type
+that provides a wrapper for the parameters passed to the
+GetBurstWindows stats function.
+Operation must be one of "push", "pull" or "any".
+WindowInMinutes is the size of the sliding window.
+This type implements the StatsMethodParameters interface type.*/
+type GetBurstWindowsParameters struct {
+	Period
+	Operation           string `doc:"Operation to count, one of push, pull or any" enum:"push,pull,any"`
+	WindowInMinutes     int    `doc:"Size of the sliding window in minutes"`
+	MaxNumberOfElements int    `doc:"Number of burst windows listed"`
+	Filter
+}
+
+/*IsValid check whether all fields in the GetBurstWindowsParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetBurstWindowsParameters) IsValid() (bool, string) {
+	if !isValidOperation(g.Operation) {
+		return false, fmt.Sprintf("Operation \"%s\" is not one of push, pull or any", g.Operation)
+	}
+	if g.WindowInMinutes < 1 {
+		return false, "WindowInMinutes is less than one"
+	}
+	if g.MaxNumberOfElements < 1 {
+		return false, "MaxNumberOfElements is less than one"
+	}
+	return g.Filter.IsValid()
+}
+
+/*GetBurstWindows generates a struct containing the <MaxNumberOfElements>
+non-overlapping windows of <WindowInMinutes> minutes (since <StartDate>)
+within which the most pushes, pulls or both (depending on <Operation>)
+were performed according to the given CSV data.
+
+Each struct within the list of structs in the data field of the returned
+BurstWindows struct contains the beginning of the window, the number of
+accesses within it and the number of accesses per repository involved.
+Check the GetBurstWindowsParameters struct for parameters.
+This method is registered as the "GetBurstWindows" stats method.*/
+func (registry *Registry) GetBurstWindows(params *GetBurstWindowsParameters) *BurstWindows {
+
+	log.Printf("\nAnalyse :: GetBurstWindows :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetBurstWindows :: params are invalid :: %s", reason)
+	}
+
+	accessEvents := registry.getAccessEvents(params.Operation, params.Filter, params.StartDate(), params.EndDate())
+	windowSize := time.Duration(params.WindowInMinutes) * time.Minute
+
+	accessWindows := getAccessWindows(accessEvents, windowSize)
+	sort.SliceStable(accessWindows, func(idxA, idxB int) bool {
+		return accessWindows[idxA].accessCount > accessWindows[idxB].accessCount
+	})
+
+	burstWindows := BurstWindows{
+		windowInMinutes: params.WindowInMinutes,
+		operation:       params.Operation,
+	}
+
+	//Pick the windows with the most accesses, skipping those overlapping
+	//with a window already picked as they describe the same burst
+	for _, window := range accessWindows {
+		if len(burstWindows.data) >= params.MaxNumberOfElements {
+			break
+		}
+		isOverlapping := false
+		for _, burstWindow := range burstWindows.data {
+			if window.start.Before(burstWindow.start.Add(windowSize)) && burstWindow.start.Before(window.start.Add(windowSize)) {
+				isOverlapping = true
+				break
+			}
+		}
+		if isOverlapping {
+			continue
+		}
+
+		accessesPerRepository := map[string]int{}
+		for _, access := range accessEvents[window.firstIdx:window.lastIdx] {
+			accessesPerRepository[access.repositoryName]++
+		}
+		burstWindows.data = append(burstWindows.data, burstWindow{
+			accessWindow:          window,
+			accessesPerRepository: accessesPerRepository,
+		})
+	}
+
+	return &burstWindows
+
+}
+
+/*MaxAccessRatePerDay is a slice of maxAccessRateOnDay structs
+containing a day and the highest number of accesses within
+any sliding window on that day.*/
+type MaxAccessRatePerDay struct {
+	data            []maxAccessRateOnDay
+	windowInMinutes int
+	operation       string
+	title           string
+}
+
+type maxAccessRateOnDay struct {
+	day         time.Time
+	accessCount int
+}
+
+/*GetLineChartSeries for the MaxAccessRatePerDay type converts the
+MaxAccessRatePerDay slice into a series that can be used by a chart generator.
+This method is a requirement of the outputgen.LineChartable interface.*/
+func (m *MaxAccessRatePerDay) GetLineChartSeries() outputgen.LineChartableSeriesList {
+
+	maxAccessRate := outputgen.LineChartableSeries{
+		Name: fmt.Sprintf("Max. accesses (%s) per %d min", m.operation, m.windowInMinutes),
+	}
+
+	for _, maxAccessRateOnDay := range m.data {
+		maxAccessRate.Times = append(maxAccessRate.Times, maxAccessRateOnDay.day)
+		maxAccessRate.Values = append(maxAccessRate.Values, float64(maxAccessRateOnDay.accessCount))
+	}
+
+	return outputgen.LineChartableSeriesList{maxAccessRate}
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (m *MaxAccessRatePerDay) SetTitle(title string) {
+	log.Printf("\nMaxAccessRatePerDay :: %v .SetTitle %s", m, title)
+	m.title = title
+	log.Printf("\nNewTitle::%s", m.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (m *MaxAccessRatePerDay) Title() string {
+	if len(m.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", m)
+	}
+	return m.title
+}
+
+/*GetMaxAccessRatePerDayParameters is the type
+that provides a wrapper for the parameters passed to the
+GetMaxAccessRatePerDay stats function.
+Operation must be one of "push", "pull" or "any".
+WindowInMinutes is the size of the sliding window.
+This type implements the StatsMethodParameters interface type.*/
+type GetMaxAccessRatePerDayParameters struct {
+	Period
+	Operation       string `doc:"Operation to count, one of push, pull or any" enum:"push,pull,any"`
+	WindowInMinutes int    `doc:"Size of the sliding window in minutes"`
+	Filter
+}
+
+/*IsValid check whether all fields in the GetMaxAccessRatePerDayParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetMaxAccessRatePerDayParameters) IsValid() (bool, string) {
+	if !isValidOperation(g.Operation) {
+		return false, fmt.Sprintf("Operation \"%s\" is not one of push, pull or any", g.Operation)
+	}
+	if g.WindowInMinutes < 1 {
+		return false, "WindowInMinutes is less than one"
+	}
+	return g.Filter.IsValid()
+}
+
+/*GetMaxAccessRatePerDay generates a struct containing, for every day
+since <StartDate>, the highest number of pushes, pulls or both
+(depending on <Operation>) performed within any sliding window of
+<WindowInMinutes> minutes starting on that day according to the given CSV data.
+The list is ordered by time and contains days without any accesses as well.
+Without a <StartDate> the list starts with the day of the first access
+and is empty if there are no accesses at all.
+Check the GetMaxAccessRatePerDayParameters struct for parameters.
+This method is registered as the "GetMaxAccessRatePerDay" stats method.*/
+func (registry *Registry) GetMaxAccessRatePerDay(params *GetMaxAccessRatePerDayParameters) *MaxAccessRatePerDay {
+
+	log.Printf("\nAnalyse :: GetMaxAccessRatePerDay :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetMaxAccessRatePerDay :: params are invalid :: %s", reason)
+	}
+
+	accessEvents := registry.getAccessEvents(params.Operation, params.Filter, params.StartDate(), params.EndDate())
+
+	maxAccessRatePerDay := MaxAccessRatePerDay{
+		windowInMinutes: params.WindowInMinutes,
+		operation:       params.Operation,
+	}
+
+	//Without a startDate the days start with the first access
+	//instead of the zero time
+	firstDay := truncateToInterval(params.StartDate(), dayInterval)
+	if params.StartDate().IsZero() {
+		if len(accessEvents) == 0 {
+			log.Printf("\nGetMaxAccessRatePerDay :: no accesses and no start date, no days to show.")
+			return &maxAccessRatePerDay
+		}
+		firstDay = truncateToInterval(accessEvents[0].timestamp, dayInterval)
+	}
+
+	//Days are keyed by their date since the timestamps of the logs
+	//and the current time are not necessarily in the same location
+	maxAccessCountPerDay := map[string]int{}
+	for _, window := range getAccessWindows(accessEvents, time.Duration(params.WindowInMinutes)*time.Minute) {
+		day := window.start.Format(dayFormat)
+		if window.accessCount > maxAccessCountPerDay[day] {
+			maxAccessCountPerDay[day] = window.accessCount
+		}
+	}
+
+	//Walk through all days up to the endDate so that
+	//days without any accesses show up as zero
+	lastDay := getLastIntervalStart(params.EndDate(), dayInterval)
+	for day := firstDay; !day.After(lastDay); day = addInterval(day, dayInterval) {
+		maxAccessRatePerDay.data = append(maxAccessRatePerDay.data, maxAccessRateOnDay{
+			day:         day,
+			accessCount: maxAccessCountPerDay[day.Format(dayFormat)],
+		})
+	}
+
+	return &maxAccessRatePerDay
+
+}
@@ -0,0 +1,145 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetAccessWindows(t *testing.T) {
+
+	var accessEvents []accessEvent
+	for _, timestamp := range []string{"2017-10-02 10:00", "2017-10-02 10:05", "2017-10-02 10:09", "2017-10-02 10:30"} {
+		accessEvents = append(accessEvents, accessEvent{timestamp: testTime(timestamp), repositoryName: "game/server"})
+	}
+
+	testCases := []struct {
+		name            string
+		accessEvents    []accessEvent
+		windowSize      time.Duration
+		wantCounts      []int
+		wantPeakStart   string
+		wantPeakIsFound bool
+	}{
+		{
+			name:       "no accesses",
+			windowSize: 10 * time.Minute,
+		},
+		{
+			name:            "single access",
+			accessEvents:    accessEvents[:1],
+			windowSize:      10 * time.Minute,
+			wantCounts:      []int{1},
+			wantPeakStart:   "2017-10-02 10:00",
+			wantPeakIsFound: true,
+		},
+		{
+			//The end of a window is exclusive
+			name:            "ten minute windows",
+			accessEvents:    accessEvents,
+			windowSize:      10 * time.Minute,
+			wantCounts:      []int{3, 2, 1, 1},
+			wantPeakStart:   "2017-10-02 10:00",
+			wantPeakIsFound: true,
+		},
+		{
+			name:            "five minute windows",
+			accessEvents:    accessEvents,
+			windowSize:      5 * time.Minute,
+			wantCounts:      []int{1, 2, 1, 1},
+			wantPeakStart:   "2017-10-02 10:05",
+			wantPeakIsFound: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			var counts []int
+			for _, window := range getAccessWindows(testCase.accessEvents, testCase.windowSize) {
+				counts = append(counts, window.accessCount)
+			}
+			if !reflect.DeepEqual(counts, testCase.wantCounts) {
+				t.Errorf("getAccessWindows() counts are %v, want %v", counts, testCase.wantCounts)
+			}
+
+			peakWindow, isFound := getPeakAccessWindow(testCase.accessEvents, testCase.windowSize)
+			if isFound != testCase.wantPeakIsFound {
+				t.Fatalf("getPeakAccessWindow() found %t, want %t", isFound, testCase.wantPeakIsFound)
+			}
+			if isFound && !peakWindow.start.Equal(testTime(testCase.wantPeakStart)) {
+				t.Errorf("getPeakAccessWindow() starts at %s, want %s", peakWindow.start, testCase.wantPeakStart)
+			}
+
+		})
+	}
+
+}
+
+func TestGetMaxAccessRatePerDay(t *testing.T) {
+
+	testCases := []struct {
+		name      string
+		accesses  []testAccess
+		startDate string
+		want      []string
+	}{
+		{
+			name: "no accesses and no start date",
+		},
+		{
+			name:      "no accesses",
+			startDate: "2017-10-02",
+			want:      []string{"2017-10-02: 0", "2017-10-03: 0"},
+		},
+		{
+			name: "single day",
+			accesses: []testAccess{
+				{pushOperation, "game/server", "v1", "alice", "2017-10-03 10:00"},
+				{pushOperation, "game/server", "v2", "alice", "2017-10-03 10:30"},
+			},
+			want: []string{"2017-10-03: 2"},
+		},
+		{
+			name: "days without accesses",
+			accesses: []testAccess{
+				{pushOperation, "game/server", "v1", "alice", "2017-10-02 10:00"},
+				{pushOperation, "game/server", "v2", "alice", "2017-10-03 10:00"},
+				{pushOperation, "game/server", "v3", "alice", "2017-10-03 12:00"},
+			},
+			startDate: "2017-10-01",
+			want:      []string{"2017-10-01: 0", "2017-10-02: 1", "2017-10-03: 1"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			params := GetMaxAccessRatePerDayParameters{
+				Operation:       pushOperation,
+				WindowInMinutes: 60,
+			}
+			if testCase.startDate != "" {
+				params.SetStartDate(testTime(testCase.startDate))
+			}
+			params.SetEndDate(testTime("2017-10-04"))
+
+			var got []string
+			for _, maxAccessRateOnDay := range newTestRegistry(testCase.accesses).GetMaxAccessRatePerDay(&params).data {
+				got = append(got, fmt.Sprintf("%s: %d", maxAccessRateOnDay.day.Format(dayFormat), maxAccessRateOnDay.accessCount))
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("GetMaxAccessRatePerDay() = %v, want %v", got, testCase.want)
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,207 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetActiveUsersOverTime",
+		Description:   "Line chart of the distinct active users per day, week or month.",
+		NewParameters: func() StatsMethodParameters { return &GetActiveUsersOverTimeParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetActiveUsersOverTime(params.(*GetActiveUsersOverTimeParameters))
+		},
+	})
+}
+
+/*ActiveUsersOverTime is a slice of activeUsersInInterval structs
+containing the beginning of a day, week or month and the number
+of distinct users who pushed or pulled within this interval.
+If split by user class, the number of active users is counted
+per user class in addition.*/
+type ActiveUsersOverTime struct {
+	data             []activeUsersInInterval
+	splitByUserClass bool
+	title            string
+}
+
+type activeUsersInInterval struct {
+	intervalStart               time.Time
+	activeUserCount             int
+	activeUserCountPerUserClass map[string]int
+}
+
+/*GetLineChartSeries for the ActiveUsersOverTime type converts the
+ActiveUsersOverTime slice into a series that can be used by a chart generator.
+If split by user class, there is one additional series per user class.
+This method is a requirement of the outputgen.LineChartable interface.*/
+func (a *ActiveUsersOverTime) GetLineChartSeries() outputgen.LineChartableSeriesList {
+
+	activeUsers := outputgen.LineChartableSeries{Name: "Active users"}
+
+	for _, activeUsersInInterval := range a.data {
+		activeUsers.Times = append(activeUsers.Times, activeUsersInInterval.intervalStart)
+		activeUsers.Values = append(activeUsers.Values, float64(activeUsersInInterval.activeUserCount))
+	}
+
+	seriesList := outputgen.LineChartableSeriesList{activeUsers}
+	if !a.splitByUserClass {
+		return seriesList
+	}
+
+	for _, userClass := range userClasses {
+		activeUsersOfClass := outputgen.LineChartableSeries{Name: fmt.Sprintf("Active %s users", userClass)}
+		for _, activeUsersInInterval := range a.data {
+			activeUsersOfClass.Times = append(activeUsersOfClass.Times, activeUsersInInterval.intervalStart)
+			activeUsersOfClass.Values = append(activeUsersOfClass.Values, float64(activeUsersInInterval.activeUserCountPerUserClass[userClass]))
+		}
+		seriesList = append(seriesList, activeUsersOfClass)
+	}
+
+	return seriesList
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (a *ActiveUsersOverTime) SetTitle(title string) {
+	log.Printf("\nActiveUsersOverTime :: %v .SetTitle %s", a, title)
+	a.title = title
+	log.Printf("\nNewTitle::%s", a.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (a *ActiveUsersOverTime) Title() string {
+	if len(a.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", a)
+	}
+	return a.title
+}
+
+/*GetActiveUsersOverTimeParameters is the type
+that provides a wrapper for the parameters passed to the
+GetActiveUsersOverTime stats function.
+Interval must be one of "day", "week" or "month", which
+results in daily, weekly or monthly active users.
+If SplitByUserClass is set the active users will be counted
+per user class in addition (see registry.UserClassification).
+This type implements the StatsMethodParameters interface type.*/
+type GetActiveUsersOverTimeParameters struct {
+	Period
+	Interval string `doc:"Interval to count per, one of day, week or month" enum:"day,week,month"`
+	UserClassSelection
+	SplitByUserClass bool `doc:"Show human and automation users separately"`
+	Filter
+}
+
+/*IsValid check whether all fields in the GetActiveUsersOverTimeParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetActiveUsersOverTimeParameters) IsValid() (bool, string) {
+	if !isValidInterval(g.Interval) {
+		return false, fmt.Sprintf("Interval \"%s\" is not one of day, week or month", g.Interval)
+	}
+	if isValid, reason := g.UserClassSelection.IsValid(); !isValid {
+		return false, reason
+	}
+	return g.Filter.IsValid()
+}
+
+/*GetActiveUsersOverTime generates a struct containing the
+number of active users within every day, ISO week or month
+(depending on <Interval>) since <StartDate> according to the given CSV data.
+A user is active within an interval if they performed
+at least one push or pull within it.
+
+Each struct within the list of structs in the data field of the returned
+ActiveUsersOverTime struct contains the beginning of the interval and
+the number of distinct active users. The list is ordered by time and
+contains intervals without any active users as well, starting with
+the interval of <StartDate> (or, without a <StartDate>, of the first
+activity) and ending with the current interval.
+
+Check the GetActiveUsersOverTimeParameters struct for parameters.
+This method is registered as the "GetActiveUsersOverTime" stats method.*/
+func (registry *Registry) GetActiveUsersOverTime(params *GetActiveUsersOverTimeParameters) *ActiveUsersOverTime {
+
+	log.Printf("\nAnalyse :: GetActiveUsersOverTime :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetActiveUsersOverTime :: params are invalid :: %s", reason)
+	}
+
+	activeUsersPerInterval := map[time.Time]map[string]bool{}
+	firstActivity := params.EndDate()
+	classPerUser := registry.getClassPerUser()
+	compiledFilter := params.Filter.compile()
+
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+			for _, tag := range repository.Tags {
+				for _, accessLog := range getLogsByOperation(tag, anyOperation) {
+					if !compiledFilter.includesUser(accessLog.User) {
+						continue
+					}
+					if !isWithinPeriod(accessLog.Timestamp, params) {
+						log.Printf("\nIgnore access to %s on %s as outside relevant time.", repository.Name, accessLog.Timestamp)
+						continue
+					}
+					if accessLog.User == nil || !isOfUserClass(classPerUser, accessLog.User.Name, params.UserClass) {
+						continue
+					}
+					intervalStart := truncateToInterval(accessLog.Timestamp, params.Interval)
+					if _, ok := activeUsersPerInterval[intervalStart]; !ok {
+						activeUsersPerInterval[intervalStart] = map[string]bool{}
+					}
+					activeUsersPerInterval[intervalStart][accessLog.User.Name] = true
+					if accessLog.Timestamp.Before(firstActivity) {
+						firstActivity = accessLog.Timestamp
+					}
+				}
+			}
+		}
+	}
+
+	activeUsersOverTime := ActiveUsersOverTime{
+		splitByUserClass: params.SplitByUserClass,
+	}
+
+	//Walk through all intervals up to the endDate so that
+	//intervals without any active users show up as zero
+	//starting with the interval of the startDate, if given,
+	//or else with the interval of the first activity
+	firstIntervalStart := truncateToInterval(firstActivity, params.Interval)
+	if !params.StartDate().IsZero() {
+		firstIntervalStart = truncateToInterval(params.StartDate(), params.Interval)
+	}
+	lastIntervalStart := getLastIntervalStart(params.EndDate(), params.Interval)
+	for intervalStart := firstIntervalStart; !intervalStart.After(lastIntervalStart); intervalStart = addInterval(intervalStart, params.Interval) {
+		activeUserCountPerUserClass := map[string]int{}
+		for userName := range activeUsersPerInterval[intervalStart] {
+			activeUserCountPerUserClass[classPerUser[userName]]++
+		}
+		activeUsersOverTime.data = append(activeUsersOverTime.data, activeUsersInInterval{
+			intervalStart:               intervalStart,
+			activeUserCount:             len(activeUsersPerInterval[intervalStart]),
+			activeUserCountPerUserClass: activeUserCountPerUserClass,
+		})
+	}
+
+	return &activeUsersOverTime
+
+}
@@ -0,0 +1,109 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetActiveUsersOverTime(t *testing.T) {
+
+	registry := newTestRegistry([]testAccess{
+		{pushOperation, "game/server", "v1", "alice", "2017-10-02 10:00"},
+		{pullOperation, "game/server", "v1", "bob", "2017-10-02 11:00"},
+		{pushOperation, "game/server", "v2", "alice", "2017-10-02 12:00"},
+		{pullOperation, "game/client", "v1", "robot$ci", "2017-10-04 09:00"},
+		{pushOperation, "game/client", "v1", "bob", "2017-10-16 15:00"},
+	})
+	registry.UserClassification = UserClassification{
+		AutomationNamePatterns: []string{"robot$*"},
+	}
+
+	testCases := []struct {
+		name      string
+		startDate string
+		endDate   string
+		interval  string
+		userClass string
+		want      []activeUsersInInterval
+	}{
+		{
+			name:      "weeks without active users are zero",
+			startDate: "2017-10-01",
+			endDate:   "2017-10-23",
+			interval:  weekInterval,
+			want: []activeUsersInInterval{
+				//The week of the startDate (a Sunday)
+				{
+					intervalStart:               testTime("2017-09-25"),
+					activeUserCountPerUserClass: map[string]int{},
+				},
+				{
+					intervalStart:               testTime("2017-10-02"),
+					activeUserCount:             3,
+					activeUserCountPerUserClass: map[string]int{humanUserClass: 2, automationUserClass: 1},
+				},
+				{
+					intervalStart:               testTime("2017-10-09"),
+					activeUserCountPerUserClass: map[string]int{},
+				},
+				{
+					intervalStart:               testTime("2017-10-16"),
+					activeUserCount:             1,
+					activeUserCountPerUserClass: map[string]int{humanUserClass: 1},
+				},
+			},
+		},
+		{
+			name:      "single interval",
+			startDate: "2017-10-02",
+			endDate:   "2017-10-03",
+			interval:  dayInterval,
+			userClass: humanUserClass,
+			want: []activeUsersInInterval{
+				{
+					intervalStart:               testTime("2017-10-02"),
+					activeUserCount:             2,
+					activeUserCountPerUserClass: map[string]int{humanUserClass: 2},
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			params := GetActiveUsersOverTimeParameters{
+				Interval:           testCase.interval,
+				UserClassSelection: UserClassSelection{UserClass: testCase.userClass},
+				SplitByUserClass:   true,
+			}
+			params.SetStartDate(testTime(testCase.startDate))
+			params.SetEndDate(testTime(testCase.endDate))
+
+			got := registry.GetActiveUsersOverTime(&params)
+			if !reflect.DeepEqual(got.data, testCase.want) {
+				t.Errorf("GetActiveUsersOverTime() = %v, want %v", got.data, testCase.want)
+			}
+
+			//One series for all users and one per user class,
+			//each with a point per interval
+			seriesList := got.GetLineChartSeries()
+			if len(seriesList) != 1+len(userClasses) {
+				t.Errorf("got %d series, want %d", len(seriesList), 1+len(userClasses))
+			}
+			for _, series := range seriesList {
+				if len(series.Times) != len(testCase.want) {
+					t.Errorf("series %s has %d points, want %d", series.Name, len(series.Times), len(testCase.want))
+				}
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,330 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetActivityAnomalies",
+		Description:   "Table of the days on which a repository, user or team deviated strongly from its baseline.",
+		NewParameters: func() StatsMethodParameters { return &GetActivityAnomaliesParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetActivityAnomalies(params.(*GetActivityAnomaliesParameters))
+		},
+	})
+}
+
+/*madToStandardDeviation scales the median absolute deviation
+to be comparable to the standard deviation of normally distributed values.*/
+const madToStandardDeviation = 1.4826
+
+const dayFormat = "2006-01-02"
+
+/*ActivityAnomalies is a slice of activityAnomaly structs
+containing the days on which the activity of a repository, user or team
+deviated strongly from its baseline.*/
+type ActivityAnomalies struct {
+	data      []activityAnomaly
+	groupBy   string
+	operation string
+	title     string
+}
+
+type activityAnomaly struct {
+	day            time.Time
+	name           string
+	accessCount    int
+	baselineMedian float64
+	score          float64
+	//the user (for repositories) or the repository (for users and teams)
+	//which accounts for most of the accesses on that day
+	topContributor      string
+	topContributorCount int
+}
+
+/*GetTableValues for the ActivityAnomalies type converts the
+ActivityAnomalies slice into a table listing every anomaly
+with its baseline, score and context that can be used by a table generator.
+The output rows are guaranteed to be ordered.
+This method is a requirement of the outputgen.TableChartable interface.*/
+func (a *ActivityAnomalies) GetTableValues() outputgen.TableValues {
+
+	nameColumnName, contextColumnName := "Repository", "Top user"
+	switch a.groupBy {
+	case userGroupBy:
+		nameColumnName, contextColumnName = "User", "Top repository"
+	case teamGroupBy:
+		nameColumnName, contextColumnName = "Team", "Top repository"
+	}
+
+	tableValues := outputgen.TableValues{
+		ColumnNames: []string{
+			"Day",
+			nameColumnName,
+			fmt.Sprintf("Accesses (%s)", a.operation),
+			"Baseline (median)",
+			"Score",
+			contextColumnName,
+		},
+	}
+
+	for _, anomaly := range a.data {
+		context := "-"
+		if anomaly.topContributorCount > 0 {
+			context = fmt.Sprintf("%s (%d)", anomaly.topContributor, anomaly.topContributorCount)
+		}
+		tableValues.Rows = append(tableValues.Rows, []string{
+			anomaly.day.Format("2006-01-02 Mon"),
+			anomaly.name,
+			fmt.Sprintf("%d", anomaly.accessCount),
+			fmt.Sprintf("%.1f", anomaly.baselineMedian),
+			fmt.Sprintf("%+.1f", anomaly.score),
+			context,
+		})
+	}
+
+	return tableValues
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (a *ActivityAnomalies) SetTitle(title string) {
+	log.Printf("\nActivityAnomalies :: %v .SetTitle %s", a, title)
+	a.title = title
+	log.Printf("\nNewTitle::%s", a.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (a *ActivityAnomalies) Title() string {
+	if len(a.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", a)
+	}
+	return a.title
+}
+
+/*GetActivityAnomaliesParameters is the type
+that provides a wrapper for the parameters passed to the
+GetActivityAnomalies stats function.
+Operation must be one of "push", "pull" or "any" and GroupBy
+one of "repository", "user" or "team" (see registry.TeamMapping).
+The baseline of every day consists of the <BaselineWindowInDays> days
+before it. A day is an anomaly if its score (the deviation from the
+baseline median in units of the scaled median absolute deviation)
+is at least <Threshold> in either direction.
+This type implements the StatsMethodParameters interface type.*/
+type GetActivityAnomaliesParameters struct {
+	Period
+	Operation            string `doc:"Operation to count, one of push, pull or any" enum:"push,pull,any"`
+	GroupBy              string `doc:"Dimension to group by, one of repository, user or team" enum:"repository,user,team"`
+	BaselineWindowInDays int    `doc:"Number of days before a day its baseline consists of (at least 3)"`
+	Threshold            int    `doc:"Minimum absolute score of a day to be an anomaly"`
+	MaxNumberOfElements  int    `doc:"Number of anomalies listed"`
+	UserClassSelection
+	Filter
+}
+
+/*IsValid check whether all fields in the GetActivityAnomaliesParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetActivityAnomaliesParameters) IsValid() (bool, string) {
+	if !isValidOperation(g.Operation) {
+		return false, fmt.Sprintf("Operation \"%s\" is not one of push, pull or any", g.Operation)
+	}
+	if !isValidGroupBy(g.GroupBy) {
+		return false, fmt.Sprintf("GroupBy \"%s\" is not one of repository, user or team", g.GroupBy)
+	}
+	if g.BaselineWindowInDays < 3 {
+		return false, "BaselineWindowInDays is less than three"
+	}
+	if g.Threshold < 1 {
+		return false, "Threshold is less than one"
+	}
+	if g.MaxNumberOfElements < 1 {
+		return false, "MaxNumberOfElements is less than one"
+	}
+	if isValid, reason := g.UserClassSelection.IsValid(); !isValid {
+		return false, reason
+	}
+	return g.Filter.IsValid()
+}
+
+/*GetActivityAnomalies generates a struct containing the
+<MaxNumberOfElements> days (since <StartDate>) on which the number of
+pushes, pulls or both (depending on <Operation>) of a repository, a user
+or a team (depending on <GroupBy>) deviated the most from its rolling baseline
+according to the given CSV data.
+
+The score of a day is its deviation from the median of the
+<BaselineWindowInDays> days before it, divided by the median absolute
+deviation of these days (scaled to be comparable to a standard deviation).
+As the number of accesses per day is an integer, the divisor is at least one.
+The baselines start with the day of the first access at the earliest,
+so without a <StartDate> the first days after it are not scored.
+Days with a score of at least <Threshold> (e.g. runaway CI loops) or
+at most -<Threshold> (e.g. outages) are anomalies.
+For every anomaly, the user (for repositories) or repository (for users
+and teams) with the most accesses on that day is given as context.
+
+Check the GetActivityAnomaliesParameters struct for parameters.
+This method is registered as the "GetActivityAnomalies" stats method.*/
+func (registry *Registry) GetActivityAnomalies(params *GetActivityAnomaliesParameters) *ActivityAnomalies {
+
+	log.Printf("\nAnalyse :: GetActivityAnomalies :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetActivityAnomalies :: params are invalid :: %s", reason)
+	}
+
+	firstDay := truncateToInterval(params.StartDate(), dayInterval)
+	baselineStart := firstDay.AddDate(0, 0, -1*params.BaselineWindowInDays)
+	firstAccess := params.EndDate()
+	classPerUser := registry.getClassPerUser()
+	compiledFilter := params.Filter.compile()
+	compiledTeamMapping := registry.TeamMapping.compile()
+
+	//accesses per name (repository, user or team), day and contributor.
+	//Days are keyed by their date since the timestamps of the logs
+	//and the start date are not necessarily in the same location.
+	accessesPerNameAndDay := map[string]map[string]map[string]int{}
+
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+
+			for _, tag := range repository.Tags {
+				for _, accessLog := range getLogsByOperation(tag, params.Operation) {
+					if accessLog.Timestamp.Before(baselineStart) || !accessLog.Timestamp.Before(params.EndDate()) {
+						continue
+					}
+					if accessLog.User == nil || !isOfUserClass(classPerUser, accessLog.User.Name, params.UserClass) {
+						continue
+					}
+					if !compiledFilter.includesUser(accessLog.User) {
+						continue
+					}
+
+					name, contributor := repository.Name, accessLog.User.Name
+					switch params.GroupBy {
+					case userGroupBy:
+						name, contributor = accessLog.User.Name, repository.Name
+					case teamGroupBy:
+						name = compiledTeamMapping.getTeamOfAccess(project.Name, repository.Name, accessLog.User)
+						contributor = repository.Name
+					}
+					day := accessLog.Timestamp.Format(dayFormat)
+					if _, ok := accessesPerNameAndDay[name]; !ok {
+						accessesPerNameAndDay[name] = map[string]map[string]int{}
+					}
+					if _, ok := accessesPerNameAndDay[name][day]; !ok {
+						accessesPerNameAndDay[name][day] = map[string]int{}
+					}
+					accessesPerNameAndDay[name][day][contributor]++
+					if accessLog.Timestamp.Before(firstAccess) {
+						firstAccess = accessLog.Timestamp
+					}
+				}
+			}
+		}
+	}
+
+	//The days before the first access (e.g. all days since the zero time
+	//if there is no start date) would only add zeros to the baselines
+	firstAccessDay := time.Date(firstAccess.Year(), firstAccess.Month(), firstAccess.Day(), 0, 0, 0, 0, baselineStart.Location())
+	if firstAccessDay.After(baselineStart) {
+		baselineStart = firstAccessDay
+	}
+
+	activityAnomalies := ActivityAnomalies{
+		groupBy:   params.GroupBy,
+		operation: params.Operation,
+	}
+
+	lastDay := getLastIntervalStart(params.EndDate(), dayInterval)
+	for name, accessesPerDay := range accessesPerNameAndDay {
+
+		//Days without any accesses count as zero so that the
+		//baseline of a rarely used repository or user stays low
+		var accessCounts []float64
+		for day := baselineStart; !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+			accessCount := 0
+			for _, contributorAccessCount := range accessesPerDay[day.Format(dayFormat)] {
+				accessCount += contributorAccessCount
+			}
+			accessCounts = append(accessCounts, float64(accessCount))
+		}
+
+		for dayIdx := params.BaselineWindowInDays; dayIdx < len(accessCounts); dayIdx++ {
+
+			baseline := accessCounts[dayIdx-params.BaselineWindowInDays : dayIdx]
+			baselineMedian := median(baseline)
+			var absoluteDeviations []float64
+			for _, accessCount := range baseline {
+				absoluteDeviations = append(absoluteDeviations, math.Abs(accessCount-baselineMedian))
+			}
+			scale := math.Max(1, madToStandardDeviation*median(absoluteDeviations))
+			score := (accessCounts[dayIdx] - baselineMedian) / scale
+
+			if math.Abs(score) < float64(params.Threshold) {
+				continue
+			}
+
+			day := baselineStart.AddDate(0, 0, dayIdx)
+			anomaly := activityAnomaly{
+				day:            day,
+				name:           name,
+				accessCount:    int(accessCounts[dayIdx]),
+				baselineMedian: baselineMedian,
+				score:          score,
+			}
+			for contributor, contributorAccessCount := range accessesPerDay[day.Format(dayFormat)] {
+				if contributorAccessCount > anomaly.topContributorCount ||
+					(contributorAccessCount == anomaly.topContributorCount && contributor < anomaly.topContributor) {
+					anomaly.topContributor = contributor
+					anomaly.topContributorCount = contributorAccessCount
+				}
+			}
+			activityAnomalies.data = append(activityAnomalies.data, anomaly)
+		}
+	}
+
+	//Sort the elements in the data slice by the absolute score descendingly,
+	//by day and name otherwise
+	sort.Slice(activityAnomalies.data, func(idxA, idxB int) bool {
+		elementA := activityAnomalies.data[idxA]
+		elementB := activityAnomalies.data[idxB]
+		if math.Abs(elementA.score) != math.Abs(elementB.score) {
+			return math.Abs(elementA.score) > math.Abs(elementB.score)
+		}
+		if !elementA.day.Equal(elementB.day) {
+			return elementA.day.Before(elementB.day)
+		}
+		return elementA.name < elementB.name
+	})
+
+	//Trim the output slice to the size defined in MaxNumberOfElements
+	//which will determine the number of rows shown in the table
+	if int(params.MaxNumberOfElements) < len(activityAnomalies.data) {
+		activityAnomalies.data = activityAnomalies.data[:params.MaxNumberOfElements]
+	}
+
+	return &activityAnomalies
+
+}
@@ -0,0 +1,141 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMedian(t *testing.T) {
+
+	testCases := []struct {
+		values []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{3}, 3},
+		{[]float64{5, 1, 3}, 3},
+		{[]float64{4, 1, 3, 2}, 2.5},
+		{[]float64{0, 0, 0, 7}, 0},
+	}
+
+	for _, testCase := range testCases {
+		values := append([]float64{}, testCase.values...)
+		if got := median(values); got != testCase.want {
+			t.Errorf("median(%v) = %v, want %v", testCase.values, got, testCase.want)
+		}
+		for idx := range values {
+			if values[idx] != testCase.values[idx] {
+				t.Errorf("median(%v) changed the order of the values to %v", testCase.values, values)
+				break
+			}
+		}
+	}
+
+}
+
+func TestGetActivityAnomalies(t *testing.T) {
+
+	var accesses []testAccess
+	for day := 1; day <= 20; day++ {
+		//Two pushes a day, but a runaway loop on 2017-10-12
+		//and an outage on 2017-10-16
+		pushCount := 2
+		switch day {
+		case 12:
+			pushCount = 20
+		case 16:
+			pushCount = 0
+		}
+		for idx := 0; idx < pushCount; idx++ {
+			accesses = append(accesses, testAccess{pushOperation, "game/server", "latest", "alice",
+				fmt.Sprintf("2017-10-%02d %02d:%02d", day, 8+idx/60, idx%60)})
+		}
+	}
+	//Varying pulls, so that the outage of a single day is not unusual
+	for day := 1; day <= 20; day++ {
+		for idx := 0; idx < day%4; idx++ {
+			accesses = append(accesses, testAccess{pullOperation, "game/server", "latest", "bob",
+				fmt.Sprintf("2017-10-%02d 12:%02d", day, idx)})
+		}
+	}
+	registry := newTestRegistry(accesses)
+
+	testCases := []struct {
+		name      string
+		startDate string
+		operation string
+		want      []activityAnomaly
+	}{
+		{
+			name:      "no start date",
+			operation: pushOperation,
+			want: []activityAnomaly{
+				{day: testTime("2017-10-12"), name: "alice", accessCount: 20, baselineMedian: 2, score: 18},
+				{day: testTime("2017-10-16"), name: "alice", accessCount: 0, baselineMedian: 2, score: -2},
+			},
+		},
+		{
+			name:      "start date after the runaway loop",
+			startDate: "2017-10-13",
+			operation: pushOperation,
+			want: []activityAnomaly{
+				{day: testTime("2017-10-16"), name: "alice", accessCount: 0, baselineMedian: 2, score: -2},
+			},
+		},
+		{
+			name:      "pulls",
+			operation: pullOperation,
+			want:      nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			params := GetActivityAnomaliesParameters{
+				Operation:            testCase.operation,
+				GroupBy:              userGroupBy,
+				BaselineWindowInDays: 7,
+				Threshold:            2,
+				MaxNumberOfElements:  5,
+			}
+			if testCase.startDate != "" {
+				params.SetStartDate(testTime(testCase.startDate))
+			}
+			params.SetEndDate(testTime("2017-10-21"))
+
+			done := make(chan *ActivityAnomalies)
+			go func() { done <- registry.GetActivityAnomalies(&params) }()
+			var got *ActivityAnomalies
+			select {
+			case got = <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("GetActivityAnomalies() did not finish within 10s")
+			}
+
+			if len(got.data) != len(testCase.want) {
+				t.Fatalf("GetActivityAnomalies() = %v, want %v", got.data, testCase.want)
+			}
+			for idx, anomaly := range got.data {
+				want := testCase.want[idx]
+				if !anomaly.day.Equal(want.day) || anomaly.name != want.name || anomaly.accessCount != want.accessCount ||
+					anomaly.baselineMedian != want.baselineMedian || math.Abs(anomaly.score-want.score) > 1e-9 {
+					t.Errorf("anomaly %d is %v, want %v", idx, anomaly, want)
+				}
+				if anomaly.accessCount > 0 && anomaly.topContributor != "game/server" {
+					t.Errorf("anomaly %d has top contributor %s, want game/server", idx, anomaly.topContributor)
+				}
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,262 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetActivityOverTime",
+		Description:   "Line chart of the pushes and/or pulls per day, week or month, optionally split by team.",
+		NewParameters: func() StatsMethodParameters { return &GetActivityOverTimeParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetActivityOverTime(params.(*GetActivityOverTimeParameters))
+		},
+	})
+}
+
+/*ActivityOverTime is a slice of activityInInterval structs
+containing the beginning of a day, week or month and the number
+of pushes to and pulls from the registry within this interval.*/
+type ActivityOverTime struct {
+	data        []activityInInterval
+	operation   string
+	splitByTeam bool
+	teamNames   []string
+	title       string
+}
+
+type activityInInterval struct {
+	intervalStart time.Time
+	pushCount     int
+	pullCount     int
+	//only set if split by team
+	accessCountPerTeam map[string]int
+}
+
+/*GetLineChartSeries for the ActivityOverTime type converts the
+ActivityOverTime slice into one series for pushes and/or one series
+for pulls (depending on the operation the stats were generated for)
+that can be used by a chart generator.
+If the stats were split by team, there is one series per team instead,
+ordered by the number of accesses of the team descendingly.
+This method is a requirement of the outputgen.LineChartable interface.*/
+func (a *ActivityOverTime) GetLineChartSeries() outputgen.LineChartableSeriesList {
+
+	if a.splitByTeam {
+		var seriesList outputgen.LineChartableSeriesList
+		for _, teamName := range a.teamNames {
+			teamSeries := outputgen.LineChartableSeries{Name: teamName}
+			for _, activityInInterval := range a.data {
+				teamSeries.Times = append(teamSeries.Times, activityInInterval.intervalStart)
+				teamSeries.Values = append(teamSeries.Values, float64(activityInInterval.accessCountPerTeam[teamName]))
+			}
+			seriesList = append(seriesList, teamSeries)
+		}
+		return seriesList
+	}
+
+	pushes := outputgen.LineChartableSeries{Name: "Pushes"}
+	pulls := outputgen.LineChartableSeries{Name: "Pulls"}
+
+	for _, activityInInterval := range a.data {
+		pushes.Times = append(pushes.Times, activityInInterval.intervalStart)
+		pushes.Values = append(pushes.Values, float64(activityInInterval.pushCount))
+		pulls.Times = append(pulls.Times, activityInInterval.intervalStart)
+		pulls.Values = append(pulls.Values, float64(activityInInterval.pullCount))
+	}
+
+	var series outputgen.LineChartableSeriesList
+	if a.operation == pushOperation || a.operation == anyOperation {
+		series = append(series, pushes)
+	}
+	if a.operation == pullOperation || a.operation == anyOperation {
+		series = append(series, pulls)
+	}
+
+	return series
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (a *ActivityOverTime) SetTitle(title string) {
+	log.Printf("\nActivityOverTime :: %v .SetTitle %s", a, title)
+	a.title = title
+	log.Printf("\nNewTitle::%s", a.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (a *ActivityOverTime) Title() string {
+	if len(a.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", a)
+	}
+	return a.title
+}
+
+/*GetActivityOverTimeParameters is the type
+that provides a wrapper for the parameters passed to the
+GetActivityOverTime stats function.
+Operation must be one of "push", "pull" or "any",
+Interval must be one of "day", "week" or "month".
+If SplitByTeam is set the accesses will be shown per team
+instead of per operation (see registry.TeamMapping).
+This type implements the StatsMethodParameters interface type.*/
+type GetActivityOverTimeParameters struct {
+	Period
+	Operation   string `doc:"Operation to count, one of push, pull or any" enum:"push,pull,any"`
+	Interval    string `doc:"Interval to count per, one of day, week or month" enum:"day,week,month"`
+	SplitByTeam bool   `doc:"Show the accesses of every team separately instead of pushes and pulls"`
+	Filter
+}
+
+/*IsValid check whether all fields in the GetActivityOverTimeParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetActivityOverTimeParameters) IsValid() (bool, string) {
+	if !isValidOperation(g.Operation) {
+		return false, fmt.Sprintf("Operation \"%s\" is not one of push, pull or any", g.Operation)
+	}
+	if !isValidInterval(g.Interval) {
+		return false, fmt.Sprintf("Interval \"%s\" is not one of day, week or month", g.Interval)
+	}
+	return g.Filter.IsValid()
+}
+
+/*GetActivityOverTime generates a struct containing the
+number of pushes and pulls performed within every day, ISO week
+or month (depending on <Interval>) since <StartDate>
+according to the given CSV data.
+
+Each struct within the list of structs in the data field of the returned
+ActivityOverTime struct contains the beginning of the interval and
+the number of pushes and pulls performed within it. The list is ordered
+by time and contains intervals without any activity as well, starting with
+the interval of <StartDate> (or, without a <StartDate>, of the first activity) and ending
+with the current interval.
+If <SplitByTeam> is set, each struct contains the number of pushes,
+pulls or both (depending on <Operation>) attributed to every team as well.
+
+Check the GetActivityOverTimeParameters struct for parameters.
+This method is registered as the "GetActivityOverTime" stats method.*/
+func (registry *Registry) GetActivityOverTime(params *GetActivityOverTimeParameters) *ActivityOverTime {
+
+	log.Printf("\nAnalyse :: GetActivityOverTime :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetActivityOverTime :: params are invalid :: %s", reason)
+	}
+
+	pushesPerInterval := map[time.Time]int{}
+	pullsPerInterval := map[time.Time]int{}
+	firstActivity := params.EndDate()
+	compiledFilter := params.Filter.compile()
+
+	accessesPerTeam := map[string]int{}
+	accessesPerTeamAndInterval := map[string]map[time.Time]int{}
+	compiledTeamMapping := registry.TeamMapping.compile()
+	countTeamAccess := func(accessOperation string, projectName string, repositoryName string, accessLog Log) {
+		if !params.SplitByTeam || (params.Operation != anyOperation && params.Operation != accessOperation) {
+			return
+		}
+		teamName := compiledTeamMapping.getTeamOfAccess(projectName, repositoryName, accessLog.User)
+		if _, ok := accessesPerTeamAndInterval[teamName]; !ok {
+			accessesPerTeamAndInterval[teamName] = map[time.Time]int{}
+		}
+		accessesPerTeam[teamName]++
+		accessesPerTeamAndInterval[teamName][truncateToInterval(accessLog.Timestamp, params.Interval)]++
+	}
+
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+			for _, tag := range repository.Tags {
+				for _, push := range tag.Pushes {
+					if !compiledFilter.includesUser(push.User) {
+						continue
+					}
+					if !isWithinPeriod(push.Timestamp, params) {
+						log.Printf("\nIgnore push to %s on %s as outside relevant time.", repository.Name, push.Timestamp)
+						continue
+					}
+					pushesPerInterval[truncateToInterval(push.Timestamp, params.Interval)]++
+					countTeamAccess(pushOperation, project.Name, repository.Name, push.Log)
+					if push.Timestamp.Before(firstActivity) {
+						firstActivity = push.Timestamp
+					}
+				}
+				for _, pull := range tag.Pulls {
+					if !compiledFilter.includesUser(pull.User) {
+						continue
+					}
+					if !isWithinPeriod(pull.Timestamp, params) {
+						log.Printf("\nIgnore pull of %s on %s as outside relevant time.", repository.Name, pull.Timestamp)
+						continue
+					}
+					pullsPerInterval[truncateToInterval(pull.Timestamp, params.Interval)]++
+					countTeamAccess(pullOperation, project.Name, repository.Name, pull.Log)
+					if pull.Timestamp.Before(firstActivity) {
+						firstActivity = pull.Timestamp
+					}
+				}
+			}
+		}
+	}
+
+	activityOverTime := ActivityOverTime{
+		operation:   params.Operation,
+		splitByTeam: params.SplitByTeam,
+	}
+
+	for teamName := range accessesPerTeam {
+		activityOverTime.teamNames = append(activityOverTime.teamNames, teamName)
+	}
+	sort.Slice(activityOverTime.teamNames, func(idxA, idxB int) bool {
+		teamNameA, teamNameB := activityOverTime.teamNames[idxA], activityOverTime.teamNames[idxB]
+		if accessesPerTeam[teamNameA] == accessesPerTeam[teamNameB] {
+			return teamNameA < teamNameB
+		}
+		return accessesPerTeam[teamNameA] > accessesPerTeam[teamNameB]
+	})
+
+	//Walk through all intervals up to the endDate so that
+	//intervals without any activity show up as zero
+	//starting with the interval of the startDate, if given,
+	//or else with the interval of the first activity
+	firstIntervalStart := truncateToInterval(firstActivity, params.Interval)
+	if !params.StartDate().IsZero() {
+		firstIntervalStart = truncateToInterval(params.StartDate(), params.Interval)
+	}
+	lastIntervalStart := getLastIntervalStart(params.EndDate(), params.Interval)
+	for intervalStart := firstIntervalStart; !intervalStart.After(lastIntervalStart); intervalStart = addInterval(intervalStart, params.Interval) {
+		activity := activityInInterval{
+			intervalStart: intervalStart,
+			pushCount:     pushesPerInterval[intervalStart],
+			pullCount:     pullsPerInterval[intervalStart],
+		}
+		if params.SplitByTeam {
+			activity.accessCountPerTeam = map[string]int{}
+			for teamName, accessesPerInterval := range accessesPerTeamAndInterval {
+				activity.accessCountPerTeam[teamName] = accessesPerInterval[intervalStart]
+			}
+		}
+		activityOverTime.data = append(activityOverTime.data, activity)
+	}
+
+	return &activityOverTime
+
+}
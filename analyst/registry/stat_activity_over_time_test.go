@@ -0,0 +1,176 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetActivityOverTime(t *testing.T) {
+
+	registry := newTestRegistry([]testAccess{
+		{pushOperation, "game/server", "v1", "alice", "2017-10-02 10:00"},
+		{pullOperation, "game/server", "v1", "bob", "2017-10-03 11:00"},
+		{pushOperation, "game/server", "v2", "alice", "2017-10-10 09:00"},
+		{pushOperation, "game/client", "v1", "bob", "2017-10-24 15:00"},
+		//Outside of every period tested
+		{pushOperation, "game/client", "v1", "bob", "2017-11-02 15:00"},
+	})
+
+	testCases := []struct {
+		name      string
+		startDate string
+		endDate   string
+		operation string
+		interval  string
+		want      []activityInInterval
+	}{
+		{
+			name:      "weeks without activity are zero",
+			startDate: "2017-10-01",
+			endDate:   "2017-10-30",
+			operation: anyOperation,
+			interval:  weekInterval,
+			want: []activityInInterval{
+				//The week of the startDate (a Sunday)
+				{intervalStart: testTime("2017-09-25")},
+				{intervalStart: testTime("2017-10-02"), pushCount: 1, pullCount: 1},
+				{intervalStart: testTime("2017-10-09"), pushCount: 1},
+				{intervalStart: testTime("2017-10-16")},
+				{intervalStart: testTime("2017-10-23"), pushCount: 1},
+			},
+		},
+		{
+			name:      "single interval",
+			startDate: "2017-10-02",
+			endDate:   "2017-10-09",
+			operation: pushOperation,
+			interval:  weekInterval,
+			want: []activityInInterval{
+				{intervalStart: testTime("2017-10-02"), pushCount: 1, pullCount: 1},
+			},
+		},
+		{
+			name:      "leading days without activity are zero",
+			startDate: "2017-10-08",
+			endDate:   "2017-10-11",
+			operation: pushOperation,
+			interval:  dayInterval,
+			want: []activityInInterval{
+				{intervalStart: testTime("2017-10-08")},
+				{intervalStart: testTime("2017-10-09")},
+				{intervalStart: testTime("2017-10-10"), pushCount: 1},
+			},
+		},
+		{
+			name:      "no activity since the start date",
+			startDate: "2017-10-17",
+			endDate:   "2017-10-18",
+			operation: pushOperation,
+			interval:  dayInterval,
+			want: []activityInInterval{
+				{intervalStart: testTime("2017-10-17")},
+			},
+		},
+		{
+			name:      "no start date",
+			endDate:   "2017-10-12",
+			operation: pushOperation,
+			interval:  dayInterval,
+			want: []activityInInterval{
+				{intervalStart: testTime("2017-10-02"), pushCount: 1},
+				{intervalStart: testTime("2017-10-03"), pullCount: 1},
+				{intervalStart: testTime("2017-10-04")},
+				{intervalStart: testTime("2017-10-05")},
+				{intervalStart: testTime("2017-10-06")},
+				{intervalStart: testTime("2017-10-07")},
+				{intervalStart: testTime("2017-10-08")},
+				{intervalStart: testTime("2017-10-09")},
+				{intervalStart: testTime("2017-10-10"), pushCount: 1},
+				{intervalStart: testTime("2017-10-11")},
+			},
+		},
+		{
+			name:      "no activity and no start date",
+			endDate:   "2017-09-30",
+			operation: pushOperation,
+			interval:  dayInterval,
+			want:      nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			params := GetActivityOverTimeParameters{
+				Operation: testCase.operation,
+				Interval:  testCase.interval,
+			}
+			if testCase.startDate != "" {
+				params.SetStartDate(testTime(testCase.startDate))
+			}
+			params.SetEndDate(testTime(testCase.endDate))
+
+			got := registry.GetActivityOverTime(&params)
+			if !reflect.DeepEqual(got.data, testCase.want) {
+				t.Errorf("GetActivityOverTime() = %v, want %v", got.data, testCase.want)
+			}
+
+			//Every series has a point per interval
+			for _, series := range got.GetLineChartSeries() {
+				if len(series.Times) != len(testCase.want) || len(series.Values) != len(testCase.want) {
+					t.Errorf("series %s has %d points in time and %d values, want %d", series.Name, len(series.Times), len(series.Values), len(testCase.want))
+				}
+			}
+
+		})
+	}
+
+}
+
+func TestGetActivityOverTimeSplitByTeam(t *testing.T) {
+
+	registry := newTestRegistry([]testAccess{
+		{pushOperation, "game/server", "v1", "alice", "2017-10-02 10:00"},
+		{pullOperation, "game/server", "v1", "bob", "2017-10-03 11:00"},
+		{pushOperation, "web/frontend", "v1", "bob", "2017-10-10 09:00"},
+		{pushOperation, "tools/linter", "v1", "carol", "2017-10-10 10:00"},
+	})
+	registry.TeamMapping = TeamMapping{Teams: []Team{
+		{Name: "backend", Projects: []string{"game"}},
+		{Name: "frontend", Projects: []string{"web"}},
+	}}
+
+	params := GetActivityOverTimeParameters{
+		Operation:   pushOperation,
+		Interval:    weekInterval,
+		SplitByTeam: true,
+	}
+	params.SetStartDate(testTime("2017-10-02"))
+	params.SetEndDate(testTime("2017-10-16"))
+
+	//Only pushes are counted per team, teams with equal counts are ordered by name
+	want := map[string][]float64{
+		"backend":      {1, 0},
+		"frontend":     {0, 1},
+		unassignedTeam: {0, 1},
+	}
+	wantOrder := []string{"backend", "frontend", unassignedTeam}
+
+	var gotOrder []string
+	for _, series := range registry.GetActivityOverTime(&params).GetLineChartSeries() {
+		gotOrder = append(gotOrder, series.Name)
+		if !reflect.DeepEqual(series.Values, want[series.Name]) {
+			t.Errorf("series %s has the values %v, want %v", series.Name, series.Values, want[series.Name])
+		}
+	}
+	if !reflect.DeepEqual(gotOrder, wantOrder) {
+		t.Errorf("series are %v, want %v", gotOrder, wantOrder)
+	}
+
+}
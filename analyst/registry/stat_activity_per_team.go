@@ -0,0 +1,397 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetActivityPerTeam",
+		Description:   "Table of the pushes, pulls, active users and repositories per team.",
+		NewParameters: func() StatsMethodParameters { return &GetActivityPerTeamParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetActivityPerTeam(params.(*GetActivityPerTeamParameters))
+		},
+	})
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetTeamActivityOverTime",
+		Description:   "Line chart of the pushes and/or pulls per team per day, week or month.",
+		NewParameters: func() StatsMethodParameters { return &GetTeamActivityOverTimeParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetTeamActivityOverTime(params.(*GetTeamActivityOverTimeParameters))
+		},
+	})
+}
+
+/*teamAccess is a single push or pull attributed to a team.*/
+type teamAccess struct {
+	teamName       string
+	repositoryName string
+	userName       string
+	operation      string
+	timestamp      time.Time
+}
+
+/*getTeamAccesses returns all pushes, pulls or both (depending on the
+given operation, i.e. "push", "pull" or "any") performed between
+<from> (inclusive) and <until> (exclusive) by users of the given user class, each attributed to a team according
+to the TeamMapping of the registry.
+Only the repositories, users and accesses passing the given filter
+will be taken into account.*/
+func (registry *Registry) getTeamAccesses(operation string, filter Filter, userClass string, from time.Time, until time.Time) []teamAccess {
+
+	var teamAccesses []teamAccess
+	classPerUser := registry.getClassPerUser()
+	compiledFilter := filter.compile()
+	compiledTeamMapping := registry.TeamMapping.compile()
+
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+
+			for _, tag := range repository.Tags {
+				for _, accessOperation := range []string{pushOperation, pullOperation} {
+					if operation != anyOperation && operation != accessOperation {
+						continue
+					}
+					for _, accessLog := range getLogsByOperation(tag, accessOperation) {
+						if accessLog.User == nil || accessLog.Timestamp.Before(from) || !accessLog.Timestamp.Before(until) {
+							continue
+						}
+						if !compiledFilter.includesUser(accessLog.User) || !isOfUserClass(classPerUser, accessLog.User.Name, userClass) {
+							continue
+						}
+						teamAccesses = append(teamAccesses, teamAccess{
+							teamName:       compiledTeamMapping.getTeamOfAccess(project.Name, repository.Name, accessLog.User),
+							repositoryName: repository.Name,
+							userName:       accessLog.User.Name,
+							operation:      accessOperation,
+							timestamp:      accessLog.Timestamp,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return teamAccesses
+
+}
+
+/*getAccessesPerTeam sums up the pushes, the pulls or both
+(depending on the given operation, i.e. "push", "pull" or "any")
+attributed to each team between <from> (inclusive) and <until> (exclusive)
+and returns them mapped to the team names, see getTeamAccesses.*/
+func (registry *Registry) getAccessesPerTeam(operation string, filter Filter, userClass string, from time.Time, until time.Time) map[string]int {
+
+	accessesPerTeam := map[string]int{}
+	for _, access := range registry.getTeamAccesses(operation, filter, userClass, from, until) {
+		accessesPerTeam[access.teamName]++
+	}
+
+	return accessesPerTeam
+
+}
+
+/*ActivityPerTeam is a slice of activityOfTeam structs
+containing the name of a team and the number of pushes, pulls,
+active users and repositories accessed attributed to the team.*/
+type ActivityPerTeam struct {
+	data  []activityOfTeam
+	title string
+}
+
+type activityOfTeam struct {
+	teamName        string
+	pushCount       int
+	pullCount       int
+	activeUserCount int
+	repositoryCount int
+}
+
+/*GetTableValues for the ActivityPerTeam type converts the
+ActivityPerTeam slice into a table of the activity of every team
+that can be used by a table generator.
+The output rows are guaranteed to be ordered.
+This method is a requirement of the outputgen.TableChartable interface.*/
+func (a *ActivityPerTeam) GetTableValues() outputgen.TableValues {
+
+	tableValues := outputgen.TableValues{
+		ColumnNames: []string{"Team", "Pushes", "Pulls", "Active users", "Repositories"},
+	}
+
+	for _, activityOfTeam := range a.data {
+		tableValues.Rows = append(tableValues.Rows, []string{
+			activityOfTeam.teamName,
+			fmt.Sprintf("%d", activityOfTeam.pushCount),
+			fmt.Sprintf("%d", activityOfTeam.pullCount),
+			fmt.Sprintf("%d", activityOfTeam.activeUserCount),
+			fmt.Sprintf("%d", activityOfTeam.repositoryCount),
+		})
+	}
+
+	return tableValues
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (a *ActivityPerTeam) SetTitle(title string) {
+	log.Printf("\nActivityPerTeam :: %v .SetTitle %s", a, title)
+	a.title = title
+	log.Printf("\nNewTitle::%s", a.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (a *ActivityPerTeam) Title() string {
+	if len(a.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", a)
+	}
+	return a.title
+}
+
+/*GetActivityPerTeamParameters is the type
+that provides a wrapper for the parameters passed to the
+GetActivityPerTeam stats function.
+This type implements the StatsMethodParameters interface type.*/
+type GetActivityPerTeamParameters struct {
+	Period
+	UserClassSelection
+	Filter
+}
+
+/*IsValid check whether all fields in the GetActivityPerTeamParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetActivityPerTeamParameters) IsValid() (bool, string) {
+	if isValid, reason := g.UserClassSelection.IsValid(); !isValid {
+		return false, reason
+	}
+	return g.Filter.IsValid()
+}
+
+/*GetActivityPerTeam generates a struct containing the number of pushes,
+pulls, distinct active users and distinct repositories accessed since
+<StartDate> per team according to the given CSV data and the TeamMapping
+of the registry. Accesses which cannot be attributed to any team
+are listed under the "unassigned" team.
+The list is ordered by the number of pushes and pulls descendingly.
+Check the GetActivityPerTeamParameters struct for parameters.
+This method is registered as the "GetActivityPerTeam" stats method.*/
+func (registry *Registry) GetActivityPerTeam(params *GetActivityPerTeamParameters) *ActivityPerTeam {
+
+	log.Printf("\nAnalyse :: GetActivityPerTeam :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetActivityPerTeam :: params are invalid :: %s", reason)
+	}
+
+	activityPerTeamName := map[string]*activityOfTeam{}
+	usersPerTeam := map[string]map[string]bool{}
+	repositoriesPerTeam := map[string]map[string]bool{}
+
+	for _, access := range registry.getTeamAccesses(anyOperation, params.Filter, params.UserClass, params.StartDate(), params.EndDate()) {
+		activity, ok := activityPerTeamName[access.teamName]
+		if !ok {
+			activity = &activityOfTeam{teamName: access.teamName}
+			activityPerTeamName[access.teamName] = activity
+			usersPerTeam[access.teamName] = map[string]bool{}
+			repositoriesPerTeam[access.teamName] = map[string]bool{}
+		}
+		if access.operation == pushOperation {
+			activity.pushCount++
+		} else {
+			activity.pullCount++
+		}
+		usersPerTeam[access.teamName][access.userName] = true
+		repositoriesPerTeam[access.teamName][access.repositoryName] = true
+	}
+
+	var activityPerTeam ActivityPerTeam
+	for teamName, activity := range activityPerTeamName {
+		activity.activeUserCount = len(usersPerTeam[teamName])
+		activity.repositoryCount = len(repositoriesPerTeam[teamName])
+		activityPerTeam.data = append(activityPerTeam.data, *activity)
+	}
+
+	sort.Slice(activityPerTeam.data, func(idxA, idxB int) bool {
+		accessCountA := activityPerTeam.data[idxA].pushCount + activityPerTeam.data[idxA].pullCount
+		accessCountB := activityPerTeam.data[idxB].pushCount + activityPerTeam.data[idxB].pullCount
+		if accessCountA == accessCountB {
+			return activityPerTeam.data[idxA].teamName < activityPerTeam.data[idxB].teamName
+		}
+		return accessCountA > accessCountB
+	})
+
+	return &activityPerTeam
+
+}
+
+/*TeamActivityOverTime contains the beginning of every day, week
+or month and the number of pushes and/or pulls attributed to
+every team within this interval.*/
+type TeamActivityOverTime struct {
+	intervalStarts        []time.Time
+	teamNames             []string
+	accessesPerTeamAndIdx map[string][]int
+	title                 string
+}
+
+/*GetLineChartSeries for the TeamActivityOverTime type converts the
+TeamActivityOverTime into one series per team
+that can be used by a chart generator.
+This method is a requirement of the outputgen.LineChartable interface.*/
+func (t *TeamActivityOverTime) GetLineChartSeries() outputgen.LineChartableSeriesList {
+
+	var seriesList outputgen.LineChartableSeriesList
+
+	for _, teamName := range t.teamNames {
+		teamSeries := outputgen.LineChartableSeries{Name: teamName}
+		for idx, intervalStart := range t.intervalStarts {
+			teamSeries.Times = append(teamSeries.Times, intervalStart)
+			teamSeries.Values = append(teamSeries.Values, float64(t.accessesPerTeamAndIdx[teamName][idx]))
+		}
+		seriesList = append(seriesList, teamSeries)
+	}
+
+	return seriesList
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (t *TeamActivityOverTime) SetTitle(title string) {
+	log.Printf("\nTeamActivityOverTime :: %v .SetTitle %s", t, title)
+	t.title = title
+	log.Printf("\nNewTitle::%s", t.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (t *TeamActivityOverTime) Title() string {
+	if len(t.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", t)
+	}
+	return t.title
+}
+
+/*GetTeamActivityOverTimeParameters is the type
+that provides a wrapper for the parameters passed to the
+GetTeamActivityOverTime stats function.
+Operation must be one of "push", "pull" or "any",
+Interval must be one of "day", "week" or "month".
+MaxNumberOfElements limits the number of teams shown.
+This type implements the StatsMethodParameters interface type.*/
+type GetTeamActivityOverTimeParameters struct {
+	Period
+	Operation           string `doc:"Operation to count, one of push, pull or any" enum:"push,pull,any"`
+	Interval            string `doc:"Interval to count per, one of day, week or month" enum:"day,week,month"`
+	MaxNumberOfElements int    `doc:"Number of teams shown"`
+	UserClassSelection
+	Filter
+}
+
+/*IsValid check whether all fields in the GetTeamActivityOverTimeParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetTeamActivityOverTimeParameters) IsValid() (bool, string) {
+	if !isValidOperation(g.Operation) {
+		return false, fmt.Sprintf("Operation \"%s\" is not one of push, pull or any", g.Operation)
+	}
+	if !isValidInterval(g.Interval) {
+		return false, fmt.Sprintf("Interval \"%s\" is not one of day, week or month", g.Interval)
+	}
+	if g.MaxNumberOfElements < 1 {
+		return false, "MaxNumberOfElements is less than one"
+	}
+	if isValid, reason := g.UserClassSelection.IsValid(); !isValid {
+		return false, reason
+	}
+	return g.Filter.IsValid()
+}
+
+/*GetTeamActivityOverTime generates a struct containing the number
+of pushes, pulls or both (depending on <Operation>) attributed to each of
+the <MaxNumberOfElements> most active teams within every day, ISO week or
+month (depending on <Interval>) since <StartDate> according to the given
+CSV data and the TeamMapping of the registry.
+Intervals without any activity are contained as well, starting with the
+interval of the first activity and ending with the current interval.
+Without any activity there are no teams and no intervals at all.
+Check the GetTeamActivityOverTimeParameters struct for parameters.
+This method is registered as the "GetTeamActivityOverTime" stats method.*/
+func (registry *Registry) GetTeamActivityOverTime(params *GetTeamActivityOverTimeParameters) *TeamActivityOverTime {
+
+	log.Printf("\nAnalyse :: GetTeamActivityOverTime :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetTeamActivityOverTime :: params are invalid :: %s", reason)
+	}
+
+	teamAccesses := registry.getTeamAccesses(params.Operation, params.Filter, params.UserClass, params.StartDate(), params.EndDate())
+
+	teamActivityOverTime := TeamActivityOverTime{
+		accessesPerTeamAndIdx: map[string][]int{},
+	}
+	if len(teamAccesses) == 0 {
+		//Every access is attributed to at least the unassigned team,
+		//so there are no teams to show only without any accesses
+		log.Printf("\nGetTeamActivityOverTime :: no accesses, no teams to show.")
+		return &teamActivityOverTime
+	}
+
+	accessesPerTeam := map[string]int{}
+	accessesPerTeamAndInterval := map[string]map[time.Time]int{}
+	firstActivity := teamAccesses[0].timestamp
+	for _, access := range teamAccesses {
+		if _, ok := accessesPerTeamAndInterval[access.teamName]; !ok {
+			accessesPerTeamAndInterval[access.teamName] = map[time.Time]int{}
+			teamActivityOverTime.teamNames = append(teamActivityOverTime.teamNames, access.teamName)
+		}
+		accessesPerTeam[access.teamName]++
+		accessesPerTeamAndInterval[access.teamName][truncateToInterval(access.timestamp, params.Interval)]++
+		if access.timestamp.Before(firstActivity) {
+			firstActivity = access.timestamp
+		}
+	}
+
+	//Only show the most active teams
+	sort.Slice(teamActivityOverTime.teamNames, func(idxA, idxB int) bool {
+		teamNameA, teamNameB := teamActivityOverTime.teamNames[idxA], teamActivityOverTime.teamNames[idxB]
+		if accessesPerTeam[teamNameA] == accessesPerTeam[teamNameB] {
+			return teamNameA < teamNameB
+		}
+		return accessesPerTeam[teamNameA] > accessesPerTeam[teamNameB]
+	})
+	if params.MaxNumberOfElements < len(teamActivityOverTime.teamNames) {
+		teamActivityOverTime.teamNames = teamActivityOverTime.teamNames[:params.MaxNumberOfElements]
+	}
+
+	//Walk through all intervals up to the endDate so that
+	//intervals without any activity show up as zero
+	lastIntervalStart := getLastIntervalStart(params.EndDate(), params.Interval)
+	for intervalStart := truncateToInterval(firstActivity, params.Interval); !intervalStart.After(lastIntervalStart); intervalStart = addInterval(intervalStart, params.Interval) {
+		teamActivityOverTime.intervalStarts = append(teamActivityOverTime.intervalStarts, intervalStart)
+		for _, teamName := range teamActivityOverTime.teamNames {
+			teamActivityOverTime.accessesPerTeamAndIdx[teamName] = append(teamActivityOverTime.accessesPerTeamAndIdx[teamName], accessesPerTeamAndInterval[teamName][intervalStart])
+		}
+	}
+
+	return &teamActivityOverTime
+
+}
@@ -0,0 +1,122 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetTeamOfAccess(t *testing.T) {
+
+	teamMapping := TeamMapping{Teams: []Team{
+		{Name: "backend", Projects: []string{"game"}, Users: []string{"alice"}},
+		{Name: "frontend", RepositoryPatterns: []string{"game/client*"}, Users: []string{"bob"}},
+		{Name: "tools", RepositoryPatterns: []string{"^tools/(lint|format)er$"}},
+	}}
+
+	testCases := []struct {
+		name           string
+		projectName    string
+		repositoryName string
+		user           *User
+		want           string
+	}{
+		{"repository pattern before project", "game", "game/client-web", &User{Name: "alice"}, "frontend"},
+		{"project", "game", "game/server", &User{Name: "bob"}, "backend"},
+		{"regular expression", "tools", "tools/linter", &User{Name: "bob"}, "tools"},
+		{"user", "tools", "tools/builder", &User{Name: "bob"}, "frontend"},
+		{"unknown user", "tools", "tools/builder", nil, unassignedTeam},
+		{"unassigned user", "tools", "tools/builder", &User{Name: "carol"}, unassignedTeam},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := teamMapping.compile().getTeamOfAccess(testCase.projectName, testCase.repositoryName, testCase.user); got != testCase.want {
+				t.Errorf("getTeamOfAccess() = %s, want %s", got, testCase.want)
+			}
+		})
+	}
+
+}
+
+func TestGetTeamActivityOverTime(t *testing.T) {
+
+	testCases := []struct {
+		name                      string
+		accesses                  []testAccess
+		maxNumberOfElements       int
+		wantTeamNames             []string
+		wantIntervalCount         int
+		wantAccessesPerTeamAndIdx map[string][]int
+	}{
+		{
+			name:                      "no accesses",
+			maxNumberOfElements:       5,
+			wantAccessesPerTeamAndIdx: map[string][]int{},
+		},
+		{
+			name: "teams and unassigned",
+			accesses: []testAccess{
+				{pushOperation, "game/server", "v1", "alice", "2017-10-02 10:00"},
+				{pullOperation, "game/server", "v1", "carol", "2017-10-04 10:00"},
+				{pushOperation, "tools/linter", "v1", "carol", "2017-10-04 11:00"},
+			},
+			maxNumberOfElements: 5,
+			wantTeamNames:       []string{"backend", unassignedTeam},
+			wantIntervalCount:   3,
+			wantAccessesPerTeamAndIdx: map[string][]int{
+				"backend":      {1, 0, 1},
+				unassignedTeam: {0, 0, 1},
+			},
+		},
+		{
+			name: "most active teams only",
+			accesses: []testAccess{
+				{pushOperation, "game/server", "v1", "alice", "2017-10-04 10:00"},
+				{pushOperation, "tools/linter", "v1", "carol", "2017-10-04 11:00"},
+			},
+			maxNumberOfElements: 1,
+			wantTeamNames:       []string{"backend"},
+			wantIntervalCount:   1,
+			wantAccessesPerTeamAndIdx: map[string][]int{
+				"backend": {1},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			registry := newTestRegistry(testCase.accesses)
+			registry.TeamMapping = TeamMapping{Teams: []Team{{Name: "backend", Projects: []string{"game"}}}}
+
+			params := GetTeamActivityOverTimeParameters{
+				Operation:           anyOperation,
+				Interval:            dayInterval,
+				MaxNumberOfElements: testCase.maxNumberOfElements,
+			}
+			params.SetEndDate(testTime("2017-10-05"))
+
+			got := registry.GetTeamActivityOverTime(&params)
+			if !reflect.DeepEqual(got.teamNames, testCase.wantTeamNames) {
+				t.Errorf("team names are %v, want %v", got.teamNames, testCase.wantTeamNames)
+			}
+			if len(got.intervalStarts) != testCase.wantIntervalCount {
+				t.Errorf("got %d intervals, want %d", len(got.intervalStarts), testCase.wantIntervalCount)
+			}
+			if !reflect.DeepEqual(got.accessesPerTeamAndIdx, testCase.wantAccessesPerTeamAndIdx) {
+				t.Errorf("accesses per team are %v, want %v", got.accessesPerTeamAndIdx, testCase.wantAccessesPerTeamAndIdx)
+			}
+			if len(got.GetLineChartSeries()) != len(testCase.wantTeamNames) {
+				t.Errorf("got %d series, want one per team", len(got.GetLineChartSeries()))
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,167 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetActivityPerWeekdayAndHour",
+		Description:   "Heat map of the pushes or pulls per weekday and hour of the day.",
+		NewParameters: func() StatsMethodParameters { return &GetActivityPerWeekdayAndHourParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetActivityPerWeekdayAndHour(params.(*GetActivityPerWeekdayAndHourParameters))
+		},
+	})
+}
+
+/*weekdaysOrdered lists the days of the week in the order
+in which they are presented (i.e. starting with Monday).*/
+var weekdaysOrdered = []time.Weekday{
+	time.Monday,
+	time.Tuesday,
+	time.Wednesday,
+	time.Thursday,
+	time.Friday,
+	time.Saturday,
+	time.Sunday,
+}
+
+/*ActivityPerWeekdayAndHour is a 7x24 grid containing
+the number of pushes and/or pulls performed
+for every hour of every day of the week.*/
+type ActivityPerWeekdayAndHour struct {
+	data  map[time.Weekday]*[24]int
+	title string
+}
+
+/*GetHeatMapValues for the ActivityPerWeekdayAndHour type converts
+the ActivityPerWeekdayAndHour grid into a grid of values
+that can be used by a heat map generator.
+Rows are the days of the week (starting with Monday),
+columns are the hours of the day.
+This method is a requirement of the outputgen.HeatMapChartable interface.*/
+func (a *ActivityPerWeekdayAndHour) GetHeatMapValues() outputgen.HeatMapValues {
+
+	var heatMapValues outputgen.HeatMapValues
+
+	for hourOfDay := 0; hourOfDay < 24; hourOfDay++ {
+		heatMapValues.ColumnLabels = append(heatMapValues.ColumnLabels, fmt.Sprintf("%dh", hourOfDay))
+	}
+
+	for _, weekday := range weekdaysOrdered {
+		row := make([]float64, 24)
+		if counts, ok := a.data[weekday]; ok {
+			for hourOfDay, count := range counts {
+				row[hourOfDay] = float64(count)
+			}
+		}
+		heatMapValues.RowLabels = append(heatMapValues.RowLabels, weekday.String())
+		heatMapValues.Values = append(heatMapValues.Values, row)
+	}
+
+	heatMapValues.CellFormat = "%.0f"
+
+	return heatMapValues
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (a *ActivityPerWeekdayAndHour) SetTitle(title string) {
+	log.Printf("\nActivityPerWeekdayAndHour :: %v .SetTitle %s", a, title)
+	a.title = title
+	log.Printf("\nNewTitle::%s", a.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (a *ActivityPerWeekdayAndHour) Title() string {
+	if len(a.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", a)
+	}
+	return a.title
+}
+
+/*GetActivityPerWeekdayAndHourParameters is the type
+that provides a wrapper for the parameters passed to the
+GetActivityPerWeekdayAndHour stats function.
+Operation must be one of "push", "pull" or "any".
+This type implements the StatsMethodParameters interface type.*/
+type GetActivityPerWeekdayAndHourParameters struct {
+	Period
+	Operation string `doc:"Operation to count, one of push, pull or any" enum:"push,pull,any"`
+	Filter
+}
+
+/*IsValid check whether all fields in the GetActivityPerWeekdayAndHourParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetActivityPerWeekdayAndHourParameters) IsValid() (bool, string) {
+	if !isValidOperation(g.Operation) {
+		return false, fmt.Sprintf("Operation \"%s\" is not one of push, pull or any", g.Operation)
+	}
+	return g.Filter.IsValid()
+}
+
+/*GetActivityPerWeekdayAndHour generates a struct containing the
+number of pushes and/or pulls (depending on <Operation>) performed
+for every hour of every day of the week, accumulated over the
+timeperiod since <StartDate> according to the given CSV data.
+
+In contrast to GetPushesPerDaytimes, which collapses all days
+into one, the result allows to tell apart e.g. a busy Monday morning
+from a quiet Sunday morning and is rendered as a heat map.
+
+Check the GetActivityPerWeekdayAndHourParameters struct for parameters.
+This method is registered as the "GetActivityPerWeekdayAndHour" stats method.*/
+func (registry *Registry) GetActivityPerWeekdayAndHour(params *GetActivityPerWeekdayAndHourParameters) *ActivityPerWeekdayAndHour {
+
+	log.Printf("\nAnalyse :: GetActivityPerWeekdayAndHour :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetActivityPerWeekdayAndHour :: params are invalid :: %s", reason)
+	}
+
+	activityPerWeekdayAndHour := ActivityPerWeekdayAndHour{
+		data: map[time.Weekday]*[24]int{},
+	}
+	for _, weekday := range weekdaysOrdered {
+		activityPerWeekdayAndHour.data[weekday] = &[24]int{}
+	}
+
+	compiledFilter := params.Filter.compile()
+
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+			for _, tag := range repository.Tags {
+				for _, accessLog := range getLogsByOperation(tag, params.Operation) {
+					if !compiledFilter.includesUser(accessLog.User) {
+						continue
+					}
+					if !isWithinPeriod(accessLog.Timestamp, params) {
+						log.Printf("\nIgnore %s of %s on %s as outside relevant time.", params.Operation, repository.Name, accessLog.Timestamp)
+						continue
+					}
+					activityPerWeekdayAndHour.data[accessLog.Timestamp.Weekday()][accessLog.Timestamp.Hour()]++
+				}
+			}
+		}
+	}
+
+	return &activityPerWeekdayAndHour
+
+}
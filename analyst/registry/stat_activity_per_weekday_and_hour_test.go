@@ -0,0 +1,62 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"testing"
+)
+
+func TestGetActivityPerWeekdayAndHour(t *testing.T) {
+
+	//2017-10-02 is a Monday, 2017-10-08 a Sunday
+	registry := newTestRegistry([]testAccess{
+		{pushOperation, "game/server", "v1", "alice", "2017-10-02 09:15"},
+		{pushOperation, "game/server", "v2", "alice", "2017-10-09 09:45"},
+		{pullOperation, "game/server", "v1", "bob", "2017-10-02 09:30"},
+		{pushOperation, "game/client", "v1", "bob", "2017-10-08 23:00"},
+		//Outside of the period
+		{pushOperation, "game/client", "v2", "bob", "2017-09-25 09:00"},
+	})
+
+	testCases := []struct {
+		name      string
+		operation string
+		want      map[string]float64
+	}{
+		{"pushes", pushOperation, map[string]float64{"Monday 9h": 2, "Sunday 23h": 1}},
+		{"pulls", pullOperation, map[string]float64{"Monday 9h": 1}},
+		{"any", anyOperation, map[string]float64{"Monday 9h": 3, "Sunday 23h": 1}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			params := GetActivityPerWeekdayAndHourParameters{Operation: testCase.operation}
+			params.SetStartDate(testTime("2017-10-01"))
+			params.SetEndDate(testTime("2017-10-16"))
+
+			heatMapValues := registry.GetActivityPerWeekdayAndHour(&params).GetHeatMapValues()
+
+			if len(heatMapValues.RowLabels) != 7 || heatMapValues.RowLabels[0] != "Monday" || heatMapValues.RowLabels[6] != "Sunday" {
+				t.Fatalf("rows are %v, want the days of the week starting with Monday", heatMapValues.RowLabels)
+			}
+			if len(heatMapValues.ColumnLabels) != 24 || heatMapValues.ColumnLabels[0] != "0h" || heatMapValues.ColumnLabels[23] != "23h" {
+				t.Fatalf("columns are %v, want the hours of the day", heatMapValues.ColumnLabels)
+			}
+			for rowIdx, rowLabel := range heatMapValues.RowLabels {
+				for columnIdx, columnLabel := range heatMapValues.ColumnLabels {
+					cell := rowLabel + " " + columnLabel
+					if got := heatMapValues.Values[rowIdx][columnIdx]; got != testCase.want[cell] {
+						t.Errorf("%s is %v, want %v", cell, got, testCase.want[cell])
+					}
+				}
+			}
+
+		})
+	}
+
+}
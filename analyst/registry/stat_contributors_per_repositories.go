@@ -0,0 +1,188 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetRepositoriesByContributorCount",
+		Description:   "Bar chart of the repositories with the most distinct users pushing to them.",
+		NewParameters: func() StatsMethodParameters { return &GetRepositoriesByContributorCountParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetRepositoriesByContributorCount(params.(*GetRepositoriesByContributorCountParameters))
+		},
+	})
+}
+
+/*ContributorsPerRepositories is a slice of contributorsPerRepository structs
+containing the name of a repository and the number
+of distinct users who pushed to it.*/
+type ContributorsPerRepositories struct {
+	data  []contributorsPerRepository
+	title string
+}
+
+type contributorsPerRepository struct {
+	repositoryName   string
+	contributorCount int
+}
+
+/*GetOrderedBarChartValues for the ContributorsPerRepositories type converts a
+ContributorsPerRepositories slice into a map that can be used by a chart generator.
+Repositories with a single contributor (i.e. a bus factor of one)
+are highlighted.
+The output slice is guaranteed to be ordered.
+This method is a requirement of the outputgen.BarChartable interface.*/
+func (c ContributorsPerRepositories) GetOrderedBarChartValues() outputgen.BarChartableValuesOrdered {
+
+	var chartables outputgen.BarChartableValuesOrdered
+
+	for _, contributorsPerRepository := range c.data {
+		label := contributorsPerRepository.repositoryName
+		isSingleContributor := contributorsPerRepository.contributorCount == 1
+		if isSingleContributor {
+			label = fmt.Sprintf("%s (bus factor 1)", label)
+		}
+		chartables = append(chartables, outputgen.BarChartableValue{
+			Label:       label,
+			Value:       contributorsPerRepository.contributorCount,
+			Highlighted: isSingleContributor,
+		})
+	}
+
+	return chartables
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (c *ContributorsPerRepositories) SetTitle(title string) {
+	log.Printf("\nContributorsPerRepositories :: %v .SetTitle %s", c, title)
+	c.title = title
+	log.Printf("\nNewTitle::%s", c.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (c *ContributorsPerRepositories) Title() string {
+	if len(c.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", c)
+	}
+	return c.title
+}
+
+/*GetRepositoriesByContributorCountParameters is the type
+that provides a wrapper for the parameters passed to the
+GetRepositoriesByContributorCount stats function.
+If SortAscending is set, the repositories with the fewest
+contributors will be listed instead of the ones with the most.
+This type implements the StatsMethodParameters interface type.*/
+type GetRepositoriesByContributorCountParameters struct {
+	Period
+	MaxNumberOfElements int  `doc:"Number of repositories shown"`
+	SortAscending       bool `doc:"Show the repositories with the fewest contributors instead"`
+	Filter
+}
+
+/*IsValid check whether all fields in the GetRepositoriesByContributorCountParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetRepositoriesByContributorCountParameters) IsValid() (bool, string) {
+	if g.MaxNumberOfElements < 1 {
+		return false, "MaxNumberOfElements is less than one"
+	}
+	return g.Filter.IsValid()
+}
+
+/*GetRepositoriesByContributorCount generates a struct containing the
+<MaxNumberOfElements> repositories with the most (or, if <SortAscending>
+is set, the fewest) distinct pushing users since <StartDate>
+according to the given CSV data.
+
+Each struct within the list of structs in the data field of the returned
+ContributorsPerRepositories struct contains
+the name of the repository and the number of distinct users who have
+pushed to any of its tags ever since <StartDate>.
+Repositories without any push since <StartDate> are not included,
+repositories with a single contributor are flagged in the chart.
+Only the repositories and pushes passing the Filter of the parameters
+are taken into account.
+Check the GetRepositoriesByContributorCountParameters struct for parameters.
+This method is registered as the "GetRepositoriesByContributorCount" stats method.*/
+func (registry *Registry) GetRepositoriesByContributorCount(params *GetRepositoriesByContributorCountParameters) *ContributorsPerRepositories {
+
+	log.Printf("\nAnalyse :: GetRepositoriesByContributorCount :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetRepositoriesByContributorCount :: params are invalid :: %s", reason)
+	}
+
+	var allContributorsPerRepositories ContributorsPerRepositories
+	compiledFilter := params.Filter.compile()
+
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+
+			contributors := map[string]bool{}
+			for _, tag := range repository.Tags {
+				for _, push := range tag.Pushes {
+					if !isWithinPeriod(push.Timestamp, params) {
+						log.Printf("\nIgnore push to %s on %s as outside relevant time.", repository.Name, push.Timestamp)
+						continue
+					}
+					if push.User == nil || !compiledFilter.includesUser(push.User) {
+						continue
+					}
+					contributors[push.User.Name] = true
+				}
+			}
+
+			if len(contributors) == 0 {
+				continue
+			}
+
+			allContributorsPerRepositories.data = append(allContributorsPerRepositories.data, contributorsPerRepository{
+				repositoryName:   repository.Name,
+				contributorCount: len(contributors),
+			})
+		}
+	}
+
+	//Sort the elements in the data slice by contributorCount
+	//descendingly (or ascendingly if requested), by name otherwise
+	sort.Slice(allContributorsPerRepositories.data, func(idxA, idxB int) bool {
+		elementA := allContributorsPerRepositories.data[idxA]
+		elementB := allContributorsPerRepositories.data[idxB]
+		if elementA.contributorCount == elementB.contributorCount {
+			return elementA.repositoryName < elementB.repositoryName
+		}
+		if params.SortAscending {
+			return elementA.contributorCount < elementB.contributorCount
+		}
+		return elementA.contributorCount > elementB.contributorCount
+	})
+
+	//Trim the output slice to the size defined in MaxNumberOfElements
+	//which will determine the number of bars shown in the chart
+	if int(params.MaxNumberOfElements) < len(allContributorsPerRepositories.data) {
+		allContributorsPerRepositories.data = allContributorsPerRepositories.data[:params.MaxNumberOfElements]
+	}
+
+	return &allContributorsPerRepositories
+
+}
@@ -0,0 +1,78 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetRepositoriesByContributorCount(t *testing.T) {
+
+	registry := newTestRegistry([]testAccess{
+		{pushOperation, "game/server", "v1", "alice", "2017-10-02 10:00"},
+		{pushOperation, "game/server", "v2", "bob", "2017-10-03 10:00"},
+		{pushOperation, "game/server", "v2", "bob", "2017-10-04 10:00"},
+		{pushOperation, "game/client", "v1", "alice", "2017-10-02 11:00"},
+		{pullOperation, "game/client", "v1", "bob", "2017-10-02 12:00"},
+		{pushOperation, "tools/cli", "v1", "carol", "2017-10-05 10:00"},
+		{pushOperation, "tools/cli", "v1", "alice", "2017-10-05 11:00"},
+		//Before the period
+		{pushOperation, "tools/cli", "v1", "dave", "2017-09-05 10:00"},
+	})
+
+	testCases := []struct {
+		name          string
+		sortAscending bool
+		filter        Filter
+		want          []contributorsPerRepository
+	}{
+		{
+			name: "most contributors",
+			want: []contributorsPerRepository{
+				{"game/server", 2},
+				{"tools/cli", 2},
+			},
+		},
+		{
+			name:          "fewest contributors",
+			sortAscending: true,
+			want: []contributorsPerRepository{
+				{"game/client", 1},
+				{"game/server", 2},
+			},
+		},
+		{
+			name:   "no matching repositories",
+			filter: Filter{RepositoriesToInclude: []string{"web/*"}},
+			want:   nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			params := GetRepositoriesByContributorCountParameters{
+				MaxNumberOfElements: 2,
+				SortAscending:       testCase.sortAscending,
+				Filter:              testCase.filter,
+			}
+			params.SetStartDate(testTime("2017-10-01"))
+			params.SetEndDate(testTime("2017-10-08"))
+
+			got := registry.GetRepositoriesByContributorCount(&params)
+			if !reflect.DeepEqual(got.data, testCase.want) {
+				t.Errorf("GetRepositoriesByContributorCount() = %v, want %v", got.data, testCase.want)
+			}
+			if len(got.GetOrderedBarChartValues()) != len(testCase.want) {
+				t.Errorf("got %d bars, want %d", len(got.GetOrderedBarChartValues()), len(testCase.want))
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,290 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetGrowthForecast",
+		Description:   "Line chart of the total number of tags or pushes with a linear or exponential forecast.",
+		NewParameters: func() StatsMethodParameters { return &GetGrowthForecastParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetGrowthForecast(params.(*GetGrowthForecastParameters))
+		},
+	})
+}
+
+const (
+	tagsGrowthMetric   = "tags"
+	pushesGrowthMetric = "pushes"
+
+	linearGrowthModel      = "linear"
+	exponentialGrowthModel = "exponential"
+)
+
+/*GrowthForecast contains the history of the cumulative number
+of tags or pushes at the beginning of every week and the
+projection of this number for the upcoming weeks.*/
+type GrowthForecast struct {
+	history  []growthInWeek
+	forecast []growthInWeek
+	metric   string
+	model    string
+	title    string
+}
+
+type growthInWeek struct {
+	time  time.Time
+	value float64
+}
+
+/*GetLineChartSeries for the GrowthForecast type converts the
+GrowthForecast into one series for the history and one dashed series
+for the forecast that can be used by a chart generator.
+This method is a requirement of the outputgen.LineChartable interface.*/
+func (g *GrowthForecast) GetLineChartSeries() outputgen.LineChartableSeriesList {
+
+	history := outputgen.LineChartableSeries{
+		Name: fmt.Sprintf("Total %s", g.metric),
+	}
+	for _, growthInWeek := range g.history {
+		history.Times = append(history.Times, growthInWeek.time)
+		history.Values = append(history.Values, growthInWeek.value)
+	}
+
+	forecast := outputgen.LineChartableSeries{
+		Name:   fmt.Sprintf("Forecast (%s trend)", g.model),
+		Dashed: true,
+	}
+	for _, growthInWeek := range g.forecast {
+		forecast.Times = append(forecast.Times, growthInWeek.time)
+		forecast.Values = append(forecast.Values, growthInWeek.value)
+	}
+
+	if len(forecast.Times) == 0 {
+		return outputgen.LineChartableSeriesList{history}
+	}
+	return outputgen.LineChartableSeriesList{history, forecast}
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (g *GrowthForecast) SetTitle(title string) {
+	log.Printf("\nGrowthForecast :: %v .SetTitle %s", g, title)
+	g.title = title
+	log.Printf("\nNewTitle::%s", g.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (g *GrowthForecast) Title() string {
+	if len(g.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", g)
+	}
+	return g.title
+}
+
+/*GetGrowthForecastParameters is the type
+that provides a wrapper for the parameters passed to the
+GetGrowthForecast stats function.
+Metric must be one of "tags" (the number of tags ever pushed)
+or "pushes" (the number of pushes ever performed).
+Model must be one of "linear" or "exponential".
+ForecastWeeks is the number of weeks the forecast reaches into the future.
+This type implements the StatsMethodParameters interface type.*/
+type GetGrowthForecastParameters struct {
+	Period
+	Metric        string `doc:"Total to forecast, one of tags or pushes" enum:"tags,pushes"`
+	Model         string `doc:"Trend to fit, one of linear or exponential" enum:"linear,exponential"`
+	ForecastWeeks int    `doc:"Number of weeks to forecast"`
+	Filter
+}
+
+/*IsValid check whether all fields in the GetGrowthForecastParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetGrowthForecastParameters) IsValid() (bool, string) {
+	if g.Metric != tagsGrowthMetric && g.Metric != pushesGrowthMetric {
+		return false, fmt.Sprintf("Metric \"%s\" is not one of tags or pushes", g.Metric)
+	}
+	if g.Model != linearGrowthModel && g.Model != exponentialGrowthModel {
+		return false, fmt.Sprintf("Model \"%s\" is not one of linear or exponential", g.Model)
+	}
+	if g.ForecastWeeks < 1 {
+		return false, "ForecastWeeks is less than one"
+	}
+	return g.Filter.IsValid()
+}
+
+/*fitLinearTrend fits a straight line through the given points
+using least squares and returns its intercept and slope.
+If the line cannot be determined (i.e. there are less than two
+distinct x values), false is returned.*/
+func fitLinearTrend(xValues []float64, yValues []float64) (float64, float64, bool) {
+
+	numberOfPoints := float64(len(xValues))
+	var sumX, sumY, sumXX, sumXY float64
+	for idx := range xValues {
+		sumX += xValues[idx]
+		sumY += yValues[idx]
+		sumXX += xValues[idx] * xValues[idx]
+		sumXY += xValues[idx] * yValues[idx]
+	}
+
+	denominator := numberOfPoints*sumXX - sumX*sumX
+	if numberOfPoints < 2 || denominator == 0 {
+		return 0, 0, false
+	}
+
+	slope := (numberOfPoints*sumXY - sumX*sumY) / denominator
+	intercept := (sumY - slope*sumX) / numberOfPoints
+	return intercept, slope, true
+
+}
+
+/*GetGrowthForecast generates a struct containing the total number of
+tags or pushes (depending on <Metric>) at the beginning of every week between
+<StartDate> and <EndDate> according to the given CSV data, and a forecast of
+this number for the next <ForecastWeeks> weeks.
+
+The totals include all tags or pushes ever, but only the weeks
+since <StartDate> (or since the first tag or push, if later)
+are used to fit the trend.
+A linear trend is fitted to the totals directly, an exponential trend
+is fitted to their logarithm. The forecast continues from the total at <EndDate>
+with the growth rate of the trend. If there is not enough history
+to fit a trend, the forecast is empty. If there is neither a <StartDate>
+nor any tag or push, the history is empty as well.
+Only the repositories and pushes passing the Filter of the parameters
+are taken into account.
+
+Check the GetGrowthForecastParameters struct for parameters.
+This method is registered as the "GetGrowthForecast" stats method.*/
+func (registry *Registry) GetGrowthForecast(params *GetGrowthForecastParameters) *GrowthForecast {
+
+	log.Printf("\nAnalyse :: GetGrowthForecast :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetGrowthForecast :: params are invalid :: %s", reason)
+	}
+
+	//The point in time at which each tag was first pushed
+	//or at which each push was performed (depending on the metric)
+	var eventTimes []time.Time
+	compiledFilter := params.Filter.compile()
+
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+
+			for _, tag := range repository.Tags {
+				if params.Metric == pushesGrowthMetric {
+					for _, push := range tag.Pushes {
+						if compiledFilter.includesUser(push.User) {
+							eventTimes = append(eventTimes, push.Timestamp)
+						}
+					}
+					continue
+				}
+				if firstPush, isPushed, _, _ := getFirstPushAndPull(tag, compiledFilter); isPushed {
+					eventTimes = append(eventTimes, firstPush)
+				}
+			}
+		}
+	}
+
+	sort.Slice(eventTimes, func(idxA, idxB int) bool {
+		return eventTimes[idxA].Before(eventTimes[idxB])
+	})
+
+	//totalAt returns the number of events before the given time
+	totalAt := func(at time.Time) float64 {
+		return float64(sort.Search(len(eventTimes), func(idx int) bool {
+			return !eventTimes[idx].Before(at)
+		}))
+	}
+
+	growthForecast := GrowthForecast{
+		metric: params.Metric,
+		model:  params.Model,
+	}
+
+	//Without a start date the history starts with the first event,
+	//without any events there is nothing to start with
+	if params.StartDate().IsZero() && len(eventTimes) == 0 {
+		log.Printf("\nGetGrowthForecast :: no %s and no start date, no weeks to show.", params.Metric)
+		return &growthForecast
+	}
+
+	//The weeks before the first event would only add zeros to the history
+	firstWeek := truncateToInterval(params.StartDate(), weekInterval)
+	if len(eventTimes) > 0 && truncateToInterval(eventTimes[0], weekInterval).After(firstWeek) {
+		firstWeek = truncateToInterval(eventTimes[0], weekInterval)
+	}
+
+	endDate := params.EndDate()
+	for week := firstWeek; week.Before(endDate); week = addInterval(week, weekInterval) {
+		growthForecast.history = append(growthForecast.history, growthInWeek{time: week, value: totalAt(week)})
+	}
+	growthForecast.history = append(growthForecast.history, growthInWeek{time: endDate, value: totalAt(endDate)})
+
+	//Fit the trend with the weeks since the first history point as x values
+	firstWeek = growthForecast.history[0].time
+	weeksSinceFirstWeek := func(at time.Time) float64 {
+		return at.Sub(firstWeek).Hours() / 24 / 7
+	}
+
+	var xValues, yValues []float64
+	for _, growthInWeek := range growthForecast.history {
+		if params.Model == exponentialGrowthModel {
+			//The logarithm of zero is undefined
+			if growthInWeek.value <= 0 {
+				continue
+			}
+			yValues = append(yValues, math.Log(growthInWeek.value))
+		} else {
+			yValues = append(yValues, growthInWeek.value)
+		}
+		xValues = append(xValues, weeksSinceFirstWeek(growthInWeek.time))
+	}
+
+	_, slope, isFitted := fitLinearTrend(xValues, yValues)
+	if !isFitted {
+		log.Printf("\nGetGrowthForecast :: not enough history to fit a %s trend", params.Model)
+		return &growthForecast
+	}
+
+	//The forecast continues from the last known value with the growth
+	//of the trend so that history and forecast are connected in the chart
+	lastKnown := growthForecast.history[len(growthForecast.history)-1]
+	growthForecast.forecast = append(growthForecast.forecast, lastKnown)
+	for weekOffset := 1; weekOffset <= params.ForecastWeeks; weekOffset++ {
+		week := endDate.AddDate(0, 0, 7*weekOffset)
+		trendGrowth := slope * (weeksSinceFirstWeek(week) - weeksSinceFirstWeek(endDate))
+		value := lastKnown.value + trendGrowth
+		if params.Model == exponentialGrowthModel {
+			value = lastKnown.value * math.Exp(trendGrowth)
+		}
+		growthForecast.forecast = append(growthForecast.forecast, growthInWeek{time: week, value: value})
+	}
+
+	return &growthForecast
+
+}
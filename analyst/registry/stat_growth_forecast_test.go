@@ -0,0 +1,166 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFitLinearTrend(t *testing.T) {
+
+	testCases := []struct {
+		name          string
+		xValues       []float64
+		yValues       []float64
+		wantIntercept float64
+		wantSlope     float64
+		wantIsFitted  bool
+	}{
+		{
+			name: "no points",
+		},
+		{
+			name:    "single point",
+			xValues: []float64{1},
+			yValues: []float64{5},
+		},
+		{
+			name:    "single distinct x value",
+			xValues: []float64{2, 2},
+			yValues: []float64{1, 3},
+		},
+		{
+			name:          "exact line",
+			xValues:       []float64{0, 1, 2, 3},
+			yValues:       []float64{1, 3, 5, 7},
+			wantIntercept: 1,
+			wantSlope:     2,
+			wantIsFitted:  true,
+		},
+		{
+			name:          "least squares",
+			xValues:       []float64{0, 1, 2},
+			yValues:       []float64{0, 2, 1},
+			wantIntercept: 0.5,
+			wantSlope:     0.5,
+			wantIsFitted:  true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			intercept, slope, isFitted := fitLinearTrend(testCase.xValues, testCase.yValues)
+			if isFitted != testCase.wantIsFitted ||
+				math.Abs(intercept-testCase.wantIntercept) > 1e-9 || math.Abs(slope-testCase.wantSlope) > 1e-9 {
+				t.Errorf("fitLinearTrend() = %v, %v, %t, want %v, %v, %t",
+					intercept, slope, isFitted, testCase.wantIntercept, testCase.wantSlope, testCase.wantIsFitted)
+			}
+		})
+	}
+
+}
+
+/*pushesPerWeek returns the given number of pushes per week
+for the weeks starting on 2017-10-02, the first number being
+the number of pushes before that week.*/
+func pushesPerWeek(pushCounts []int) []testAccess {
+	var accesses []testAccess
+	for week, pushCount := range pushCounts {
+		weekStart := testTime("2017-09-25").AddDate(0, 0, 7*week)
+		for idx := 0; idx < pushCount; idx++ {
+			pushTime := weekStart.Add(time.Duration(idx) * time.Minute)
+			accesses = append(accesses, testAccess{pushOperation, "game/server", fmt.Sprintf("v%d.%d", week, idx), "alice",
+				pushTime.Format("2006-01-02 15:04")})
+		}
+	}
+	return accesses
+}
+
+func TestGetGrowthForecast(t *testing.T) {
+
+	testCases := []struct {
+		name         string
+		accesses     []testAccess
+		startDate    string
+		model        string
+		wantHistory  []float64
+		wantForecast []float64
+	}{
+		{
+			name:         "linear",
+			accesses:     pushesPerWeek([]int{0, 7, 7, 7, 7}),
+			startDate:    "2017-10-02",
+			model:        linearGrowthModel,
+			wantHistory:  []float64{0, 7, 14, 21, 28},
+			wantForecast: []float64{28, 35, 42},
+		},
+		{
+			name:         "exponential",
+			accesses:     pushesPerWeek([]int{1, 1, 2, 4, 8}),
+			startDate:    "2017-10-02",
+			model:        exponentialGrowthModel,
+			wantHistory:  []float64{1, 2, 4, 8, 16},
+			wantForecast: []float64{16, 32, 64},
+		},
+		{
+			//The history starts with the week of the first push
+			name:         "no start date",
+			accesses:     pushesPerWeek([]int{0, 0, 0, 7, 7}),
+			model:        linearGrowthModel,
+			wantHistory:  []float64{0, 7, 14},
+			wantForecast: []float64{14, 21, 28},
+		},
+		{
+			//Zero totals do not count for an exponential trend
+			name:        "not enough history",
+			accesses:    pushesPerWeek([]int{0, 0, 0, 0, 7}),
+			startDate:   "2017-10-02",
+			model:       exponentialGrowthModel,
+			wantHistory: []float64{0, 7},
+		},
+		{
+			name:  "no events and no start date",
+			model: linearGrowthModel,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			params := GetGrowthForecastParameters{
+				Metric:        pushesGrowthMetric,
+				Model:         testCase.model,
+				ForecastWeeks: 2,
+			}
+			if testCase.startDate != "" {
+				params.SetStartDate(testTime(testCase.startDate))
+			}
+			params.SetEndDate(testTime("2017-10-30"))
+
+			got := newTestRegistry(testCase.accesses).GetGrowthForecast(&params)
+
+			var history, forecast []float64
+			for _, growthInWeek := range got.history {
+				history = append(history, growthInWeek.value)
+			}
+			for _, growthInWeek := range got.forecast {
+				forecast = append(forecast, math.Floor(growthInWeek.value*1e6+0.5)/1e6)
+			}
+			if fmt.Sprint(history) != fmt.Sprint(testCase.wantHistory) {
+				t.Errorf("history is %v, want %v", history, testCase.wantHistory)
+			}
+			if fmt.Sprint(forecast) != fmt.Sprint(testCase.wantForecast) {
+				t.Errorf("forecast is %v, want %v", forecast, testCase.wantForecast)
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,237 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetPushToPullLatencies",
+		Description:   "Table of the time between the first push and the first pull of tags per repository.",
+		NewParameters: func() StatsMethodParameters { return &GetPushToPullLatenciesParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetPushToPullLatencies(params.(*GetPushToPullLatenciesParameters))
+		},
+	})
+}
+
+/*PushToPullLatenciesPerRepositories is a slice of pushToPullLatenciesPerRepository
+structs containing the name of a repository and the distribution of the time
+between the first push and the first pull of each of its tags.*/
+type PushToPullLatenciesPerRepositories struct {
+	data  []pushToPullLatenciesPerRepository
+	title string
+}
+
+type pushToPullLatenciesPerRepository struct {
+	repositoryName   string
+	tagCount         int
+	neverPulledCount int
+	//sorted ascendingly
+	latencies []time.Duration
+}
+
+func (p pushToPullLatenciesPerRepository) median() time.Duration {
+	return percentileDuration(p.latencies, 50)
+}
+
+/*GetTableValues for the PushToPullLatenciesPerRepositories type converts a
+PushToPullLatenciesPerRepositories slice into a table of the median,
+90th percentile and maximum latency per repository
+that can be used by a table generator.
+The output rows are guaranteed to be ordered.
+This method is a requirement of the outputgen.TableChartable interface.*/
+func (p *PushToPullLatenciesPerRepositories) GetTableValues() outputgen.TableValues {
+
+	tableValues := outputgen.TableValues{
+		ColumnNames: []string{"Repository", "Tags pushed", "Never pulled", "Median", "90th percentile", "Max"},
+	}
+
+	for _, latencies := range p.data {
+		if len(latencies.latencies) == 0 {
+			tableValues.Rows = append(tableValues.Rows, []string{
+				latencies.repositoryName,
+				fmt.Sprintf("%d", latencies.tagCount),
+				fmt.Sprintf("%d", latencies.neverPulledCount),
+				"-", "-", "-",
+			})
+			continue
+		}
+		tableValues.Rows = append(tableValues.Rows, []string{
+			latencies.repositoryName,
+			fmt.Sprintf("%d", latencies.tagCount),
+			fmt.Sprintf("%d", latencies.neverPulledCount),
+			formatDuration(latencies.median()),
+			formatDuration(percentileDuration(latencies.latencies, 90)),
+			formatDuration(percentileDuration(latencies.latencies, 100)),
+		})
+	}
+
+	return tableValues
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (p *PushToPullLatenciesPerRepositories) SetTitle(title string) {
+	log.Printf("\nPushToPullLatenciesPerRepositories :: %v .SetTitle %s", p, title)
+	p.title = title
+	log.Printf("\nNewTitle::%s", p.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (p *PushToPullLatenciesPerRepositories) Title() string {
+	if len(p.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", p)
+	}
+	return p.title
+}
+
+/*GetPushToPullLatenciesParameters is the type
+that provides a wrapper for the parameters passed to the
+GetPushToPullLatencies stats function.
+This type implements the StatsMethodParameters interface type.*/
+type GetPushToPullLatenciesParameters struct {
+	Period
+	MaxNumberOfElements int `doc:"Number of repositories listed"`
+	Filter
+}
+
+/*IsValid check whether all fields in the GetPushToPullLatenciesParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetPushToPullLatenciesParameters) IsValid() (bool, string) {
+	if g.MaxNumberOfElements < 1 {
+		return false, "MaxNumberOfElements is less than one"
+	}
+	return g.Filter.IsValid()
+}
+
+/*getFirstPushAndPull returns the time of the very first push to the given
+tag and the time of the first pull of the tag at or after that push.
+The returned bools indicate whether the tag has been pushed or pulled at all.
+As in countTagOverwrites, the very first push to the tag may have been
+performed by any user, but only the pulls by users passing the given filter
+are taken into account.*/
+func getFirstPushAndPull(tag *Tag, compiledFilter *compiledFilter) (time.Time, bool, time.Time, bool) {
+
+	var firstPush time.Time
+	isPushed := false
+	for _, push := range tag.Pushes {
+		if !isPushed || push.Timestamp.Before(firstPush) {
+			firstPush = push.Timestamp
+			isPushed = true
+		}
+	}
+
+	var firstPull time.Time
+	isPulled := false
+	if !isPushed {
+		return firstPush, isPushed, firstPull, isPulled
+	}
+
+	for _, pull := range tag.Pulls {
+		if pull.Timestamp.Before(firstPush) || !compiledFilter.includesUser(pull.User) {
+			continue
+		}
+		if !isPulled || pull.Timestamp.Before(firstPull) {
+			firstPull = pull.Timestamp
+			isPulled = true
+		}
+	}
+
+	return firstPush, isPushed, firstPull, isPulled
+
+}
+
+/*GetPushToPullLatencies generates a struct containing, for the
+<MaxNumberOfElements> repositories with the slowest median,
+the distribution of the time between the first push of a tag
+and its first pull, for all tags first pushed since <StartDate>
+according to the given CSV data.
+
+Each struct within the list of structs in the data field of the returned
+PushToPullLatenciesPerRepositories struct contains the name of the
+repository, the number of tags first pushed since <StartDate>, the number of
+these tags which have never been pulled and the latencies of all other tags.
+Only the repositories and pulls passing the Filter of the parameters
+are taken into account; the first push of a tag counts whoever performed it.
+Repositories with the same median latency are ordered by name.
+Check the GetPushToPullLatenciesParameters struct for parameters.
+This method is registered as the "GetPushToPullLatencies" stats method.*/
+func (registry *Registry) GetPushToPullLatencies(params *GetPushToPullLatenciesParameters) *PushToPullLatenciesPerRepositories {
+
+	log.Printf("\nAnalyse :: GetPushToPullLatencies :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetPushToPullLatencies :: params are invalid :: %s", reason)
+	}
+
+	var allLatencies PushToPullLatenciesPerRepositories
+	compiledFilter := params.Filter.compile()
+
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+
+			latencies := pushToPullLatenciesPerRepository{
+				repositoryName: repository.Name,
+			}
+			for _, tag := range repository.Tags {
+				firstPush, isPushed, firstPull, isPulled := getFirstPushAndPull(tag, compiledFilter)
+				if !isPushed || !isWithinPeriod(firstPush, params) {
+					continue
+				}
+				latencies.tagCount++
+				if !isPulled || !firstPull.Before(params.EndDate()) {
+					latencies.neverPulledCount++
+					continue
+				}
+				latencies.latencies = append(latencies.latencies, firstPull.Sub(firstPush))
+			}
+
+			if latencies.tagCount == 0 {
+				continue
+			}
+
+			sort.Slice(latencies.latencies, func(idxA, idxB int) bool {
+				return latencies.latencies[idxA] < latencies.latencies[idxB]
+			})
+			allLatencies.data = append(allLatencies.data, latencies)
+		}
+	}
+
+	//Sort the elements in the data slice by the median latency descendingly, by name otherwise
+	sort.Slice(allLatencies.data, func(idxA, idxB int) bool {
+		elementA := allLatencies.data[idxA]
+		elementB := allLatencies.data[idxB]
+		if elementA.median() == elementB.median() {
+			return elementA.repositoryName < elementB.repositoryName
+		}
+		return elementA.median() > elementB.median()
+	})
+
+	//Trim the output slice to the size defined in MaxNumberOfElements
+	//which will determine the number of rows shown in the table
+	if int(params.MaxNumberOfElements) < len(allLatencies.data) {
+		allLatencies.data = allLatencies.data[:params.MaxNumberOfElements]
+	}
+
+	return &allLatencies
+
+}
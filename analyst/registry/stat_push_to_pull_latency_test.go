@@ -0,0 +1,111 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetFirstPushAndPull(t *testing.T) {
+
+	registry := newTestRegistry([]testAccess{
+		//Pulled before the (second) first push, e.g. of a reused tag name
+		{pullOperation, "game/server", "v1", "bob", "2017-10-01 09:00"},
+		{pushOperation, "game/server", "v1", "robot$ci", "2017-10-02 10:00"},
+		{pushOperation, "game/server", "v1", "alice", "2017-10-02 11:00"},
+		{pullOperation, "game/server", "v1", "robot$ci", "2017-10-02 11:30"},
+		{pullOperation, "game/server", "v1", "bob", "2017-10-02 12:00"},
+		{pushOperation, "game/server", "v2", "alice", "2017-10-03 10:00"},
+		{pullOperation, "game/server", "v3", "bob", "2017-10-03 10:00"},
+	})
+	tags := registry.Projects[1].Repositories["game/server"].Tags
+
+	testCases := []struct {
+		name          string
+		tag           string
+		filter        Filter
+		wantFirstPush string
+		wantIsPushed  bool
+		wantFirstPull string
+		wantIsPulled  bool
+	}{
+		{
+			name:          "pushed and pulled",
+			tag:           "v1",
+			wantFirstPush: "2017-10-02 10:00",
+			wantIsPushed:  true,
+			wantFirstPull: "2017-10-02 11:30",
+			wantIsPulled:  true,
+		},
+		{
+			name:          "ignored user",
+			tag:           "v1",
+			filter:        Filter{UsersToIgnore: []string{"robot$*"}},
+			wantFirstPush: "2017-10-02 10:00",
+			wantIsPushed:  true,
+			wantFirstPull: "2017-10-02 12:00",
+			wantIsPulled:  true,
+		},
+		{
+			name:          "never pulled",
+			tag:           "v2",
+			wantFirstPush: "2017-10-03 10:00",
+			wantIsPushed:  true,
+		},
+		{
+			name: "never pushed",
+			tag:  "v3",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			firstPush, isPushed, firstPull, isPulled := getFirstPushAndPull(tags[testCase.tag], testCase.filter.compile())
+			if isPushed != testCase.wantIsPushed || (isPushed && !firstPush.Equal(testTime(testCase.wantFirstPush))) {
+				t.Errorf("first push is %s (%t), want %s (%t)", firstPush, isPushed, testCase.wantFirstPush, testCase.wantIsPushed)
+			}
+			if isPulled != testCase.wantIsPulled || (isPulled && !firstPull.Equal(testTime(testCase.wantFirstPull))) {
+				t.Errorf("first pull is %s (%t), want %s (%t)", firstPull, isPulled, testCase.wantFirstPull, testCase.wantIsPulled)
+			}
+
+		})
+	}
+
+}
+
+func TestGetPushToPullLatencies(t *testing.T) {
+
+	registry := newTestRegistry([]testAccess{
+		{pushOperation, "game/server", "v1", "alice", "2017-10-02 10:00"},
+		{pullOperation, "game/server", "v1", "bob", "2017-10-02 12:00"},
+		{pushOperation, "game/client", "v1", "alice", "2017-10-02 10:00"},
+		{pullOperation, "game/client", "v1", "bob", "2017-10-02 11:00"},
+		//Never pulled, i.e. all with a median of zero
+		{pushOperation, "tools/linter", "v1", "alice", "2017-10-03 10:00"},
+		{pushOperation, "tools/formatter", "v1", "alice", "2017-10-03 10:00"},
+		{pushOperation, "meta/cache", "v1", "alice", "2017-10-03 10:00"},
+	})
+
+	params := GetPushToPullLatenciesParameters{MaxNumberOfElements: 4}
+	params.SetStartDate(testTime("2017-10-01"))
+	params.SetEndDate(testTime("2017-10-09"))
+
+	//Run repeatedly since the order of the projects is random
+	want := []string{"game/server", "game/client", "meta/cache", "tools/formatter"}
+	for run := 0; run < 10; run++ {
+		var got []string
+		for _, latencies := range registry.GetPushToPullLatencies(&params).data {
+			got = append(got, latencies.repositoryName)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("repositories are %v, want %v", got, want)
+		}
+	}
+
+}
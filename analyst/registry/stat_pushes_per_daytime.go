@@ -10,11 +10,21 @@ import (
 	"fmt"
 	"log"
 	"sort"
-	"time"
 
 	"github.com/demonware/harbor-analytics/analyst/outputgen"
 )
 
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetPushesPerDaytimes",
+		Description:   "Bar chart of the number of pushes per hour of the day.",
+		NewParameters: func() StatsMethodParameters { return &GetPushesPerDaytimesParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetPushesPerDaytimes(params.(*GetPushesPerDaytimesParameters))
+		},
+	})
+}
+
 /*PushesPerDaytimes is a slice of pushesPerDaytime structs
 containing the hour of a day and the number
 of pushes to the registry within this hour.*/
@@ -70,45 +80,15 @@ that provides a wrapper for the parameters passed to the
 GetPushesPerDaytimes stats function.
 This type implements the StatsMethodParameters interface type.*/
 type GetPushesPerDaytimesParameters struct {
-	startDate time.Time
-}
-
-/*SetStartDate sets the startDate parameter in the parameters struct,
-in this case: GetPushesPerDaytimesParameters.
-This method is required by the StatsMethodParameters interface.*/
-func (g *GetPushesPerDaytimesParameters) SetStartDate(startDate time.Time) {
-	log.Printf("\nGetPushesPerDaytimesParameters.SetStartDate to %s", startDate)
-	g.startDate = startDate
-}
-
-/*StartDate returns the startDate parameter of the parameters struct,
-in this case: GetPushesPerDaytimesParameters.
-This method is required by the StatsMethodParameters interface.*/
-func (g *GetPushesPerDaytimesParameters) StartDate() time.Time {
-	return g.startDate
+	Period
+	Filter
 }
 
 /*IsValid check whether all fields in the GetPushesPerDaytimesParameters
 have a valid value. If not valid, false and a reason string is returned.
 This method is required by the StatsMethodParameters interface.*/
 func (g *GetPushesPerDaytimesParameters) IsValid() (bool, string) {
-	return true, ""
-}
-
-/*GetPushesPerDaytimesWrapper is a wrapper method of the
-GetPushesPerDaytimes methods. In contrast to the concrete
-GetPushesPerDaytimes method, it accept interface types
-which will then be converted to concrete types and pasded to the
-concrete method.
-
-Wrappers are a workaround to make statistical methods of the
-registry type generically accessible. The wrappers are accessed in the
-configreader module when configuration is mapped to the methods here.
-It would not be possibls to access the concrete methods (like
-GetPushesPerDaytimes) without knowing the concrete parameter and
-output types.*/
-func (registry *Registry) GetPushesPerDaytimesWrapper(paramsGeneric StatsMethodParameters) outputgen.BarChartable {
-	return registry.GetPushesPerDaytimes(paramsGeneric.(*GetPushesPerDaytimesParameters))
+	return g.Filter.IsValid()
 }
 
 /*GetPushesPerDaytimes generates a struct containing the
@@ -122,7 +102,7 @@ the hour of a day and the number of pushes that
 have been performed within this hour accumulated ever since <StartDate>.
 
 Check the GetPushesPerDaytimesParameters struct for parameters.
-This method is wrapped by GetPushesPerDaytimesWrapper.*/
+This method is registered as the "GetPushesPerDaytimes" stats method.*/
 func (registry *Registry) GetPushesPerDaytimes(params *GetPushesPerDaytimesParameters) *PushesPerDaytimes {
 
 	log.Printf("\nAnalyse :: GetPushesPerDaytimes :: %v", params)
@@ -131,15 +111,22 @@ func (registry *Registry) GetPushesPerDaytimes(params *GetPushesPerDaytimesParam
 	}
 
 	pushesPerDayimeMapping := map[int]int{}
+	compiledFilter := params.Filter.compile()
 
 	//Go through all repositories and sum up the pushes
 	//performed to any tag in the repository
 	for _, project := range registry.Projects {
 		for _, repository := range project.Repositories {
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
 			for _, tag := range repository.Tags {
 				for _, push := range tag.Pushes {
-					if push.Timestamp.Before(params.StartDate()) {
-						log.Printf("\nIgnore push to %s on %s as before relevant time.", repository.Name, push.Timestamp)
+					if !compiledFilter.includesUser(push.User) {
+						continue
+					}
+					if !isWithinPeriod(push.Timestamp, params) {
+						log.Printf("\nIgnore push to %s on %s as outside relevant time.", repository.Name, push.Timestamp)
 						continue
 					}
 					pushesPerDayimeMapping[push.Timestamp.Hour()] = pushesPerDayimeMapping[push.Timestamp.Hour()] + 1
@@ -7,6 +7,7 @@
 package registry
 
 import (
+	"fmt"
 	"log"
 	"sort"
 	"time"
@@ -14,6 +15,21 @@ import (
 	"github.com/demonware/harbor-analytics/analyst/outputgen"
 )
 
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetMostPushedToRepositories",
+		Description:   "Bar chart of the repositories or teams with the most pushes, optionally compared with the previous period.",
+		NewParameters: func() StatsMethodParameters { return &GetMostPushedToRepositoriesParameters{} },
+		Compute: func(registry *Registry, paramsGeneric StatsMethodParameters) outputgen.Chartable {
+			params := paramsGeneric.(*GetMostPushedToRepositoriesParameters)
+			if params.CompareWithPreviousPeriod {
+				return registry.GetMostPushedToRepositoriesComparison(params)
+			}
+			return registry.GetMostPushedToRepositories(params)
+		},
+	})
+}
+
 /*PushesPerRepositories is a slice of pushesPerRepository structs
 containing the name of a repository and the number
 of pushes to it.*/
@@ -27,6 +43,20 @@ type pushesPerRepository struct {
 	pushCount      int
 }
 
+/*PushesPerRepositoriesComparison is a slice of pushesPerRepositoryComparison
+structs containing the name of a repository and the number of pushes
+to it within the current and the previous period.*/
+type PushesPerRepositoriesComparison struct {
+	data  []pushesPerRepositoryComparison
+	title string
+}
+
+type pushesPerRepositoryComparison struct {
+	repositoryName    string
+	pushCount         int
+	previousPushCount int
+}
+
 /*GetOrderedBarChartValues for the PushesPerRepositories type converts a
 PushesPerRepositories slice into a map that can be used by a chart generator.
 The output slice is guaranteed to be ordered.
@@ -64,29 +94,64 @@ func (p *PushesPerRepositories) Title() string {
 	return p.title
 }
 
+/*GetGroupedBarChartValues for the PushesPerRepositoriesComparison type converts a
+PushesPerRepositoriesComparison slice into groups of the current and previous
+push count per repository that can be used by a chart generator.
+The label of each group contains the difference between the two periods.
+The output slice is guaranteed to be ordered.
+This method is a requirement of the outputgen.GroupedBarChartable interface.*/
+func (p PushesPerRepositoriesComparison) GetGroupedBarChartValues() outputgen.GroupedBarChartableValues {
+
+	chartables := outputgen.GroupedBarChartableValues{
+		SeriesNames: []string{"Current period", "Previous period"},
+	}
+
+	for _, pushesPerRepository := range p.data {
+		chartables.Groups = append(chartables.Groups, outputgen.GroupedBarChartableGroup{
+			Label: fmt.Sprintf("%s (%s)",
+				pushesPerRepository.repositoryName,
+				formatDelta(pushesPerRepository.pushCount-pushesPerRepository.previousPushCount)),
+			Values: []int{pushesPerRepository.pushCount, pushesPerRepository.previousPushCount},
+		})
+	}
+
+	return chartables
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (p *PushesPerRepositoriesComparison) SetTitle(title string) {
+	log.Printf("\nPushesPerRepositoriesComparison :: %v .SetTitle %s", p, title)
+	p.title = title
+	log.Printf("\nNewTitle::%s", p.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (p *PushesPerRepositoriesComparison) Title() string {
+	if len(p.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", p)
+	}
+	return p.title
+}
+
 /*GetMostPushedToRepositoriesParameters is the type
 that provides a wrapper for the parameters passed to the
 GetMostPushedToRepositories stats function.
+If CompareWithPreviousPeriod is set, the push count of the
+previous period of the same length will be shown next to the current one,
+which requires a period with a start date.
+GroupBy must be one of "repository" or "team" (see registry.TeamMapping)
+and determines whether the pushes are counted per repository or per team.
 This type implements the StatsMethodParameters interface type.*/
 type GetMostPushedToRepositoriesParameters struct {
-	startDate            time.Time
-	MaxNumberOfElements  int
-	RepositoriesToIgnore []string
-}
-
-/*SetStartDate sets the startDate parameter in the parameters struct,
-in this case: GetMostPushedToRepositoriesParameters.
-This method is required by the StatsMethodParameters interface.*/
-func (g *GetMostPushedToRepositoriesParameters) SetStartDate(startDate time.Time) {
-	log.Printf("\nGetMostPushedToRepositoriesParameters.SetStartDate to %s", startDate)
-	g.startDate = startDate
-}
-
-/*StartDate returns the startDate parameter of the parameters struct,
-in this case: GetMostPushedToRepositoriesParameters.
-This method is required by the StatsMethodParameters interface.*/
-func (g *GetMostPushedToRepositoriesParameters) StartDate() time.Time {
-	return g.startDate
+	Period
+	MaxNumberOfElements       int    `doc:"Number of repositories shown"`
+	CompareWithPreviousPeriod bool   `doc:"Compare with the previous period of the same length"`
+	GroupBy                   string `doc:"Dimension to group by, one of repository or team" enum:"repository,team" default:"repository"`
+	Filter
 }
 
 /*IsValid check whether all fields in the GetMostPushedToRepositoriesParameters
@@ -96,23 +161,13 @@ func (g *GetMostPushedToRepositoriesParameters) IsValid() (bool, string) {
 	if g.MaxNumberOfElements < 1 {
 		return false, "MaxNumberOfElements is less than one"
 	}
-	return true, ""
-}
-
-/*GetMostPushedToRepositoriesWrapper is a wrapper method of the
-GetMostPushedToRepositories methods. In contrast to the concrete
-GetMostPushedToRepositories method, it accept interface types
-which will then be converted to concrete types and pasded to the
-concrete method.
-
-Wrappers are a workaround to make statistical methods of the
-registry type generically accessible. The wrappers are accessed in the
-configreader module when configuration is mapped to the methods here.
-It would not be possibls to access the concrete methods (like
-GetMostPushedToRepositories) without knowing the concrete parameter and
-output types.*/
-func (registry *Registry) GetMostPushedToRepositoriesWrapper(paramsGeneric StatsMethodParameters) outputgen.BarChartable {
-	return registry.GetMostPushedToRepositories(paramsGeneric.(*GetMostPushedToRepositoriesParameters))
+	if g.CompareWithPreviousPeriod && g.StartDate().IsZero() {
+		return false, "CompareWithPreviousPeriod requires a period with a start date"
+	}
+	if g.GroupBy != repositoryGroupBy && g.GroupBy != teamGroupBy {
+		return false, fmt.Sprintf("GroupBy \"%s\" is not one of repository or team", g.GroupBy)
+	}
+	return g.Filter.IsValid()
 }
 
 /*GetMostPushedToRepositories generates a struct containing the
@@ -123,10 +178,13 @@ Each struct within the list of structs in the data field of the returned
 PushesPerRepositories struct contains
 the name of the repository and the number of pushes that
 have been performed to it ever since <StartDate>.
-Repositories matching a name in the given list of repositoriesToIgnore
-will not be included in the returned structure.
+If <GroupBy> is "team", the pushes are attributed to teams instead
+(see registry.TeamMapping) and the name of the team takes the place
+of the name of the repository.
+Only the repositories and pushes passing the Filter of the parameters
+will be included in the returned structure.
 Check the GetMostPushedToRepositoriesParameters struct for parameters.
-This method is wrapped by GetMostPushedToRepositoriesWrapper.*/
+This method is registered as the "GetMostPushedToRepositories" stats method.*/
 func (registry *Registry) GetMostPushedToRepositories(params *GetMostPushedToRepositoriesParameters) *PushesPerRepositories {
 
 	log.Printf("\nAnalyse :: GetMostPushedToRepositories :: %v", params)
@@ -135,42 +193,57 @@ func (registry *Registry) GetMostPushedToRepositories(params *GetMostPushedToRep
 	}
 
 	var allPushesPerRepositories PushesPerRepositories
+	for repositoryName, pushCount := range registry.getPushesPerGroup(params, params.StartDate(), params.EndDate()) {
+		allPushesPerRepositories.data = append(allPushesPerRepositories.data, pushesPerRepository{
+			repositoryName: repositoryName,
+			pushCount:      pushCount,
+		})
+	}
 
-	//Go through all repositories and sum up the pushes
-	//performed to any tag in the repository
-	for _, project := range registry.Projects {
-		for _, repository := range project.Repositories {
+	//Sort the elements in the data slace by pushCount descendingly
+	sort.Slice(allPushesPerRepositories.data, func(idxA, idxB int) bool {
+		return allPushesPerRepositories.data[idxA].pushCount > allPushesPerRepositories.data[idxB].pushCount
+	})
 
-			skipRepository := false
-			for _, repositoryNameToIgnore := range params.RepositoriesToIgnore {
-				if repositoryNameToIgnore == repository.Name {
-					skipRepository = true
-					log.Printf("\nIgnore repository %s.", repository.Name)
-					break
-				}
-			}
+	//Trim the output slice to the size defined in MaxNumberOfElements
+	//which will determine the number of bars shown in the chart
+	if int(params.MaxNumberOfElements) < len(allPushesPerRepositories.data) {
+		allPushesPerRepositories.data = allPushesPerRepositories.data[:params.MaxNumberOfElements]
+	}
 
-			if skipRepository {
-				continue
-			}
+	return &allPushesPerRepositories
 
-			totalPushes := 0
-			for _, tag := range repository.Tags {
-				pushes := 0
-				for _, push := range tag.Pushes {
-					if push.Timestamp.Before(params.StartDate()) {
-						log.Printf("\nIgnore push to %s on %s as before relevant time.", repository.Name, push.Timestamp)
-						continue
-					}
-					pushes++
-				}
-				totalPushes += pushes
-			}
-			allPushesPerRepositories.data = append(allPushesPerRepositories.data, pushesPerRepository{
-				repositoryName: repository.Name,
-				pushCount:      totalPushes,
-			})
-		}
+}
+
+/*GetMostPushedToRepositoriesComparison generates a struct containing the
+<MaxNumberOfElements> most pushed-to repositories (since <StartDate>)
+according to the given CSV data, just like GetMostPushedToRepositories does.
+
+In addition to the number of pushes since <StartDate>, each struct within
+the list of structs in the data field of the returned
+PushesPerRepositoriesComparison struct contains the number of pushes
+within the previous period of the same length
+(e.g. this week and last week).
+The repositories are ranked by the number of pushes within the current period.
+Check the GetMostPushedToRepositoriesParameters struct for parameters.
+This method is called by the "GetMostPushedToRepositories" stats method.*/
+func (registry *Registry) GetMostPushedToRepositoriesComparison(params *GetMostPushedToRepositoriesParameters) *PushesPerRepositoriesComparison {
+
+	log.Printf("\nAnalyse :: GetMostPushedToRepositoriesComparison :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetMostPushedToRepositoriesComparison :: params are invalid :: %s", reason)
+	}
+
+	previousPushesPerRepository := registry.getPushesPerGroup(
+		params, getPreviousPeriodStartDate(params.StartDate(), params.EndDate()), params.StartDate())
+
+	var allPushesPerRepositories PushesPerRepositoriesComparison
+	for repositoryName, pushCount := range registry.getPushesPerGroup(params, params.StartDate(), params.EndDate()) {
+		allPushesPerRepositories.data = append(allPushesPerRepositories.data, pushesPerRepositoryComparison{
+			repositoryName:    repositoryName,
+			pushCount:         pushCount,
+			previousPushCount: previousPushesPerRepository[repositoryName],
+		})
 	}
 
 	//Sort the elements in the data slace by pushCount descendingly
@@ -179,7 +252,7 @@ func (registry *Registry) GetMostPushedToRepositories(params *GetMostPushedToRep
 	})
 
 	//Trim the output slice to the size defined in MaxNumberOfElements
-	//which will determine the number of bars shown in the chart
+	//which will determine the number of bar groups shown in the chart
 	if int(params.MaxNumberOfElements) < len(allPushesPerRepositories.data) {
 		allPushesPerRepositories.data = allPushesPerRepositories.data[:params.MaxNumberOfElements]
 	}
@@ -187,3 +260,65 @@ func (registry *Registry) GetMostPushedToRepositories(params *GetMostPushedToRep
 	return &allPushesPerRepositories
 
 }
+
+/*getPushesPerGroup returns the pushes between <from> (inclusive) and <until> (exclusive)
+mapped to the names of the repositories or of the teams, depending on <GroupBy>.*/
+func (registry *Registry) getPushesPerGroup(params *GetMostPushedToRepositoriesParameters, from time.Time, until time.Time) map[string]int {
+	if params.GroupBy == teamGroupBy {
+		return registry.getAccessesPerTeam(pushOperation, params.Filter, anyUserClass, from, until)
+	}
+	return registry.getAccessesPerRepository(pushOperation, params.Filter, anyUserClass, from, until)
+}
+
+/*getAccessesPerRepository sums up the pushes to, the pulls of or both
+(depending on the given operation, i.e. "push", "pull" or "any")
+any tag of each repository between <from> (inclusive) and <until> (exclusive)
+and returns them mapped to the repository names.
+Only the repositories passing the given filter and the accesses passing
+it and performed by users of the given userClass (which may be "any"
+to include accesses by unknown users as well)
+will be included in the returned map.*/
+func (registry *Registry) getAccessesPerRepository(operation string, filter Filter, userClass string, from time.Time, until time.Time) map[string]int {
+
+	accessesPerRepository := map[string]int{}
+	classPerUser := registry.getClassPerUser()
+	compiledFilter := filter.compile()
+
+	//Go through all repositories and sum up the accesses
+	//performed to any tag in the repository
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+
+			totalAccesses := 0
+			for _, tag := range repository.Tags {
+				accesses := 0
+				for _, accessLog := range getLogsByOperation(tag, operation) {
+					if !compiledFilter.includesUser(accessLog.User) {
+						continue
+					}
+					userName := ""
+					if accessLog.User != nil {
+						userName = accessLog.User.Name
+					}
+					if !isOfUserClass(classPerUser, userName, userClass) {
+						continue
+					}
+					if accessLog.Timestamp.Before(from) || !accessLog.Timestamp.Before(until) {
+						log.Printf("\nIgnore access to %s on %s as outside relevant time.", repository.Name, accessLog.Timestamp)
+						continue
+					}
+					accesses++
+				}
+				totalAccesses += accesses
+			}
+			accessesPerRepository[repository.Name] = totalAccesses
+		}
+	}
+
+	return accessesPerRepository
+
+}
@@ -0,0 +1,74 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetMostPushedToRepositories(t *testing.T) {
+
+	registry := newTestRegistry([]testAccess{
+		{pushOperation, "game/server", "v1", "alice", "2017-10-02 10:00"},
+		{pushOperation, "game/server", "v2", "alice", "2017-10-03 10:00"},
+		{pushOperation, "game/client", "v1", "bob", "2017-10-03 11:00"},
+		{pushOperation, "web/frontend", "v1", "bob", "2017-10-04 09:00"},
+		{pullOperation, "web/frontend", "v1", "alice", "2017-10-04 10:00"},
+		//Within the previous period
+		{pushOperation, "web/frontend", "v0", "bob", "2017-09-28 09:00"},
+	})
+	registry.TeamMapping = TeamMapping{Teams: []Team{
+		{Name: "backend", Projects: []string{"game"}},
+		{Name: "frontend", Projects: []string{"web"}},
+	}}
+
+	testCases := []struct {
+		groupBy      string
+		want         map[string]int
+		wantPrevious map[string]int
+	}{
+		{
+			groupBy:      repositoryGroupBy,
+			want:         map[string]int{"game/server": 2, "game/client": 1, "web/frontend": 1},
+			wantPrevious: map[string]int{"game/server": 0, "game/client": 0, "web/frontend": 1},
+		},
+		{
+			groupBy:      teamGroupBy,
+			want:         map[string]int{"backend": 3, "frontend": 1},
+			wantPrevious: map[string]int{"backend": 0, "frontend": 1},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.groupBy, func(t *testing.T) {
+
+			params := GetMostPushedToRepositoriesParameters{MaxNumberOfElements: 5, GroupBy: testCase.groupBy}
+			params.SetStartDate(testTime("2017-10-01"))
+			params.SetEndDate(testTime("2017-10-08"))
+
+			got := map[string]int{}
+			for _, pushesPerRepository := range registry.GetMostPushedToRepositories(&params).data {
+				got[pushesPerRepository.repositoryName] = pushesPerRepository.pushCount
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("GetMostPushedToRepositories() = %v, want %v", got, testCase.want)
+			}
+
+			params.CompareWithPreviousPeriod = true
+			gotPrevious := map[string]int{}
+			for _, pushesPerRepository := range registry.GetMostPushedToRepositoriesComparison(&params).data {
+				gotPrevious[pushesPerRepository.repositoryName] = pushesPerRepository.previousPushCount
+			}
+			if !reflect.DeepEqual(gotPrevious, testCase.wantPrevious) {
+				t.Errorf("GetMostPushedToRepositoriesComparison() has the previous counts %v, want %v", gotPrevious, testCase.wantPrevious)
+			}
+
+		})
+	}
+
+}
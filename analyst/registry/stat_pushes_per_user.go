@@ -7,6 +7,7 @@
 package registry
 
 import (
+	"fmt"
 	"log"
 	"sort"
 	"time"
@@ -14,6 +15,21 @@ import (
 	"github.com/demonware/harbor-analytics/analyst/outputgen"
 )
 
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetMostPushingUsers",
+		Description:   "Bar chart of the users with the most pushes, optionally either compared with the previous period or split by user class.",
+		NewParameters: func() StatsMethodParameters { return &GetMostPushingUsersParameters{} },
+		Compute: func(registry *Registry, paramsGeneric StatsMethodParameters) outputgen.Chartable {
+			params := paramsGeneric.(*GetMostPushingUsersParameters)
+			if params.CompareWithPreviousPeriod {
+				return registry.GetMostPushingUsersComparison(params)
+			}
+			return registry.GetMostPushingUsers(params)
+		},
+	})
+}
+
 /*PushesPerUsers is a slice of pushesPerUser structs
 containing the name of a user and the number
 of pushes performed by them.*/
@@ -25,20 +41,43 @@ type PushesPerUsers struct {
 type pushesPerUser struct {
 	userName  string
 	pushCount int
+	//only set if split by user class
+	userClass string
+}
+
+/*PushesPerUsersComparison is a slice of pushesPerUserComparison
+structs containing the name of a user and the number of pushes
+performed by them within the current and the previous period.*/
+type PushesPerUsersComparison struct {
+	data  []pushesPerUserComparison
+	title string
+}
+
+type pushesPerUserComparison struct {
+	userName          string
+	pushCount         int
+	previousPushCount int
 }
 
 /*GetOrderedBarChartValues for the PushesPerUsers type converts a
 PushesPerUsers slice into a map that can be used by a chart generator.
 The output slice is guaranteed to be ordered.
+If the users have been split by user class, automation users
+will be highlighted.
 This method is a requirement of the outputgen.BarChartable interface.*/
 func (p PushesPerUsers) GetOrderedBarChartValues() outputgen.BarChartableValuesOrdered {
 
 	var chartables outputgen.BarChartableValuesOrdered
 
 	for _, pushesPerUser := range p.data {
+		label := pushesPerUser.userName
+		if len(pushesPerUser.userClass) > 0 {
+			label = fmt.Sprintf("%s (%s)", pushesPerUser.userName, pushesPerUser.userClass)
+		}
 		chartables = append(chartables, outputgen.BarChartableValue{
-			Label: pushesPerUser.userName,
-			Value: pushesPerUser.pushCount,
+			Label:       label,
+			Value:       pushesPerUser.pushCount,
+			Highlighted: pushesPerUser.userClass == automationUserClass,
 		})
 	}
 
@@ -64,29 +103,66 @@ func (p *PushesPerUsers) Title() string {
 	return p.title
 }
 
+/*GetGroupedBarChartValues for the PushesPerUsersComparison type converts a
+PushesPerUsersComparison slice into groups of the current and previous
+push count per user that can be used by a chart generator.
+The label of each group contains the difference between the two periods.
+The output slice is guaranteed to be ordered.
+This method is a requirement of the outputgen.GroupedBarChartable interface.*/
+func (p PushesPerUsersComparison) GetGroupedBarChartValues() outputgen.GroupedBarChartableValues {
+
+	chartables := outputgen.GroupedBarChartableValues{
+		SeriesNames: []string{"Current period", "Previous period"},
+	}
+
+	for _, pushesPerUser := range p.data {
+		chartables.Groups = append(chartables.Groups, outputgen.GroupedBarChartableGroup{
+			Label: fmt.Sprintf("%s (%s)",
+				pushesPerUser.userName,
+				formatDelta(pushesPerUser.pushCount-pushesPerUser.previousPushCount)),
+			Values: []int{pushesPerUser.pushCount, pushesPerUser.previousPushCount},
+		})
+	}
+
+	return chartables
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (p *PushesPerUsersComparison) SetTitle(title string) {
+	log.Printf("\nPushesPerUsersComparison :: %v .SetTitle %s", p, title)
+	p.title = title
+	log.Printf("\nNewTitle::%s", p.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (p *PushesPerUsersComparison) Title() string {
+	if len(p.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", p)
+	}
+	return p.title
+}
+
 /*GetMostPushingUsersParameters is the type
 that provides a wrapper for the parameters passed to the
 GetMostPushingUsers stats function.
+If CompareWithPreviousPeriod is set, the push count of the
+previous period of the same length will be shown next to the current one,
+which requires a period with a start date.
+If SplitByUserClass is set the class of each user
+will be shown (see registry.UserClassification).
+Both cannot be set at the same time.
 This type implements the StatsMethodParameters interface type.*/
 type GetMostPushingUsersParameters struct {
-	startDate           time.Time
-	MaxNumberOfElements int
-	UsersToIgnore       []string
-}
-
-/*SetStartDate sets the startDate parameter in the parameters struct,
-in this case: GetMostPushingUsersParameters.
-This method is required by the StatsMethodParameters interface.*/
-func (g *GetMostPushingUsersParameters) SetStartDate(startDate time.Time) {
-	log.Printf("\nGetMostPushingUsersParameters.SetStartDate to %s", startDate)
-	g.startDate = startDate
-}
-
-/*StartDate returns the startDate parameter of the parameters struct,
-in this case: GetMostPushingUsersParameters.
-This method is required by the StatsMethodParameters interface.*/
-func (g *GetMostPushingUsersParameters) StartDate() time.Time {
-	return g.startDate
+	Period
+	MaxNumberOfElements       int  `doc:"Number of users shown"`
+	CompareWithPreviousPeriod bool `doc:"Compare with the previous period of the same length"`
+	UserClassSelection
+	SplitByUserClass bool `doc:"Show human and automation users separately"`
+	Filter
 }
 
 /*IsValid check whether all fields in the GetMostPushingUsersParameters
@@ -96,23 +172,16 @@ func (g *GetMostPushingUsersParameters) IsValid() (bool, string) {
 	if g.MaxNumberOfElements < 1 {
 		return false, "MaxNumberOfElements is less than one"
 	}
-	return true, ""
-}
-
-/*GetMostPushingUsersWrapper is a wrapper method of the
-GetMostPushingUsers methods. In contrast to the concrete
-GetMostPushingUsers method, it accept interface types
-which will then be converted to concrete types and pasded to the
-concrete method.
-
-Wrappers are a workaround to make statistical methods of the
-registry type generically accessible. The wrappers are accessed in the
-configreader module when configuration is mapped to the methods here.
-It would not be possibls to access the concrete methods (like
-GetMostPushingUsers) without knowing the concrete parameter and
-output types.*/
-func (registry *Registry) GetMostPushingUsersWrapper(paramsGeneric StatsMethodParameters) outputgen.BarChartable {
-	return registry.GetMostPushingUsers(paramsGeneric.(*GetMostPushingUsersParameters))
+	if g.CompareWithPreviousPeriod && g.StartDate().IsZero() {
+		return false, "CompareWithPreviousPeriod requires a period with a start date"
+	}
+	if g.CompareWithPreviousPeriod && g.SplitByUserClass {
+		return false, "CompareWithPreviousPeriod and SplitByUserClass cannot be combined"
+	}
+	if isValid, reason := g.UserClassSelection.IsValid(); !isValid {
+		return false, reason
+	}
+	return g.Filter.IsValid()
 }
 
 /*GetMostPushingUsers generates a struct containing the top
@@ -121,10 +190,10 @@ repository (since <StartDate>) according to the given CSV data.
 Each struct within the list of structs in the data field of the returned
 PushesPerUsers struct contains the name of the user
 and the number of pushes that have been performed by them ever since <StartDate>.
-Users matching a name in the given list of usersToIgnore
-will not be included in the returned structure.
+Only the users and pushes passing the Filter of the parameters
+will be included in the returned structure.
 Check the GetMostPushingUsersParameters struct for parameters.
-This method is wrapped by GetMostPushingUsersWrapper.*/
+This method is registered as the "GetMostPushingUsers" stats method.*/
 func (registry *Registry) GetMostPushingUsers(params *GetMostPushingUsersParameters) *PushesPerUsers {
 
 	log.Printf("\nAnalyse :: GetMostPushingUsers :: %v", params)
@@ -132,31 +201,11 @@ func (registry *Registry) GetMostPushingUsers(params *GetMostPushingUsersParamet
 		log.Fatalf("\nGetMostPushingUsers :: params are invalid :: %s", reason)
 	}
 
-	allPushesPerUsersMapping := map[string]int{}
+	allPushesPerUsersMapping := registry.getAccessesPerUser(pushOperation, params.Filter, params.UserClass, params.StartDate(), params.EndDate())
 
-	for _, project := range registry.Projects {
-		for _, repository := range project.Repositories {
-			for _, tag := range repository.Tags {
-				for _, push := range tag.Pushes {
-					skipUser := false
-					for _, userNameToIgnore := range params.UsersToIgnore {
-						if userNameToIgnore == push.User.Name {
-							skipUser = true
-							log.Printf("\nIgnore user %s.", push.User.Name)
-							break
-						}
-					}
-					if skipUser {
-						continue
-					}
-					if push.Timestamp.Before(params.StartDate()) {
-						log.Printf("\nIgnore push to %s on %s as before relevant time.", repository.Name, push.Timestamp)
-						continue
-					}
-					allPushesPerUsersMapping[push.User.Name] = allPushesPerUsersMapping[push.User.Name] + 1
-				}
-			}
-		}
+	var classPerUser map[string]string
+	if params.SplitByUserClass {
+		classPerUser = registry.getClassPerUser()
 	}
 
 	var allPushesPerUsers PushesPerUsers
@@ -164,6 +213,7 @@ func (registry *Registry) GetMostPushingUsers(params *GetMostPushingUsersParamet
 		allPushesPerUsers.data = append(allPushesPerUsers.data, pushesPerUser{
 			userName:  username,
 			pushCount: pushCount,
+			userClass: classPerUser[username],
 		})
 	}
 
@@ -181,3 +231,89 @@ func (registry *Registry) GetMostPushingUsers(params *GetMostPushingUsersParamet
 	return &allPushesPerUsers
 
 }
+
+/*GetMostPushingUsersComparison generates a struct containing the top
+<MaxNumberOfElements> users who have performed the most pushes to any
+repository (since <StartDate>) according to the given CSV data,
+just like GetMostPushingUsers does.
+
+In addition to the number of pushes since <StartDate>, each struct within
+the list of structs in the data field of the returned
+PushesPerUsersComparison struct contains the number of pushes
+performed by the user within the previous period of the same length
+(e.g. this week and last week).
+The users are ranked by the number of pushes within the current period.
+Check the GetMostPushingUsersParameters struct for parameters.
+This method is called by the "GetMostPushingUsers" stats method.*/
+func (registry *Registry) GetMostPushingUsersComparison(params *GetMostPushingUsersParameters) *PushesPerUsersComparison {
+
+	log.Printf("\nAnalyse :: GetMostPushingUsersComparison :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetMostPushingUsersComparison :: params are invalid :: %s", reason)
+	}
+
+	previousPushesPerUser := registry.getAccessesPerUser(
+		pushOperation, params.Filter, params.UserClass, getPreviousPeriodStartDate(params.StartDate(), params.EndDate()), params.StartDate())
+
+	var allPushesPerUsers PushesPerUsersComparison
+	for username, pushCount := range registry.getAccessesPerUser(pushOperation, params.Filter, params.UserClass, params.StartDate(), params.EndDate()) {
+		allPushesPerUsers.data = append(allPushesPerUsers.data, pushesPerUserComparison{
+			userName:          username,
+			pushCount:         pushCount,
+			previousPushCount: previousPushesPerUser[username],
+		})
+	}
+
+	//Sort the elements in the data slace by pushCount descendingly
+	sort.Slice(allPushesPerUsers.data, func(idxA, idxB int) bool {
+		return allPushesPerUsers.data[idxA].pushCount > allPushesPerUsers.data[idxB].pushCount
+	})
+
+	//Trim the output slice to the size defined in MaxNumberOfElements
+	//which will determine the number of bar groups shown in the chart
+	if int(params.MaxNumberOfElements) < len(allPushesPerUsers.data) {
+		allPushesPerUsers.data = allPushesPerUsers.data[:params.MaxNumberOfElements]
+	}
+
+	return &allPushesPerUsers
+
+}
+
+/*getAccessesPerUser sums up the pushes, the pulls or both
+(depending on the given operation, i.e. "push", "pull" or "any")
+performed by each user between <from> (inclusive) and <until> (exclusive)
+and returns them mapped to the user names.
+Only the users and accesses passing the given filter and
+belonging to the given userClass will be included in the returned map.*/
+func (registry *Registry) getAccessesPerUser(operation string, filter Filter, userClass string, from time.Time, until time.Time) map[string]int {
+
+	accessesPerUser := map[string]int{}
+	classPerUser := registry.getClassPerUser()
+	compiledFilter := filter.compile()
+
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+			for _, tag := range repository.Tags {
+				for _, accessLog := range getLogsByOperation(tag, operation) {
+					if accessLog.User == nil || !compiledFilter.includesUser(accessLog.User) {
+						continue
+					}
+					if !isOfUserClass(classPerUser, accessLog.User.Name, userClass) {
+						continue
+					}
+					if accessLog.Timestamp.Before(from) || !accessLog.Timestamp.Before(until) {
+						log.Printf("\nIgnore access to %s on %s as outside relevant time.", repository.Name, accessLog.Timestamp)
+						continue
+					}
+					accessesPerUser[accessLog.User.Name] = accessesPerUser[accessLog.User.Name] + 1
+				}
+			}
+		}
+	}
+
+	return accessesPerUser
+
+}
@@ -0,0 +1,280 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetTagNameCategoriesPerProjects",
+		Description:   "Heat map of the tag name categories (e.g. semver, latest) per project.",
+		NewParameters: func() StatsMethodParameters { return &GetTagNameCategoriesPerProjectsParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetTagNameCategoriesPerProjects(params.(*GetTagNameCategoriesPerProjectsParameters))
+		},
+	})
+}
+
+/*otherTagCategory is the category of all tag names
+which do not match any of the tag category rules.*/
+const otherTagCategory = "other"
+
+/*defaultTagCategoryRules are used to classify tag names
+if no rules are configured. The first matching rule wins.*/
+var defaultTagCategoryRules = []map[string]string{
+	{"latest": `^latest$`},
+	{"semantic version": `^v?[0-9]+\.[0-9]+\.[0-9]+([-+][0-9A-Za-z.-]+)?$`},
+	{"date stamp": `^(19|20)[0-9]{2}-?[01][0-9]-?[0-3][0-9]([-_.T]?[0-9]{2,6})?$`},
+	{"git SHA": `^[0-9a-f]{7,40}$`},
+	{"branch name": `^(master|main|develop|dev|release|feature|bugfix|hotfix)([-_/.].*)?$`},
+}
+
+/*tagCategoryRule is a compiled rule that classifies
+all tag names matching the pattern into the category.*/
+type tagCategoryRule struct {
+	category string
+	pattern  *regexp.Regexp
+}
+
+/*TagNameCategoriesPerProjects is a slice of tagNameCategoriesPerProject structs
+containing the name of a project and the number of its tags
+within each tag name category.*/
+type TagNameCategoriesPerProjects struct {
+	data       []tagNameCategoriesPerProject
+	categories []string
+	title      string
+}
+
+type tagNameCategoriesPerProject struct {
+	projectName        string
+	tagCountByCategory map[string]int
+	tagCount           int
+}
+
+/*GetHeatMapValues for the TagNameCategoriesPerProjects type converts
+the TagNameCategoriesPerProjects slice into a grid of values
+that can be used by a heat map generator.
+Rows are the projects, columns are the tag name categories
+(in the order of the rules, followed by the "other" category)
+and cells contain the number of tags of the project in the category.
+This method is a requirement of the outputgen.HeatMapChartable interface.*/
+func (t *TagNameCategoriesPerProjects) GetHeatMapValues() outputgen.HeatMapValues {
+
+	heatMapValues := outputgen.HeatMapValues{
+		ColumnLabels: t.categories,
+		CellFormat:   "%.0f",
+	}
+
+	for _, tagNameCategoriesPerProject := range t.data {
+		var row []float64
+		for _, category := range t.categories {
+			row = append(row, float64(tagNameCategoriesPerProject.tagCountByCategory[category]))
+		}
+		heatMapValues.RowLabels = append(heatMapValues.RowLabels, tagNameCategoriesPerProject.projectName)
+		heatMapValues.Values = append(heatMapValues.Values, row)
+	}
+
+	return heatMapValues
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (t *TagNameCategoriesPerProjects) SetTitle(title string) {
+	log.Printf("\nTagNameCategoriesPerProjects :: %v .SetTitle %s", t, title)
+	t.title = title
+	log.Printf("\nNewTitle::%s", t.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (t *TagNameCategoriesPerProjects) Title() string {
+	if len(t.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", t)
+	}
+	return t.title
+}
+
+/*GetTagNameCategoriesPerProjectsParameters is the type
+that provides a wrapper for the parameters passed to the
+GetTagNameCategoriesPerProjects stats function.
+TagCategoryRules is an ordered list of mappings from a category
+name to a regular expression, each mapping having exactly one entry.
+A tag name belongs to the category of the first rule it matches.
+Several rules may share a category, which is shown only once.
+If no rules are given, defaultTagCategoryRules apply.
+This type implements the StatsMethodParameters interface type.*/
+type GetTagNameCategoriesPerProjectsParameters struct {
+	Period
+	MaxNumberOfElements int                 `doc:"Number of projects shown"`
+	TagCategoryRules    []map[string]string `doc:"Ordered list of single category: regular expression mappings, the first matching one applies"`
+	Filter
+}
+
+/*IsValid check whether all fields in the GetTagNameCategoriesPerProjectsParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetTagNameCategoriesPerProjectsParameters) IsValid() (bool, string) {
+	if g.MaxNumberOfElements < 1 {
+		return false, "MaxNumberOfElements is less than one"
+	}
+	if _, err := g.compileTagCategoryRules(); err != nil {
+		return false, err.Error()
+	}
+	return g.Filter.IsValid()
+}
+
+/*compileTagCategoryRules compiles the configured (or default)
+tag category rules in the order of the configuration.*/
+func (g *GetTagNameCategoriesPerProjectsParameters) compileTagCategoryRules() ([]tagCategoryRule, error) {
+
+	ruleConfigs := g.TagCategoryRules
+	if len(ruleConfigs) == 0 {
+		ruleConfigs = defaultTagCategoryRules
+	}
+
+	var rules []tagCategoryRule
+	for idx, ruleConfig := range ruleConfigs {
+		//The order of the entries of a map is random,
+		//so more than one would break the order of the rules
+		if len(ruleConfig) != 1 {
+			return nil, fmt.Errorf("TagCategoryRules entry %d has %d mappings instead of exactly one", idx+1, len(ruleConfig))
+		}
+		for category, expression := range ruleConfig {
+			pattern, err := regexp.Compile(expression)
+			if err != nil {
+				return nil, fmt.Errorf("TagCategoryRules entry %s has an invalid regular expression: %s", category, err.Error())
+			}
+			rules = append(rules, tagCategoryRule{
+				category: category,
+				pattern:  pattern,
+			})
+		}
+	}
+
+	return rules, nil
+
+}
+
+/*getTagCategories returns the distinct categories of the given rules
+in the order of their first rule, followed by the "other" category.*/
+func getTagCategories(rules []tagCategoryRule) []string {
+	var categories []string
+	isCategoryListed := map[string]bool{}
+	for _, rule := range rules {
+		if !isCategoryListed[rule.category] {
+			categories = append(categories, rule.category)
+			isCategoryListed[rule.category] = true
+		}
+	}
+	if !isCategoryListed[otherTagCategory] {
+		categories = append(categories, otherTagCategory)
+	}
+	return categories
+}
+
+/*GetTagNameCategoriesPerProjects generates a struct containing,
+for the <MaxNumberOfElements> projects with the most tags pushed since
+<StartDate>, the number of these tags per tag name category
+(e.g. semantic version, latest, git SHA, date stamp, branch name or other)
+according to the given CSV data.
+
+Every tag of any repository of the project that has been pushed
+at least once since <StartDate> is classified by the first
+of the <TagCategoryRules> its name matches, tags not matching any
+rule are classified as "other".
+Check the GetTagNameCategoriesPerProjectsParameters struct for parameters.
+This method is registered as the "GetTagNameCategoriesPerProjects" stats method.*/
+func (registry *Registry) GetTagNameCategoriesPerProjects(params *GetTagNameCategoriesPerProjectsParameters) *TagNameCategoriesPerProjects {
+
+	log.Printf("\nAnalyse :: GetTagNameCategoriesPerProjects :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetTagNameCategoriesPerProjects :: params are invalid :: %s", reason)
+	}
+
+	rules, _ := params.compileTagCategoryRules()
+
+	allTagNameCategoriesPerProjects := TagNameCategoriesPerProjects{
+		categories: getTagCategories(rules),
+	}
+
+	compiledFilter := params.Filter.compile()
+
+	for _, project := range registry.Projects {
+
+		tagNameCategoriesPerProject := tagNameCategoriesPerProject{
+			projectName:        project.Name,
+			tagCountByCategory: map[string]int{},
+		}
+
+		for _, repository := range project.Repositories {
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+			for _, tag := range repository.Tags {
+
+				isPushedInPeriod := false
+				for _, push := range tag.Pushes {
+					if !compiledFilter.includesUser(push.User) {
+						continue
+					}
+					if isWithinPeriod(push.Timestamp, params) {
+						isPushedInPeriod = true
+						break
+					}
+				}
+				if !isPushedInPeriod {
+					log.Printf("\nIgnore tag %s of %s as not pushed within relevant time.", tag.Name, repository.Name)
+					continue
+				}
+
+				category := otherTagCategory
+				for _, rule := range rules {
+					if rule.pattern.MatchString(tag.Name) {
+						category = rule.category
+						break
+					}
+				}
+				tagNameCategoriesPerProject.tagCountByCategory[category]++
+				tagNameCategoriesPerProject.tagCount++
+			}
+		}
+
+		if tagNameCategoriesPerProject.tagCount == 0 {
+			continue
+		}
+
+		allTagNameCategoriesPerProjects.data = append(allTagNameCategoriesPerProjects.data, tagNameCategoriesPerProject)
+	}
+
+	//Sort the elements in the data slice by tagCount descendingly, by name otherwise
+	sort.Slice(allTagNameCategoriesPerProjects.data, func(idxA, idxB int) bool {
+		elementA := allTagNameCategoriesPerProjects.data[idxA]
+		elementB := allTagNameCategoriesPerProjects.data[idxB]
+		if elementA.tagCount == elementB.tagCount {
+			return elementA.projectName < elementB.projectName
+		}
+		return elementA.tagCount > elementB.tagCount
+	})
+
+	//Trim the output slice to the size defined in MaxNumberOfElements
+	//which will determine the number of rows shown in the chart
+	if int(params.MaxNumberOfElements) < len(allTagNameCategoriesPerProjects.data) {
+		allTagNameCategoriesPerProjects.data = allTagNameCategoriesPerProjects.data[:params.MaxNumberOfElements]
+	}
+
+	return &allTagNameCategoriesPerProjects
+
+}
@@ -0,0 +1,166 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultTagCategoryRules(t *testing.T) {
+
+	params := GetTagNameCategoriesPerProjectsParameters{MaxNumberOfElements: 5}
+	rules, err := params.compileTagCategoryRules()
+	if err != nil {
+		t.Fatalf("compileTagCategoryRules() failed with %v", err)
+	}
+
+	testCases := []struct {
+		tagName string
+		want    string
+	}{
+		{"latest", "latest"},
+		{"latest-dev", otherTagCategory},
+		{"1.2.3", "semantic version"},
+		{"v1.2.3", "semantic version"},
+		{"v1.2.3-rc.1", "semantic version"},
+		{"1.2", otherTagCategory},
+		{"2017-10-02", "date stamp"},
+		{"20171002", "date stamp"},
+		{"20171002_1530", "date stamp"},
+		{"3f2a9c1", "git SHA"},
+		{"3f2a9c1e4b5d6f708192a3b4c5d6e7f809112233", "git SHA"},
+		{"3F2A9C1", otherTagCategory},
+		{"master", "branch name"},
+		{"feature/login", "branch name"},
+		{"release-1.2", "branch name"},
+		{"masterpiece", otherTagCategory},
+	}
+
+	for _, testCase := range testCases {
+		got := otherTagCategory
+		for _, rule := range rules {
+			if rule.pattern.MatchString(testCase.tagName) {
+				got = rule.category
+				break
+			}
+		}
+		if got != testCase.want {
+			t.Errorf("category of %s is %s, want %s", testCase.tagName, got, testCase.want)
+		}
+	}
+
+}
+
+func TestGetTagNameCategoriesPerProjectsParametersIsValid(t *testing.T) {
+
+	testCases := []struct {
+		name  string
+		rules []map[string]string
+		want  bool
+	}{
+		{"default rules", nil, true},
+		{"single mappings", []map[string]string{{"release": `^v[0-9]+$`}, {"nightly": `^nightly-`}}, true},
+		{"invalid regular expression", []map[string]string{{"release": `^v[0-9+$`}}, false},
+		{"several mappings in one entry", []map[string]string{{"release": `^v[0-9]+$`, "nightly": `^nightly-`}}, false},
+		{"empty entry", []map[string]string{{}}, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			params := GetTagNameCategoriesPerProjectsParameters{MaxNumberOfElements: 5, TagCategoryRules: testCase.rules}
+			if got, reason := params.IsValid(); got != testCase.want {
+				t.Errorf("IsValid() = %t (%s), want %t", got, reason, testCase.want)
+			}
+		})
+	}
+
+}
+
+func TestGetTagNameCategoriesPerProjects(t *testing.T) {
+
+	registry := newTestRegistry([]testAccess{
+		{pushOperation, "game/server", "v1.0.0", "alice", "2017-10-02 10:00"},
+		{pushOperation, "game/server", "latest", "alice", "2017-10-02 10:00"},
+		{pushOperation, "game/client", "nightly-42", "robot$ci", "2017-10-03 10:00"},
+		{pushOperation, "tools/linter", "v2", "bob", "2017-10-04 10:00"},
+		{pushOperation, "tools/linter", "latest", "bob", "2017-10-04 10:00"},
+		{pushOperation, "meta/cache", "latest", "bob", "2017-10-04 10:00"},
+		{pushOperation, "web/frontend", "v3", "bob", "2017-10-04 10:00"},
+		{pushOperation, "web/frontend", "latest", "bob", "2017-10-04 10:00"},
+		//Outside of the period
+		{pushOperation, "meta/cache", "v1.0.0", "bob", "2017-09-01 10:00"},
+	})
+
+	testCases := []struct {
+		name           string
+		rules          []map[string]string
+		maxNumber      int
+		wantCategories []string
+		wantRows       []string
+		wantValues     [][]float64
+	}{
+		{
+			name:           "default rules",
+			maxNumber:      5,
+			wantCategories: []string{"latest", "semantic version", "date stamp", "git SHA", "branch name", otherTagCategory},
+			wantRows:       []string{"game", "tools", "web", "meta"},
+			wantValues: [][]float64{
+				{1, 1, 0, 0, 0, 1},
+				{1, 0, 0, 0, 0, 1},
+				{1, 0, 0, 0, 0, 1},
+				{1, 0, 0, 0, 0, 0},
+			},
+		},
+		{
+			//Equal tag counts are ordered by project name
+			name:           "rules sharing a category",
+			rules:          []map[string]string{{"release": `^v[0-9]+$`}, {"nightly": `^nightly-`}, {"release": `^v[0-9.]+$`}},
+			maxNumber:      3,
+			wantCategories: []string{"release", "nightly", otherTagCategory},
+			wantRows:       []string{"game", "tools", "web"},
+			wantValues: [][]float64{
+				{1, 1, 1},
+				{1, 0, 1},
+				{1, 0, 1},
+			},
+		},
+		{
+			name:           "rule named like the other category",
+			rules:          []map[string]string{{otherTagCategory: `^latest$`}},
+			maxNumber:      1,
+			wantCategories: []string{otherTagCategory},
+			wantRows:       []string{"game"},
+			wantValues:     [][]float64{{3}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			params := GetTagNameCategoriesPerProjectsParameters{
+				MaxNumberOfElements: testCase.maxNumber,
+				TagCategoryRules:    testCase.rules,
+			}
+			params.SetStartDate(testTime("2017-10-01"))
+			params.SetEndDate(testTime("2017-10-09"))
+
+			heatMapValues := registry.GetTagNameCategoriesPerProjects(&params).GetHeatMapValues()
+			if !reflect.DeepEqual(heatMapValues.ColumnLabels, testCase.wantCategories) {
+				t.Errorf("categories are %v, want %v", heatMapValues.ColumnLabels, testCase.wantCategories)
+			}
+			if !reflect.DeepEqual(heatMapValues.RowLabels, testCase.wantRows) {
+				t.Errorf("projects are %v, want %v", heatMapValues.RowLabels, testCase.wantRows)
+			}
+			if !reflect.DeepEqual(heatMapValues.Values, testCase.wantValues) {
+				t.Errorf("values are %v, want %v", heatMapValues.Values, testCase.wantValues)
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,360 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetMostOverwrittenTags",
+		Description:   "Bar chart of the tags pushed most often.",
+		NewParameters: func() StatsMethodParameters { return &GetMostOverwrittenTagsParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetMostOverwrittenTags(params.(*GetMostOverwrittenTagsParameters))
+		},
+	})
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetRepositoriesByOverwriteRate",
+		Description:   "Bar chart of the repositories whose tags are overwritten most often on average.",
+		NewParameters: func() StatsMethodParameters { return &GetRepositoriesByOverwriteRateParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetRepositoriesByOverwriteRate(params.(*GetRepositoriesByOverwriteRateParameters))
+		},
+	})
+}
+
+/*OverwritesPerTags is a slice of overwritesPerTag structs
+containing the full name of a tag (i.e. repository:tag) and the number
+of times it has been overwritten by a subsequent push.*/
+type OverwritesPerTags struct {
+	data  []overwritesPerTag
+	title string
+}
+
+type overwritesPerTag struct {
+	tagName        string
+	overwriteCount int
+}
+
+/*GetOrderedBarChartValues for the OverwritesPerTags type converts a
+OverwritesPerTags slice into a map that can be used by a chart generator.
+The output slice is guaranteed to be ordered.
+This method is a requirement of the outputgen.BarChartable interface.*/
+func (o OverwritesPerTags) GetOrderedBarChartValues() outputgen.BarChartableValuesOrdered {
+
+	var chartables outputgen.BarChartableValuesOrdered
+
+	for _, overwritesPerTag := range o.data {
+		chartables = append(chartables, outputgen.BarChartableValue{
+			Label: overwritesPerTag.tagName,
+			Value: overwritesPerTag.overwriteCount,
+		})
+	}
+
+	return chartables
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (o *OverwritesPerTags) SetTitle(title string) {
+	log.Printf("\nOverwritesPerTags :: %v .SetTitle %s", o, title)
+	o.title = title
+	log.Printf("\nNewTitle::%s", o.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (o *OverwritesPerTags) Title() string {
+	if len(o.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", o)
+	}
+	return o.title
+}
+
+/*OverwriteRatesPerRepositories is a slice of overwriteRatePerRepository structs
+containing the name of a repository and the percentage of pushes
+to it which overwrote an existing tag.*/
+type OverwriteRatesPerRepositories struct {
+	data  []overwriteRatePerRepository
+	title string
+}
+
+type overwriteRatePerRepository struct {
+	repositoryName string
+	pushCount      int
+	overwriteCount int
+}
+
+func (o overwriteRatePerRepository) overwriteRatePercent() int {
+	if o.pushCount == 0 {
+		return 0
+	}
+	return 100 * o.overwriteCount / o.pushCount
+}
+
+/*GetOrderedBarChartValues for the OverwriteRatesPerRepositories type converts a
+OverwriteRatesPerRepositories slice into a map that can be used by a chart generator.
+The values are percentages.
+The output slice is guaranteed to be ordered.
+This method is a requirement of the outputgen.BarChartable interface.*/
+func (o OverwriteRatesPerRepositories) GetOrderedBarChartValues() outputgen.BarChartableValuesOrdered {
+
+	var chartables outputgen.BarChartableValuesOrdered
+
+	for _, overwriteRatePerRepository := range o.data {
+		chartables = append(chartables, outputgen.BarChartableValue{
+			Label: fmt.Sprintf("%s (%d of %d)",
+				overwriteRatePerRepository.repositoryName,
+				overwriteRatePerRepository.overwriteCount,
+				overwriteRatePerRepository.pushCount),
+			Value: overwriteRatePerRepository.overwriteRatePercent(),
+		})
+	}
+
+	return chartables
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (o *OverwriteRatesPerRepositories) SetTitle(title string) {
+	log.Printf("\nOverwriteRatesPerRepositories :: %v .SetTitle %s", o, title)
+	o.title = title
+	log.Printf("\nNewTitle::%s", o.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (o *OverwriteRatesPerRepositories) Title() string {
+	if len(o.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", o)
+	}
+	return o.title
+}
+
+/*GetMostOverwrittenTagsParameters is the type
+that provides a wrapper for the parameters passed to the
+GetMostOverwrittenTags stats function.
+This type implements the StatsMethodParameters interface type.*/
+type GetMostOverwrittenTagsParameters struct {
+	Period
+	MaxNumberOfElements int `doc:"Number of tags shown"`
+	Filter
+}
+
+/*IsValid check whether all fields in the GetMostOverwrittenTagsParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetMostOverwrittenTagsParameters) IsValid() (bool, string) {
+	if g.MaxNumberOfElements < 1 {
+		return false, "MaxNumberOfElements is less than one"
+	}
+	return g.Filter.IsValid()
+}
+
+/*GetRepositoriesByOverwriteRateParameters is the type
+that provides a wrapper for the parameters passed to the
+GetRepositoriesByOverwriteRate stats function.
+Repositories with less than MinNumberOfPushes pushes since the start date
+are not ranked, since their overwrite rate is hardly meaningful.
+This type implements the StatsMethodParameters interface type.*/
+type GetRepositoriesByOverwriteRateParameters struct {
+	Period
+	MaxNumberOfElements int `doc:"Number of repositories shown"`
+	MinNumberOfPushes   int `doc:"Minimum number of pushes of a repository to be taken into account, if any"`
+	Filter
+}
+
+/*IsValid check whether all fields in the GetRepositoriesByOverwriteRateParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetRepositoriesByOverwriteRateParameters) IsValid() (bool, string) {
+	if g.MaxNumberOfElements < 1 {
+		return false, "MaxNumberOfElements is less than one"
+	}
+	if g.MinNumberOfPushes < 0 {
+		return false, "MinNumberOfPushes is negative"
+	}
+	return g.Filter.IsValid()
+}
+
+/*countTagOverwrites returns the number of pushes to the given tag
+between the given startDate (inclusive) and endDate (exclusive) and how many
+of these pushes overwrote the tag (i.e. every push but the very first push to the tag).
+Only the pushes by users passing the given filter are counted, but the
+very first push to the tag (i.e. its creation) may have been performed by any user,
+as in getFirstPushAndPull.*/
+func countTagOverwrites(tag *Tag, startDate time.Time, endDate time.Time, compiledFilter *compiledFilter) (int, int) {
+
+	var firstPush *Push
+	for _, push := range tag.Pushes {
+		if firstPush == nil || push.Timestamp.Before(firstPush.Timestamp) {
+			firstPush = push
+		}
+	}
+
+	pushCount := 0
+	overwriteCount := 0
+
+	for _, push := range tag.Pushes {
+		if push.Timestamp.Before(startDate) || !push.Timestamp.Before(endDate) {
+			continue
+		}
+		if !compiledFilter.includesUser(push.User) {
+			continue
+		}
+		pushCount++
+		if push != firstPush {
+			overwriteCount++
+		}
+	}
+
+	return pushCount, overwriteCount
+
+}
+
+/*GetMostOverwrittenTags generates a struct containing the
+<MaxNumberOfElements> tags which have been overwritten most often
+(since <StartDate>) according to the given CSV data.
+A tag is overwritten whenever it receives another push
+after its very first push (e.g. "latest").
+
+Each struct within the list of structs in the data field of the returned
+OverwritesPerTags struct contains the full name of the tag
+(i.e. repository:tag) and the number of overwrites since <StartDate>.
+Only the repositories and pushes passing the Filter of the parameters
+are taken into account.
+Check the GetMostOverwrittenTagsParameters struct for parameters.
+This method is registered as the "GetMostOverwrittenTags" stats method.*/
+func (registry *Registry) GetMostOverwrittenTags(params *GetMostOverwrittenTagsParameters) *OverwritesPerTags {
+
+	log.Printf("\nAnalyse :: GetMostOverwrittenTags :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetMostOverwrittenTags :: params are invalid :: %s", reason)
+	}
+
+	var allOverwritesPerTags OverwritesPerTags
+	compiledFilter := params.Filter.compile()
+
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+
+			for _, tag := range repository.Tags {
+				_, overwriteCount := countTagOverwrites(tag, params.StartDate(), params.EndDate(), compiledFilter)
+				if overwriteCount < 1 {
+					continue
+				}
+				allOverwritesPerTags.data = append(allOverwritesPerTags.data, overwritesPerTag{
+					tagName:        fmt.Sprintf("%s:%s", repository.Name, tag.Name),
+					overwriteCount: overwriteCount,
+				})
+			}
+		}
+	}
+
+	//Sort the elements in the data slice by overwriteCount descendingly, by name otherwise
+	sort.Slice(allOverwritesPerTags.data, func(idxA, idxB int) bool {
+		elementA := allOverwritesPerTags.data[idxA]
+		elementB := allOverwritesPerTags.data[idxB]
+		if elementA.overwriteCount == elementB.overwriteCount {
+			return elementA.tagName < elementB.tagName
+		}
+		return elementA.overwriteCount > elementB.overwriteCount
+	})
+
+	//Trim the output slice to the size defined in MaxNumberOfElements
+	//which will determine the number of bars shown in the chart
+	if int(params.MaxNumberOfElements) < len(allOverwritesPerTags.data) {
+		allOverwritesPerTags.data = allOverwritesPerTags.data[:params.MaxNumberOfElements]
+	}
+
+	return &allOverwritesPerTags
+
+}
+
+/*GetRepositoriesByOverwriteRate generates a struct containing the
+<MaxNumberOfElements> repositories with the highest overwrite rate
+(since <StartDate>) according to the given CSV data.
+The overwrite rate is the percentage of pushes to a repository which
+overwrote an already existing tag (see GetMostOverwrittenTags).
+
+Each struct within the list of structs in the data field of the returned
+OverwriteRatesPerRepositories struct contains
+the name of the repository, the number of pushes and the number
+of overwrites since <StartDate>.
+Repositories with less than <MinNumberOfPushes> pushes will not be
+included in the returned structure. Only the repositories and pushes
+passing the Filter of the parameters are taken into account.
+Check the GetRepositoriesByOverwriteRateParameters struct for parameters.
+This method is registered as the "GetRepositoriesByOverwriteRate" stats method.*/
+func (registry *Registry) GetRepositoriesByOverwriteRate(params *GetRepositoriesByOverwriteRateParameters) *OverwriteRatesPerRepositories {
+
+	log.Printf("\nAnalyse :: GetRepositoriesByOverwriteRate :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetRepositoriesByOverwriteRate :: params are invalid :: %s", reason)
+	}
+
+	var allOverwriteRatesPerRepositories OverwriteRatesPerRepositories
+	compiledFilter := params.Filter.compile()
+
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+
+			overwriteRate := overwriteRatePerRepository{
+				repositoryName: repository.Name,
+			}
+			for _, tag := range repository.Tags {
+				pushCount, overwriteCount := countTagOverwrites(tag, params.StartDate(), params.EndDate(), compiledFilter)
+				overwriteRate.pushCount += pushCount
+				overwriteRate.overwriteCount += overwriteCount
+			}
+
+			if overwriteRate.pushCount == 0 || overwriteRate.pushCount < params.MinNumberOfPushes {
+				continue
+			}
+
+			allOverwriteRatesPerRepositories.data = append(allOverwriteRatesPerRepositories.data, overwriteRate)
+		}
+	}
+
+	//Sort the elements in the data slice by overwrite rate descendingly, by name otherwise
+	sort.Slice(allOverwriteRatesPerRepositories.data, func(idxA, idxB int) bool {
+		elementA := allOverwriteRatesPerRepositories.data[idxA]
+		elementB := allOverwriteRatesPerRepositories.data[idxB]
+		if elementA.overwriteRatePercent() == elementB.overwriteRatePercent() {
+			return elementA.repositoryName < elementB.repositoryName
+		}
+		return elementA.overwriteRatePercent() > elementB.overwriteRatePercent()
+	})
+
+	//Trim the output slice to the size defined in MaxNumberOfElements
+	//which will determine the number of bars shown in the chart
+	if int(params.MaxNumberOfElements) < len(allOverwriteRatesPerRepositories.data) {
+		allOverwriteRatesPerRepositories.data = allOverwriteRatesPerRepositories.data[:params.MaxNumberOfElements]
+	}
+
+	return &allOverwriteRatesPerRepositories
+
+}
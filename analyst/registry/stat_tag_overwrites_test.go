@@ -0,0 +1,180 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCountTagOverwrites(t *testing.T) {
+
+	registry := newTestRegistry([]testAccess{
+		{pushOperation, "game/server", "latest", "alice", "2017-09-28 10:00"},
+		{pushOperation, "game/server", "latest", "bob", "2017-10-02 10:00"},
+		{pushOperation, "game/server", "latest", "robot$ci", "2017-10-03 10:00"},
+		{pullOperation, "game/server", "latest", "bob", "2017-10-03 11:00"},
+		{pushOperation, "game/server", "v1", "alice", "2017-10-02 10:00"},
+	})
+	tags := registry.Projects[1].Repositories["game/server"].Tags
+
+	testCases := []struct {
+		name               string
+		tag                string
+		startDate          string
+		filter             Filter
+		wantPushCount      int
+		wantOverwriteCount int
+	}{
+		{
+			name:               "first push before the period",
+			tag:                "latest",
+			startDate:          "2017-10-01",
+			wantPushCount:      2,
+			wantOverwriteCount: 2,
+		},
+		{
+			name:               "first push within the period",
+			tag:                "latest",
+			startDate:          "2017-09-01",
+			wantPushCount:      3,
+			wantOverwriteCount: 2,
+		},
+		{
+			name:               "ignored user",
+			tag:                "latest",
+			startDate:          "2017-10-01",
+			filter:             Filter{UsersToIgnore: []string{"^robot\\$"}},
+			wantPushCount:      1,
+			wantOverwriteCount: 1,
+		},
+		{
+			//The first push is not counted, but still not an overwrite
+			name:               "first push by an ignored user",
+			tag:                "latest",
+			startDate:          "2017-09-01",
+			filter:             Filter{UsersToIgnore: []string{"alice"}},
+			wantPushCount:      2,
+			wantOverwriteCount: 2,
+		},
+		{
+			name:          "single push",
+			tag:           "v1",
+			startDate:     "2017-10-01",
+			wantPushCount: 1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			pushCount, overwriteCount := countTagOverwrites(tags[testCase.tag],
+				testTime(testCase.startDate), testTime("2017-10-08"), testCase.filter.compile())
+			if pushCount != testCase.wantPushCount || overwriteCount != testCase.wantOverwriteCount {
+				t.Errorf("countTagOverwrites() = %d, %d, want %d, %d",
+					pushCount, overwriteCount, testCase.wantPushCount, testCase.wantOverwriteCount)
+			}
+		})
+	}
+
+}
+
+var overwritesTestAccesses = []testAccess{
+	{pushOperation, "game/server", "latest", "alice", "2017-10-02 10:00"},
+	{pushOperation, "game/server", "latest", "alice", "2017-10-03 10:00"},
+	{pushOperation, "game/server", "latest", "alice", "2017-10-04 10:00"},
+	{pushOperation, "game/server", "v1", "alice", "2017-10-02 11:00"},
+	{pushOperation, "game/client", "latest", "bob", "2017-10-02 10:00"},
+	{pushOperation, "game/client", "latest", "bob", "2017-10-05 10:00"},
+	{pushOperation, "tools/cli", "v1", "carol", "2017-10-05 10:00"},
+}
+
+func TestGetMostOverwrittenTags(t *testing.T) {
+
+	registry := newTestRegistry(overwritesTestAccesses)
+
+	testCases := []struct {
+		name   string
+		filter Filter
+		want   []overwritesPerTag
+	}{
+		{
+			name: "all repositories",
+			want: []overwritesPerTag{
+				{"game/server:latest", 2},
+				{"game/client:latest", 1},
+			},
+		},
+		{
+			name:   "no overwrites",
+			filter: Filter{ProjectsToInclude: []string{"tools"}},
+			want:   nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			params := GetMostOverwrittenTagsParameters{
+				MaxNumberOfElements: 5,
+				Filter:              testCase.filter,
+			}
+			params.SetStartDate(testTime("2017-10-01"))
+			params.SetEndDate(testTime("2017-10-08"))
+
+			got := registry.GetMostOverwrittenTags(&params)
+			if !reflect.DeepEqual(got.data, testCase.want) {
+				t.Errorf("GetMostOverwrittenTags() = %v, want %v", got.data, testCase.want)
+			}
+
+		})
+	}
+
+}
+
+func TestGetRepositoriesByOverwriteRate(t *testing.T) {
+
+	registry := newTestRegistry(overwritesTestAccesses)
+
+	testCases := []struct {
+		name              string
+		minNumberOfPushes int
+		want              []overwriteRatePerRepository
+	}{
+		{
+			name: "all repositories",
+			want: []overwriteRatePerRepository{
+				{repositoryName: "game/client", pushCount: 2, overwriteCount: 1},
+				{repositoryName: "game/server", pushCount: 4, overwriteCount: 2},
+				{repositoryName: "tools/cli", pushCount: 1, overwriteCount: 0},
+			},
+		},
+		{
+			name:              "too few pushes",
+			minNumberOfPushes: 10,
+			want:              nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			params := GetRepositoriesByOverwriteRateParameters{
+				MaxNumberOfElements: 5,
+				MinNumberOfPushes:   testCase.minNumberOfPushes,
+			}
+			params.SetStartDate(testTime("2017-10-01"))
+			params.SetEndDate(testTime("2017-10-08"))
+
+			got := registry.GetRepositoriesByOverwriteRate(&params)
+			if !reflect.DeepEqual(got.data, testCase.want) {
+				t.Errorf("GetRepositoriesByOverwriteRate() = %v, want %v", got.data, testCase.want)
+			}
+
+		})
+	}
+
+}
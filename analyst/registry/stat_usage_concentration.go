@@ -0,0 +1,327 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetUsageConcentration",
+		Description:   "Table of the share of the top users, repositories and optionally teams and the Gini coefficient.",
+		NewParameters: func() StatsMethodParameters { return &GetUsageConcentrationParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetUsageConcentration(params.(*GetUsageConcentrationParameters))
+		},
+	})
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetUsageLorenzCurves",
+		Description:   "Lorenz curves of the pushes or pulls per user, per repository and optionally per team.",
+		NewParameters: func() StatsMethodParameters { return &GetUsageConcentrationParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetUsageLorenzCurves(params.(*GetUsageConcentrationParameters))
+		},
+	})
+}
+
+/*concentrationTopNumbers are the numbers of top repositories
+and users whose share of the total accesses will be calculated.*/
+var concentrationTopNumbers = []int{1, 5, 10}
+
+/*UsageConcentration contains the usage concentration
+of repositories and of users.*/
+type UsageConcentration struct {
+	data  []usageConcentration
+	title string
+}
+
+/*usageConcentration describes how concentrated the accesses
+are among the repositories or users (the subjects).*/
+type usageConcentration struct {
+	subjectName string
+	//sorted descendingly
+	accessCounts []int
+}
+
+func (u usageConcentration) totalAccessCount() int {
+	total := 0
+	for _, accessCount := range u.accessCounts {
+		total += accessCount
+	}
+	return total
+}
+
+/*topShare returns the fraction of all accesses
+accounted for by the top n subjects.*/
+func (u usageConcentration) topShare(n int) float64 {
+	total := u.totalAccessCount()
+	if total == 0 {
+		return 0
+	}
+	topTotal := 0
+	for idx := 0; idx < n && idx < len(u.accessCounts); idx++ {
+		topTotal += u.accessCounts[idx]
+	}
+	return float64(topTotal) / float64(total)
+}
+
+/*giniCoefficient returns the Gini coefficient of the accesses
+i.e. 0 if all subjects were accessed equally often and
+(close to) 1 if a single subject accounts for all accesses.*/
+func (u usageConcentration) giniCoefficient() float64 {
+	total := u.totalAccessCount()
+	numberOfSubjects := len(u.accessCounts)
+	if total == 0 || numberOfSubjects == 0 {
+		return 0
+	}
+	//The access counts are sorted descendingly, the formula
+	//requires the rank in ascending order
+	weightedTotal := 0
+	for idx, accessCount := range u.accessCounts {
+		weightedTotal += (numberOfSubjects - idx) * accessCount
+	}
+	return 2*float64(weightedTotal)/(float64(numberOfSubjects)*float64(total)) - float64(numberOfSubjects+1)/float64(numberOfSubjects)
+}
+
+/*lorenzCurve returns the points of the Lorenz curve of the accesses
+i.e. the cumulative percentage of accesses (y) accounted for by the
+cumulative percentage of the least accessed subjects (x).*/
+func (u usageConcentration) lorenzCurve() ([]float64, []float64) {
+	xValues := []float64{0}
+	yValues := []float64{0}
+	total := u.totalAccessCount()
+	numberOfSubjects := len(u.accessCounts)
+	if total == 0 || numberOfSubjects == 0 {
+		return xValues, yValues
+	}
+	cumulativeTotal := 0
+	for idx := numberOfSubjects - 1; idx >= 0; idx-- {
+		cumulativeTotal += u.accessCounts[idx]
+		xValues = append(xValues, float64(numberOfSubjects-idx)/float64(numberOfSubjects)*100)
+		yValues = append(yValues, float64(cumulativeTotal)/float64(total)*100)
+	}
+	return xValues, yValues
+}
+
+/*GetTableValues for the UsageConcentration type converts
+the UsageConcentration into a table of the number of repositories and users,
+the share of the top repositories and users and the Gini coefficient
+that can be used by a table generator.
+This method is a requirement of the outputgen.TableChartable interface.*/
+func (u *UsageConcentration) GetTableValues() outputgen.TableValues {
+
+	tableValues := outputgen.TableValues{
+		ColumnNames: []string{"", "Count", "Accesses"},
+	}
+	for _, topNumber := range concentrationTopNumbers {
+		tableValues.ColumnNames = append(tableValues.ColumnNames, fmt.Sprintf("Top %d share", topNumber))
+	}
+	tableValues.ColumnNames = append(tableValues.ColumnNames, "Gini coefficient")
+
+	for _, concentration := range u.data {
+		row := []string{
+			concentration.subjectName,
+			fmt.Sprintf("%d", len(concentration.accessCounts)),
+			fmt.Sprintf("%d", concentration.totalAccessCount()),
+		}
+		for _, topNumber := range concentrationTopNumbers {
+			row = append(row, fmt.Sprintf("%.1f%%", concentration.topShare(topNumber)*100))
+		}
+		row = append(row, fmt.Sprintf("%.2f", concentration.giniCoefficient()))
+		tableValues.Rows = append(tableValues.Rows, row)
+	}
+
+	return tableValues
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (u *UsageConcentration) SetTitle(title string) {
+	log.Printf("\nUsageConcentration :: %v .SetTitle %s", u, title)
+	u.title = title
+	log.Printf("\nNewTitle::%s", u.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (u *UsageConcentration) Title() string {
+	if len(u.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", u)
+	}
+	return u.title
+}
+
+/*UsageLorenzCurves contains the usage concentration
+of repositories and of users which will be shown as Lorenz curves.*/
+type UsageLorenzCurves struct {
+	data  []usageConcentration
+	title string
+}
+
+/*GetCurveChartValues for the UsageLorenzCurves type converts
+the UsageLorenzCurves into one Lorenz curve for the repositories
+and one for the users as well as the line of equality,
+which can be used by a curve chart generator.
+This method is a requirement of the outputgen.CurveChartable interface.*/
+func (u *UsageLorenzCurves) GetCurveChartValues() outputgen.CurveChartableValues {
+
+	curveChartValues := outputgen.CurveChartableValues{
+		XAxisName:  "Share of repositories / users (least accessed first)",
+		YAxisName:  "Share of accesses",
+		XRange:     [2]float64{0, 100},
+		YRange:     [2]float64{0, 100},
+		AxisFormat: "%.0f%%",
+	}
+
+	for _, concentration := range u.data {
+		xValues, yValues := concentration.lorenzCurve()
+		curveChartValues.Curves = append(curveChartValues.Curves, outputgen.CurveChartableCurve{
+			Name:    fmt.Sprintf("%s (Gini %.2f)", concentration.subjectName, concentration.giniCoefficient()),
+			XValues: xValues,
+			YValues: yValues,
+		})
+	}
+
+	curveChartValues.Curves = append(curveChartValues.Curves, outputgen.CurveChartableCurve{
+		Name:    "Equal usage",
+		XValues: []float64{0, 100},
+		YValues: []float64{0, 100},
+	})
+
+	return curveChartValues
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (u *UsageLorenzCurves) SetTitle(title string) {
+	log.Printf("\nUsageLorenzCurves :: %v .SetTitle %s", u, title)
+	u.title = title
+	log.Printf("\nNewTitle::%s", u.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (u *UsageLorenzCurves) Title() string {
+	if len(u.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", u)
+	}
+	return u.title
+}
+
+/*GetUsageConcentrationParameters is the type
+that provides a wrapper for the parameters passed to the
+GetUsageConcentration and GetUsageLorenzCurves stats functions.
+Operation must be one of "push", "pull" or "any" and determines
+which accesses are taken into account.
+UserClass restricts the accesses of the repositories and the users alike.
+If SplitByUserClass is set the concentration among the users will be
+calculated per user class in addition (see registry.UserClassification).
+If IncludeTeams is set the concentration among the teams will be
+calculated in addition (see registry.TeamMapping).
+This type implements the StatsMethodParameters interface type.*/
+type GetUsageConcentrationParameters struct {
+	Period
+	Operation string `doc:"Operation to count, one of push, pull or any" enum:"push,pull,any"`
+	UserClassSelection
+	SplitByUserClass bool `doc:"Show human and automation users separately"`
+	IncludeTeams     bool `doc:"Show the concentration among the teams as well"`
+	Filter
+}
+
+/*IsValid check whether all fields in the GetUsageConcentrationParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetUsageConcentrationParameters) IsValid() (bool, string) {
+	if !isValidOperation(g.Operation) {
+		return false, fmt.Sprintf("Operation \"%s\" is not one of push, pull or any", g.Operation)
+	}
+	if isValid, reason := g.UserClassSelection.IsValid(); !isValid {
+		return false, reason
+	}
+	return g.Filter.IsValid()
+}
+
+/*newUsageConcentration converts the given access counts
+mapped to names into a usageConcentration.*/
+func newUsageConcentration(subjectName string, accessesPerSubject map[string]int) usageConcentration {
+	concentration := usageConcentration{subjectName: subjectName}
+	for _, accessCount := range accessesPerSubject {
+		concentration.accessCounts = append(concentration.accessCounts, accessCount)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(concentration.accessCounts)))
+	return concentration
+}
+
+/*GetUsageConcentration generates a struct describing how concentrated
+the usage of the registry (since <StartDate>) is according to the
+given CSV data, once among the repositories and once among the users
+(and, if <IncludeTeams> is set, once among the teams).
+
+The accesses (pushes, pulls or both, depending on <Operation>) are
+aggregated the same way as GetMostPushedToRepositories and
+GetMostPushingUsers aggregate pushes. For both, the share of all accesses
+accounted for by the top 1, 5 and 10 repositories or users
+and the Gini coefficient is calculated.
+Repositories which have not been accessed at all are taken into account,
+users only if they accessed the registry at least once.
+Check the GetUsageConcentrationParameters struct for parameters.
+This method is registered as the "GetUsageConcentration" stats method.*/
+func (registry *Registry) GetUsageConcentration(params *GetUsageConcentrationParameters) *UsageConcentration {
+
+	log.Printf("\nAnalyse :: GetUsageConcentration :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetUsageConcentration :: params are invalid :: %s", reason)
+	}
+
+	concentration := UsageConcentration{
+		data: []usageConcentration{
+			newUsageConcentration("Repositories", registry.getAccessesPerRepository(
+				params.Operation, params.Filter, params.UserClass, params.StartDate(), params.EndDate())),
+			newUsageConcentration("Users", registry.getAccessesPerUser(
+				params.Operation, params.Filter, params.UserClass, params.StartDate(), params.EndDate())),
+		},
+	}
+
+	if params.IncludeTeams {
+		concentration.data = append(concentration.data, newUsageConcentration("Teams", registry.getAccessesPerTeam(
+			params.Operation, params.Filter, params.UserClass, params.StartDate(), params.EndDate())))
+	}
+
+	if params.SplitByUserClass {
+		for _, userClass := range userClasses {
+			if params.UserClass != "" && params.UserClass != anyUserClass && params.UserClass != userClass {
+				continue
+			}
+			concentration.data = append(concentration.data, newUsageConcentration(
+				fmt.Sprintf("Users (%s)", userClass), registry.getAccessesPerUser(
+					params.Operation, params.Filter, userClass, params.StartDate(), params.EndDate())))
+		}
+	}
+
+	return &concentration
+
+}
+
+/*GetUsageLorenzCurves generates a struct containing the same
+usage concentration as GetUsageConcentration does, which will be
+shown as the Lorenz curves of the repositories and users.
+Check the GetUsageConcentrationParameters struct for parameters.
+This method is registered as the "GetUsageLorenzCurves" stats method.*/
+func (registry *Registry) GetUsageLorenzCurves(params *GetUsageConcentrationParameters) *UsageLorenzCurves {
+	return &UsageLorenzCurves{
+		data: registry.GetUsageConcentration(params).data,
+	}
+}
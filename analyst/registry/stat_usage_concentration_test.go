@@ -0,0 +1,139 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestUsageConcentration(t *testing.T) {
+
+	testCases := []struct {
+		name              string
+		accessCounts      []int
+		wantTopShare      float64
+		wantGini          float64
+		wantLorenzXValues []float64
+		wantLorenzYValues []float64
+	}{
+		{
+			name:              "no subjects",
+			wantLorenzXValues: []float64{0},
+			wantLorenzYValues: []float64{0},
+		},
+		{
+			name:              "no accesses",
+			accessCounts:      []int{0, 0},
+			wantLorenzXValues: []float64{0},
+			wantLorenzYValues: []float64{0},
+		},
+		{
+			name:              "equal accesses",
+			accessCounts:      []int{5, 5, 5, 5},
+			wantTopShare:      0.25,
+			wantGini:          0,
+			wantLorenzXValues: []float64{0, 25, 50, 75, 100},
+			wantLorenzYValues: []float64{0, 25, 50, 75, 100},
+		},
+		{
+			name:              "single subject accessed",
+			accessCounts:      []int{10, 0, 0, 0},
+			wantTopShare:      1,
+			wantGini:          0.75,
+			wantLorenzXValues: []float64{0, 25, 50, 75, 100},
+			wantLorenzYValues: []float64{0, 0, 0, 0, 100},
+		},
+		{
+			name:              "unequal accesses",
+			accessCounts:      []int{3, 1},
+			wantTopShare:      0.75,
+			wantGini:          0.25,
+			wantLorenzXValues: []float64{0, 50, 100},
+			wantLorenzYValues: []float64{0, 25, 100},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			concentration := usageConcentration{accessCounts: testCase.accessCounts}
+
+			if got := concentration.topShare(1); math.Abs(got-testCase.wantTopShare) > 1e-9 {
+				t.Errorf("topShare(1) = %v, want %v", got, testCase.wantTopShare)
+			}
+			if got := concentration.giniCoefficient(); math.Abs(got-testCase.wantGini) > 1e-9 {
+				t.Errorf("giniCoefficient() = %v, want %v", got, testCase.wantGini)
+			}
+			xValues, yValues := concentration.lorenzCurve()
+			if !reflect.DeepEqual(xValues, testCase.wantLorenzXValues) || !reflect.DeepEqual(yValues, testCase.wantLorenzYValues) {
+				t.Errorf("lorenzCurve() = %v, %v, want %v, %v", xValues, yValues, testCase.wantLorenzXValues, testCase.wantLorenzYValues)
+			}
+
+		})
+	}
+
+}
+
+func TestNewUsageConcentration(t *testing.T) {
+
+	concentration := newUsageConcentration("Repositories", map[string]int{"a": 1, "b": 7, "c": 3})
+
+	if want := []int{7, 3, 1}; !reflect.DeepEqual(concentration.accessCounts, want) {
+		t.Errorf("access counts are %v, want %v", concentration.accessCounts, want)
+	}
+	if concentration.totalAccessCount() != 11 {
+		t.Errorf("totalAccessCount() = %d, want 11", concentration.totalAccessCount())
+	}
+
+}
+
+func TestGetUsageConcentration(t *testing.T) {
+
+	registry := newTestRegistry([]testAccess{
+		{pushOperation, "game/server", "v1", "alice", "2017-10-02 10:00"},
+		{pullOperation, "game/server", "v1", "robot$ci", "2017-10-02 11:00"},
+		{pullOperation, "game/server", "v1", "robot$ci", "2017-10-02 12:00"},
+		{pullOperation, "game/client", "v1", "bob", "2017-10-03 10:00"},
+	})
+	registry.UserClassification = UserClassification{
+		AutomationNamePatterns: []string{"robot$*"},
+	}
+	registry.TeamMapping = TeamMapping{Teams: []Team{
+		{Name: "backend", RepositoryPatterns: []string{"game/server"}},
+	}}
+
+	testCases := []struct {
+		userClass string
+		want      map[string][]int
+	}{
+		{anyUserClass, map[string][]int{"Repositories": {3, 1}, "Users": {2, 1, 1}, "Teams": {3, 1}}},
+		{humanUserClass, map[string][]int{"Repositories": {1, 1}, "Users": {1, 1}, "Teams": {1, 1}}},
+		{automationUserClass, map[string][]int{"Repositories": {2, 0}, "Users": {2}, "Teams": {2}}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.userClass, func(t *testing.T) {
+
+			params := GetUsageConcentrationParameters{Operation: anyOperation, IncludeTeams: true}
+			params.UserClass = testCase.userClass
+			params.SetStartDate(testTime("2017-10-01"))
+			params.SetEndDate(testTime("2017-10-09"))
+
+			got := map[string][]int{}
+			for _, concentration := range registry.GetUsageConcentration(&params).data {
+				got[concentration.subjectName] = concentration.accessCounts
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("access counts are %v, want %v", got, testCase.want)
+			}
+
+		})
+	}
+
+}
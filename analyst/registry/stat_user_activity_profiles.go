@@ -0,0 +1,317 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetUserActivityProfiles",
+		Description:   "One report section per user with their key figures, repositories and hours of activity.",
+		NewParameters: func() StatsMethodParameters { return &GetUserActivityProfilesParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetUserActivityProfiles(params.(*GetUserActivityProfilesParameters))
+		},
+	})
+}
+
+/*UserActivityProfiles is a slice of userActivityProfile structs
+each containing the activity of one user.
+Every profile will be presented in a section of its own.*/
+type UserActivityProfiles struct {
+	data  []userActivityProfile
+	title string
+}
+
+type userActivityProfile struct {
+	user          *User
+	userClass     string
+	firstActivity time.Time
+	lastActivity  time.Time
+	pushCount     int
+	pullCount     int
+	//the number of distinct repositories pushed to
+	repositoryCount int
+	//the repositories pushed to (sorted by pushCount descendingly)
+	pushesPerRepositories PushesPerRepositories
+	//the pushes per hour of the day (sorted by hourOfDay ascendingly)
+	pushesPerDaytimes PushesPerDaytimes
+}
+
+/*UserActivitySummary contains the key figures
+of the activity of a single user.*/
+type UserActivitySummary struct {
+	profile userActivityProfile
+	title   string
+}
+
+/*GetTableValues for the UserActivitySummary type converts the
+UserActivitySummary into a table of key figures
+that can be used by a table generator.
+This method is a requirement of the outputgen.TableChartable interface.*/
+func (u *UserActivitySummary) GetTableValues() outputgen.TableValues {
+
+	timeFormat := "2006-01-02 15:04"
+
+	return outputgen.TableValues{
+		ColumnNames: []string{"Key figure", "Value"},
+		Rows: [][]string{
+			{"User", fmt.Sprintf("%s (ID %d)", u.profile.user.Name, u.profile.user.ID)},
+			{"User class", u.profile.userClass},
+			{"First activity", u.profile.firstActivity.Format(timeFormat)},
+			{"Last activity", u.profile.lastActivity.Format(timeFormat)},
+			{"Pushes", fmt.Sprintf("%d", u.profile.pushCount)},
+			{"Pulls", fmt.Sprintf("%d", u.profile.pullCount)},
+			{"Repositories pushed to", fmt.Sprintf("%d", u.profile.repositoryCount)},
+		},
+	}
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (u *UserActivitySummary) SetTitle(title string) {
+	log.Printf("\nUserActivitySummary :: %v .SetTitle %s", u, title)
+	u.title = title
+	log.Printf("\nNewTitle::%s", u.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (u *UserActivitySummary) Title() string {
+	if len(u.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", u)
+	}
+	return u.title
+}
+
+/*GetSections for the UserActivityProfiles type converts the
+UserActivityProfiles slice into one section per user, each consisting
+of a summary table, a bar chart of the repositories pushed to and
+a bar chart of the pushes per hour of the day.
+The bar charts are left out for users without pushes.
+The titles of all charts start with the title of the UserActivityProfiles
+so that the charts of different stats do not share a title.
+This method is a requirement of the outputgen.SectionChartable interface.*/
+func (u *UserActivityProfiles) GetSections() []outputgen.ChartableSection {
+
+	var sections []outputgen.ChartableSection
+
+	for idx := range u.data {
+		profile := u.data[idx]
+		sectionTitle := fmt.Sprintf("%s: %s", u.Title(), profile.user.Name)
+
+		summary := &UserActivitySummary{profile: profile}
+		summary.SetTitle(fmt.Sprintf("%s: summary", sectionTitle))
+		charts := []outputgen.Chartable{summary}
+		if profile.pushCount > 0 {
+			profile.pushesPerRepositories.SetTitle(fmt.Sprintf("%s: pushes per repository", sectionTitle))
+			profile.pushesPerDaytimes.SetTitle(fmt.Sprintf("%s: pushes per hour of the day", sectionTitle))
+			charts = append(charts, &profile.pushesPerRepositories, &profile.pushesPerDaytimes)
+		}
+
+		sections = append(sections, outputgen.ChartableSection{
+			Title: sectionTitle,
+			Description: fmt.Sprintf("Active from %s to %s with %d pushes and %d pulls.",
+				profile.firstActivity.Format("2006-01-02"), profile.lastActivity.Format("2006-01-02"),
+				profile.pushCount, profile.pullCount),
+			Charts: charts,
+		})
+	}
+
+	return sections
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (u *UserActivityProfiles) SetTitle(title string) {
+	log.Printf("\nUserActivityProfiles :: %v .SetTitle %s", u, title)
+	u.title = title
+	log.Printf("\nNewTitle::%s", u.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (u *UserActivityProfiles) Title() string {
+	if len(u.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", u)
+	}
+	return u.title
+}
+
+/*GetUserActivityProfilesParameters is the type
+that provides a wrapper for the parameters passed to the
+GetUserActivityProfiles stats function.
+Either UserNames lists the users to profile or, if empty,
+the <NumberOfUsers> users with the most pushes will be profiled.
+MaxNumberOfElements limits the number of repositories shown per user.
+This type implements the StatsMethodParameters interface type.*/
+type GetUserActivityProfilesParameters struct {
+	Period
+	UserNames           []string `doc:"Names of the users to profile (instead of the most pushing ones)"`
+	NumberOfUsers       int      `doc:"Number of most pushing users to profile if no UserNames are given"`
+	MaxNumberOfElements int      `doc:"Number of repositories shown per user"`
+	UserClassSelection
+	Filter
+}
+
+/*IsValid check whether all fields in the GetUserActivityProfilesParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetUserActivityProfilesParameters) IsValid() (bool, string) {
+	if len(g.UserNames) == 0 && g.NumberOfUsers < 1 {
+		return false, "neither UserNames are given nor is NumberOfUsers at least one"
+	}
+	if g.MaxNumberOfElements < 1 {
+		return false, "MaxNumberOfElements is less than one"
+	}
+	if isValid, reason := g.UserClassSelection.IsValid(); !isValid {
+		return false, reason
+	}
+	return g.Filter.IsValid()
+}
+
+/*GetUserActivityProfiles generates a struct containing the activity
+profile (since <StartDate>) of every user listed in <UserNames> or,
+if none are listed, of the <NumberOfUsers> users with the most pushes
+according to the given CSV data.
+
+Each profile contains the first and last push or pull of the user,
+the number of pushes and pulls, the <MaxNumberOfElements> repositories
+the user pushed to most and the number of pushes per hour of the day.
+Users who have not been active since <StartDate> are not profiled.
+Only the repositories, users and accesses passing the Filter
+of the parameters are taken into account.
+Check the GetUserActivityProfilesParameters struct for parameters.
+This method is registered as the "GetUserActivityProfiles" stats method.*/
+func (registry *Registry) GetUserActivityProfiles(params *GetUserActivityProfilesParameters) *UserActivityProfiles {
+
+	log.Printf("\nAnalyse :: GetUserActivityProfiles :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetUserActivityProfiles :: params are invalid :: %s", reason)
+	}
+
+	userNames := params.UserNames
+	if len(userNames) == 0 {
+		pushesPerUser := registry.getAccessesPerUser(pushOperation, params.Filter, params.UserClass, params.StartDate(), params.EndDate())
+		for userName := range pushesPerUser {
+			userNames = append(userNames, userName)
+		}
+		sort.Slice(userNames, func(idxA, idxB int) bool {
+			return pushesPerUser[userNames[idxA]] > pushesPerUser[userNames[idxB]]
+		})
+		if params.NumberOfUsers < len(userNames) {
+			userNames = userNames[:params.NumberOfUsers]
+		}
+	}
+
+	profilePerUser := map[string]*userActivityProfile{}
+	pushesPerRepositoryPerUser := map[string]map[string]int{}
+	pushesPerHourPerUser := map[string]map[int]int{}
+	for _, userName := range userNames {
+		pushesPerRepositoryPerUser[userName] = map[string]int{}
+		pushesPerHourPerUser[userName] = map[int]int{}
+	}
+
+	compiledFilter := params.Filter.compile()
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+			for _, tag := range repository.Tags {
+				for _, operation := range []string{pushOperation, pullOperation} {
+					for _, accessLog := range getLogsByOperation(tag, operation) {
+						if accessLog.User == nil || !isWithinPeriod(accessLog.Timestamp, params) {
+							continue
+						}
+						if !compiledFilter.includesUser(accessLog.User) {
+							continue
+						}
+						userName := accessLog.User.Name
+						if _, isProfiled := pushesPerRepositoryPerUser[userName]; !isProfiled {
+							continue
+						}
+
+						profile, ok := profilePerUser[userName]
+						if !ok {
+							profile = &userActivityProfile{
+								user:          accessLog.User,
+								firstActivity: accessLog.Timestamp,
+								lastActivity:  accessLog.Timestamp,
+							}
+							profilePerUser[userName] = profile
+						}
+						if accessLog.Timestamp.Before(profile.firstActivity) {
+							profile.firstActivity = accessLog.Timestamp
+						}
+						if accessLog.Timestamp.After(profile.lastActivity) {
+							profile.lastActivity = accessLog.Timestamp
+						}
+
+						if operation == pullOperation {
+							profile.pullCount++
+							continue
+						}
+						profile.pushCount++
+						pushesPerRepositoryPerUser[userName][repository.Name]++
+						pushesPerHourPerUser[userName][accessLog.Timestamp.Hour()]++
+					}
+				}
+			}
+		}
+	}
+
+	classPerUser := registry.getClassPerUser()
+	var userActivityProfiles UserActivityProfiles
+	for _, userName := range userNames {
+
+		profile, ok := profilePerUser[userName]
+		if !ok {
+			log.Printf("\nIgnore user %s as not active within relevant time.", userName)
+			continue
+		}
+		profile.userClass = classPerUser[userName]
+		profile.repositoryCount = len(pushesPerRepositoryPerUser[userName])
+
+		for repositoryName, pushCount := range pushesPerRepositoryPerUser[userName] {
+			profile.pushesPerRepositories.data = append(profile.pushesPerRepositories.data, pushesPerRepository{
+				repositoryName: repositoryName,
+				pushCount:      pushCount,
+			})
+		}
+		sort.Slice(profile.pushesPerRepositories.data, func(idxA, idxB int) bool {
+			return profile.pushesPerRepositories.data[idxA].pushCount > profile.pushesPerRepositories.data[idxB].pushCount
+		})
+		if params.MaxNumberOfElements < len(profile.pushesPerRepositories.data) {
+			profile.pushesPerRepositories.data = profile.pushesPerRepositories.data[:params.MaxNumberOfElements]
+		}
+
+		//Show all hours of the day, even those without pushes
+		for hourOfDay := 0; hourOfDay < 24; hourOfDay++ {
+			profile.pushesPerDaytimes.data = append(profile.pushesPerDaytimes.data, pushesPerDaytime{
+				hourOfDay: hourOfDay,
+				pushCount: pushesPerHourPerUser[userName][hourOfDay],
+			})
+		}
+
+		userActivityProfiles.data = append(userActivityProfiles.data, *profile)
+	}
+
+	return &userActivityProfiles
+
+}
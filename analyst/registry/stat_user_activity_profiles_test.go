@@ -0,0 +1,54 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUserActivityProfilesGetSections(t *testing.T) {
+
+	registry := newTestRegistry([]testAccess{
+		{pushOperation, "game/server", "v1", "alice", "2017-10-02 10:00"},
+		{pushOperation, "game/client", "v1", "alice", "2017-10-02 11:00"},
+		{pullOperation, "game/server", "v1", "dave", "2017-10-03 09:00"},
+	})
+
+	params := GetUserActivityProfilesParameters{
+		UserNames:           []string{"alice", "dave", "nobody"},
+		MaxNumberOfElements: 5,
+	}
+	params.SetStartDate(testTime("2017-10-01"))
+	params.SetEndDate(testTime("2017-10-31"))
+	profiles := registry.GetUserActivityProfiles(&params)
+	profiles.SetTitle("Profiles")
+
+	var sectionTitles []string
+	var chartTitles [][]string
+	for _, section := range profiles.GetSections() {
+		sectionTitles = append(sectionTitles, section.Title)
+		var titles []string
+		for _, chart := range section.Charts {
+			titles = append(titles, chart.Title())
+		}
+		chartTitles = append(chartTitles, titles)
+	}
+
+	wantSectionTitles := []string{"Profiles: alice", "Profiles: dave"}
+	if !reflect.DeepEqual(sectionTitles, wantSectionTitles) {
+		t.Errorf("section titles are %v, want %v", sectionTitles, wantSectionTitles)
+	}
+	wantChartTitles := [][]string{
+		{"Profiles: alice: summary", "Profiles: alice: pushes per repository", "Profiles: alice: pushes per hour of the day"},
+		{"Profiles: dave: summary"},
+	}
+	if !reflect.DeepEqual(chartTitles, wantChartTitles) {
+		t.Errorf("chart titles are %v, want %v", chartTitles, wantChartTitles)
+	}
+
+}
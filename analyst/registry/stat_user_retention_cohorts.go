@@ -0,0 +1,219 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+func init() {
+	RegisterStatsMethod(StatsMethod{
+		Name:          "GetUserRetentionCohorts",
+		Description:   "Heat map of the share of users of a monthly cohort still active in later months.",
+		NewParameters: func() StatsMethodParameters { return &GetUserRetentionCohortsParameters{} },
+		Compute: func(registry *Registry, params StatsMethodParameters) outputgen.Chartable {
+			return registry.GetUserRetentionCohorts(params.(*GetUserRetentionCohortsParameters))
+		},
+	})
+}
+
+/*UserRetentionCohorts is a slice of userRetentionCohort structs
+containing, for every month in which users were active for the
+first time, the fraction of these users still active in the following months.*/
+type UserRetentionCohorts struct {
+	data           []userRetentionCohort
+	numberOfMonths int
+	title          string
+}
+
+type userRetentionCohort struct {
+	firstActiveMonth time.Time
+	userCount        int
+	//retention[n] is the fraction of users active in month M+n,
+	//NaN if month M+n is yet to come
+	retention []float64
+}
+
+/*GetHeatMapValues for the UserRetentionCohorts type converts
+the UserRetentionCohorts slice into a grid of values
+that can be used by a heat map generator.
+Rows are the cohorts (oldest first), columns are the months
+after the first activity of a cohort and cells contain the
+percentage of users of the cohort who were active in that month.
+This method is a requirement of the outputgen.HeatMapChartable interface.*/
+func (u *UserRetentionCohorts) GetHeatMapValues() outputgen.HeatMapValues {
+
+	var heatMapValues outputgen.HeatMapValues
+
+	for monthOffset := 0; monthOffset <= u.numberOfMonths; monthOffset++ {
+		heatMapValues.ColumnLabels = append(heatMapValues.ColumnLabels, fmt.Sprintf("M+%d", monthOffset))
+	}
+
+	for _, cohort := range u.data {
+		heatMapValues.RowLabels = append(heatMapValues.RowLabels, fmt.Sprintf("%s (%d)", cohort.firstActiveMonth.Format("2006-01"), cohort.userCount))
+		var row []float64
+		for _, retention := range cohort.retention {
+			row = append(row, retention*100)
+		}
+		heatMapValues.Values = append(heatMapValues.Values, row)
+	}
+
+	heatMapValues.CellFormat = "%.0f%%"
+
+	return heatMapValues
+
+}
+
+/*SetTitle sets the human-readable title of the statistics type.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (u *UserRetentionCohorts) SetTitle(title string) {
+	log.Printf("\nUserRetentionCohorts :: %v .SetTitle %s", u, title)
+	u.title = title
+	log.Printf("\nNewTitle::%s", u.Title())
+}
+
+/*Title returns the human-readable title of the statistics type
+and will raise an error if the title is emtystring.
+This method is a requirement of the outputgen.Chartable interface.*/
+func (u *UserRetentionCohorts) Title() string {
+	if len(u.title) < 1 {
+		log.Fatalf("\nTitle of chartable %v is empty. Abort.", u)
+	}
+	return u.title
+}
+
+/*GetUserRetentionCohortsParameters is the type
+that provides a wrapper for the parameters passed to the
+GetUserRetentionCohorts stats function.
+NumberOfMonths is the number of months after the first activity
+of a cohort for which the retention will be shown.
+This type implements the StatsMethodParameters interface type.*/
+type GetUserRetentionCohortsParameters struct {
+	Period
+	NumberOfMonths int `doc:"Number of months after the first activity shown per cohort"`
+	UserClassSelection
+	Filter
+}
+
+/*IsValid check whether all fields in the GetUserRetentionCohortsParameters
+have a valid value. If not valid, false and a reason string is returned.
+This method is required by the StatsMethodParameters interface.*/
+func (g *GetUserRetentionCohortsParameters) IsValid() (bool, string) {
+	if g.NumberOfMonths < 1 {
+		return false, "NumberOfMonths is less than one"
+	}
+	if isValid, reason := g.UserClassSelection.IsValid(); !isValid {
+		return false, reason
+	}
+	return g.Filter.IsValid()
+}
+
+/*GetUserRetentionCohorts generates a struct containing one cohort
+for every month (since <StartDate>) in which users performed their
+very first push or pull according to the given CSV data.
+
+For every cohort of users whose first activity was in month M,
+the struct contains the fraction of these users who were active
+(i.e. pushed or pulled) in the months M, M+1, ... M+<NumberOfMonths>.
+Months which are yet to come are marked as unknown (NaN).
+
+Check the GetUserRetentionCohortsParameters struct for parameters.
+This method is registered as the "GetUserRetentionCohorts" stats method.*/
+func (registry *Registry) GetUserRetentionCohorts(params *GetUserRetentionCohortsParameters) *UserRetentionCohorts {
+
+	log.Printf("\nAnalyse :: GetUserRetentionCohorts :: %v", params)
+	if isValid, reason := params.IsValid(); !isValid {
+		log.Fatalf("\nGetUserRetentionCohorts :: params are invalid :: %s", reason)
+	}
+
+	//The first activity has to be determined over the whole
+	//history, otherwise every user would appear to be new
+	//in the month of <StartDate>
+	firstActiveMonthPerUser := map[string]time.Time{}
+	activeMonthsPerUser := map[string]map[time.Time]bool{}
+	classPerUser := registry.getClassPerUser()
+	compiledFilter := params.Filter.compile()
+
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+			if !compiledFilter.includesRepository(project.Name, repository.Name) {
+				continue
+			}
+			for _, tag := range repository.Tags {
+				for _, accessLog := range getLogsByOperation(tag, anyOperation) {
+					if !compiledFilter.includesUser(accessLog.User) {
+						continue
+					}
+					if accessLog.User == nil || !isOfUserClass(classPerUser, accessLog.User.Name, params.UserClass) {
+						continue
+					}
+					if !accessLog.Timestamp.Before(params.EndDate()) {
+						continue
+					}
+					userName := accessLog.User.Name
+					activeMonth := truncateToInterval(accessLog.Timestamp, monthInterval)
+					if firstActiveMonth, ok := firstActiveMonthPerUser[userName]; !ok || activeMonth.Before(firstActiveMonth) {
+						firstActiveMonthPerUser[userName] = activeMonth
+					}
+					if _, ok := activeMonthsPerUser[userName]; !ok {
+						activeMonthsPerUser[userName] = map[time.Time]bool{}
+					}
+					activeMonthsPerUser[userName][activeMonth] = true
+				}
+			}
+		}
+	}
+
+	usersPerCohort := map[time.Time][]string{}
+	for userName, firstActiveMonth := range firstActiveMonthPerUser {
+		if firstActiveMonth.Before(truncateToInterval(params.StartDate(), monthInterval)) {
+			log.Printf("\nIgnore user %s as first active before relevant time.", userName)
+			continue
+		}
+		usersPerCohort[firstActiveMonth] = append(usersPerCohort[firstActiveMonth], userName)
+	}
+
+	lastMonth := getLastIntervalStart(params.EndDate(), monthInterval)
+	userRetentionCohorts := UserRetentionCohorts{
+		numberOfMonths: params.NumberOfMonths,
+	}
+	for firstActiveMonth, userNames := range usersPerCohort {
+		cohort := userRetentionCohort{
+			firstActiveMonth: firstActiveMonth,
+			userCount:        len(userNames),
+		}
+		for monthOffset := 0; monthOffset <= params.NumberOfMonths; monthOffset++ {
+			month := firstActiveMonth.AddDate(0, monthOffset, 0)
+			if month.After(lastMonth) {
+				cohort.retention = append(cohort.retention, math.NaN())
+				continue
+			}
+			activeUserCount := 0
+			for _, userName := range userNames {
+				if activeMonthsPerUser[userName][month] {
+					activeUserCount++
+				}
+			}
+			cohort.retention = append(cohort.retention, float64(activeUserCount)/float64(len(userNames)))
+		}
+		userRetentionCohorts.data = append(userRetentionCohorts.data, cohort)
+	}
+
+	//Sort the cohorts by month ascendingly
+	sort.Slice(userRetentionCohorts.data, func(idxA, idxB int) bool {
+		return userRetentionCohorts.data[idxA].firstActiveMonth.Before(userRetentionCohorts.data[idxB].firstActiveMonth)
+	})
+
+	return &userRetentionCohorts
+
+}
@@ -0,0 +1,75 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGetUserRetentionCohorts(t *testing.T) {
+
+	registry := newTestRegistry([]testAccess{
+		//Active before the period, so not part of any cohort
+		{pushOperation, "game/server", "v1", "carol", "2017-06-20 10:00"},
+		{pushOperation, "game/server", "v1", "carol", "2017-07-20 10:00"},
+		//July cohort
+		{pushOperation, "game/server", "v1", "alice", "2017-07-03 10:00"},
+		{pullOperation, "game/server", "v1", "bob", "2017-07-04 10:00"},
+		{pushOperation, "game/server", "v2", "alice", "2017-08-10 10:00"},
+		{pullOperation, "game/server", "v2", "bob", "2017-09-01 10:00"},
+		{pushOperation, "game/server", "v3", "alice", "2017-09-11 10:00"},
+		//September cohort
+		{pullOperation, "game/client", "v1", "dave", "2017-09-15 10:00"},
+		//After the end of the period
+		{pullOperation, "game/client", "v1", "dave", "2017-10-02 10:00"},
+	})
+
+	params := GetUserRetentionCohortsParameters{NumberOfMonths: 2}
+	params.SetStartDate(testTime("2017-07-01"))
+	params.SetEndDate(testTime("2017-10-01"))
+
+	got := registry.GetUserRetentionCohorts(&params)
+
+	nan := math.NaN()
+	want := []struct {
+		firstActiveMonth string
+		userCount        int
+		retention        []float64
+	}{
+		{"2017-07-01", 2, []float64{1, 0.5, 1}},
+		//Months after the period are unknown
+		{"2017-09-01", 1, []float64{1, nan, nan}},
+	}
+
+	if len(got.data) != len(want) {
+		t.Fatalf("got %d cohorts, want %d", len(got.data), len(want))
+	}
+	for idx, cohort := range got.data {
+		if !cohort.firstActiveMonth.Equal(testTime(want[idx].firstActiveMonth)) || cohort.userCount != want[idx].userCount {
+			t.Errorf("cohort %d is %s with %d users, want %s with %d users",
+				idx, cohort.firstActiveMonth, cohort.userCount, want[idx].firstActiveMonth, want[idx].userCount)
+		}
+		if len(cohort.retention) != len(want[idx].retention) {
+			t.Errorf("cohort %d has retention %v, want %v", idx, cohort.retention, want[idx].retention)
+			continue
+		}
+		for monthOffset, retention := range cohort.retention {
+			wantRetention := want[idx].retention[monthOffset]
+			if retention != wantRetention && !(math.IsNaN(retention) && math.IsNaN(wantRetention)) {
+				t.Errorf("cohort %d has retention %v, want %v", idx, cohort.retention, want[idx].retention)
+				break
+			}
+		}
+	}
+
+	heatMapValues := got.GetHeatMapValues()
+	if len(heatMapValues.RowLabels) != len(want) || len(heatMapValues.ColumnLabels) != params.NumberOfMonths+1 {
+		t.Errorf("heat map has row labels %v and column labels %v", heatMapValues.RowLabels, heatMapValues.ColumnLabels)
+	}
+
+}
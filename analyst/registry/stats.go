@@ -6,14 +6,244 @@
 
 package registry
 
-import "time"
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+)
 
 /*StatsMethodParameters is an interface type that needs to be implemented
 by every type that is to be used as a method/function parameter of a
 StatsMethod i.e. a method that is used to process registry data to generate
-statistical output.*/
+statistical output.
+The exported fields of such a type are its configurable parameters.
+They are described by a "doc" tag and, if limited to certain values,
+an "enum" tag listing these (see configreader.GetStatsMethodDocs).
+The period a stats method analyses is given by the Period and
+the projects, repositories and users it takes into account are
+selected by the Filter, both embedded in the parameters struct.*/
 type StatsMethodParameters interface {
 	SetStartDate(time.Time)
 	StartDate() time.Time
+	SetEndDate(time.Time)
+	EndDate() time.Time
 	IsValid() (bool, string)
 }
+
+/*Period is the period a stats method analyses, starting at the startDate
+(inclusive) and ending at the endDate (exclusive), see isWithinPeriod.
+It is embedded in the parameters struct of every stats method and
+provides the methods of the StatsMethodParameters interface to set and
+get both dates, which the configreader sets from the period parameters
+of a charts item. A zero startDate stands for a period without beginning.*/
+type Period struct {
+	startDate time.Time
+	endDate   time.Time
+}
+
+/*SetStartDate sets the startDate of the Period.
+This method is required by the StatsMethodParameters interface.*/
+func (p *Period) SetStartDate(startDate time.Time) {
+	log.Printf("\nPeriod.SetStartDate to %s", startDate)
+	p.startDate = startDate
+}
+
+/*StartDate returns the startDate of the Period.
+This method is required by the StatsMethodParameters interface.*/
+func (p *Period) StartDate() time.Time {
+	return p.startDate
+}
+
+/*SetEndDate sets the endDate of the Period.
+This method is required by the StatsMethodParameters interface.*/
+func (p *Period) SetEndDate(endDate time.Time) {
+	log.Printf("\nPeriod.SetEndDate to %s", endDate)
+	p.endDate = endDate
+}
+
+/*EndDate returns the endDate of the Period.
+This method is required by the StatsMethodParameters interface.*/
+func (p *Period) EndDate() time.Time {
+	return p.endDate
+}
+
+/*isWithinPeriod checks whether the given timestamp lies within the period
+of the given parameters, i.e. not before their startDate and before their endDate.*/
+func isWithinPeriod(timestamp time.Time, params StatsMethodParameters) bool {
+	return !timestamp.Before(params.StartDate()) && timestamp.Before(params.EndDate())
+}
+
+const (
+	pushOperation = "push"
+	pullOperation = "pull"
+	anyOperation  = "any"
+)
+
+/*isValidOperation checks whether the given operation is one of
+the operations that stats methods accept as a parameter
+(i.e. "push", "pull" or "any").*/
+func isValidOperation(operation string) bool {
+	return operation == pushOperation || operation == pullOperation || operation == anyOperation
+}
+
+/*getLogsByOperation returns the logs of all pushes to, all pulls of
+or both pushes to and pulls of the given tag,
+depending on the given operation (i.e. "push", "pull" or "any").*/
+func getLogsByOperation(tag *Tag, operation string) []Log {
+
+	var logs []Log
+
+	if operation == pushOperation || operation == anyOperation {
+		for _, push := range tag.Pushes {
+			logs = append(logs, push.Log)
+		}
+	}
+
+	if operation == pullOperation || operation == anyOperation {
+		for _, pull := range tag.Pulls {
+			logs = append(logs, pull.Log)
+		}
+	}
+
+	return logs
+
+}
+
+const (
+	dayInterval   = "day"
+	weekInterval  = "week"
+	monthInterval = "month"
+)
+
+/*isValidInterval checks whether the given interval is one of
+the intervals that stats methods accept as a parameter
+(i.e. "day", "week" or "month").*/
+func isValidInterval(interval string) bool {
+	return interval == dayInterval || interval == weekInterval || interval == monthInterval
+}
+
+/*truncateToInterval returns the beginning of the day, the ISO week
+(i.e. Monday) or the month (depending on the given interval)
+the given time lies in.*/
+func truncateToInterval(timestamp time.Time, interval string) time.Time {
+
+	day := time.Date(timestamp.Year(), timestamp.Month(), timestamp.Day(), 0, 0, 0, 0, timestamp.Location())
+
+	switch interval {
+	case weekInterval:
+		//time.Weekday starts counting on Sunday
+		daysSinceMonday := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -1*daysSinceMonday)
+	case monthInterval:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+
+	return day
+
+}
+
+/*getLastIntervalStart returns the beginning of the last day, ISO week
+or month (depending on the given interval) before the given endDate,
+i.e. of the last interval which overlaps with a period ending at endDate.*/
+func getLastIntervalStart(endDate time.Time, interval string) time.Time {
+	return truncateToInterval(endDate.Add(-1*time.Nanosecond), interval)
+}
+
+/*addInterval adds one day, week or month (depending on the given
+interval) to the given time.*/
+func addInterval(timestamp time.Time, interval string) time.Time {
+
+	switch interval {
+	case weekInterval:
+		return timestamp.AddDate(0, 0, 7)
+	case monthInterval:
+		return timestamp.AddDate(0, 1, 0)
+	}
+
+	return timestamp.AddDate(0, 0, 1)
+
+}
+
+const (
+	repositoryGroupBy = "repository"
+	userGroupBy       = "user"
+	teamGroupBy       = "team"
+)
+
+/*isValidGroupBy checks whether the given grouping is one of
+the groupings that stats methods accept as a parameter
+(i.e. "repository", "user" or "team", see registry.TeamMapping).*/
+func isValidGroupBy(groupBy string) bool {
+	return groupBy == repositoryGroupBy || groupBy == userGroupBy || groupBy == teamGroupBy
+}
+
+/*getPreviousPeriodStartDate returns the start date of the period
+which directly precedes the period between the given startDate and endDate
+and which is of the same length.
+e.g. if the period is the last week, the previous period
+started two weeks ago.
+The startDate must not be the zero time (see the IsValid methods
+of the parameters with CompareWithPreviousPeriod).*/
+func getPreviousPeriodStartDate(startDate time.Time, endDate time.Time) time.Time {
+	return startDate.Add(-1 * endDate.Sub(startDate))
+}
+
+/*formatDelta returns the given difference with an explicit sign
+e.g. "+3", "-2" or "±0".*/
+func formatDelta(delta int) string {
+	if delta > 0 {
+		return fmt.Sprintf("+%d", delta)
+	}
+	if delta == 0 {
+		return "±0"
+	}
+	return fmt.Sprintf("%d", delta)
+}
+
+/*percentileDuration returns the given percentile (between 0 and 100)
+of the given durations, which must be sorted ascendingly,
+using the nearest-rank method.*/
+func percentileDuration(sortedDurations []time.Duration, percentile float64) time.Duration {
+	if len(sortedDurations) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100 * float64(len(sortedDurations))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sortedDurations[rank-1]
+}
+
+/*formatDuration returns a short human-readable representation
+of the given duration, e.g. "2d 4h", "3h 20m" or "45s".*/
+func formatDuration(duration time.Duration) string {
+	days := int(duration.Hours()) / 24
+	hours := int(duration.Hours()) % 24
+	minutes := int(duration.Minutes()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%ds", int(duration.Seconds()))
+}
+
+/*median returns the median of the given values
+without changing the order of the given slice.*/
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sortedValues := append([]float64{}, values...)
+	sort.Float64s(sortedValues)
+	middle := len(sortedValues) / 2
+	if len(sortedValues)%2 == 0 {
+		return (sortedValues[middle-1] + sortedValues[middle]) / 2
+	}
+	return sortedValues[middle]
+}
@@ -0,0 +1,74 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/demonware/harbor-analytics/analyst/outputgen"
+)
+
+/*StatsMethod describes a statistical method of the Registry type
+which can be referenced by its name in the analyst.yaml config file.
+
+NewParameters returns a new, empty parameters struct (as a pointer)
+which the configuration is then applied to. Compute calls the concrete
+statistical method with these parameters, which it converts to the
+concrete parameters type expected by the method.
+Every stats method registers itself by calling RegisterStatsMethod
+in the init function of its file.*/
+type StatsMethod struct {
+	Name          string
+	Description   string
+	NewParameters func() StatsMethodParameters
+	Compute       func(registry *Registry, params StatsMethodParameters) outputgen.Chartable
+}
+
+/*statsMethods holds all registered stats methods mapped to their names.*/
+var statsMethods = map[string]StatsMethod{}
+
+/*RegisterStatsMethod makes the given stats method available under its name.
+It panics if the stats method is incomplete or if a stats method
+of the same name has already been registered, as both are
+programming errors which must not go unnoticed.*/
+func RegisterStatsMethod(statsMethod StatsMethod) {
+	if statsMethod.Name == "" || statsMethod.NewParameters == nil || statsMethod.Compute == nil {
+		panic(fmt.Sprintf("stats method %q is incomplete", statsMethod.Name))
+	}
+	if _, isRegistered := statsMethods[statsMethod.Name]; isRegistered {
+		panic(fmt.Sprintf("stats method %q is registered twice", statsMethod.Name))
+	}
+	statsMethods[statsMethod.Name] = statsMethod
+}
+
+/*GetStatsMethods returns all registered stats methods ordered by their name.*/
+func GetStatsMethods() []StatsMethod {
+	var allStatsMethods []StatsMethod
+	for _, statsMethod := range statsMethods {
+		allStatsMethods = append(allStatsMethods, statsMethod)
+	}
+	sort.Slice(allStatsMethods, func(idxA, idxB int) bool {
+		return allStatsMethods[idxA].Name < allStatsMethods[idxB].Name
+	})
+	return allStatsMethods
+}
+
+/*LookupStatsMethod returns the registered stats method with the given name.
+If there is none, the returned error lists the names of all registered ones.*/
+func LookupStatsMethod(name string) (StatsMethod, error) {
+	statsMethod, isRegistered := statsMethods[name]
+	if isRegistered {
+		return statsMethod, nil
+	}
+	var names []string
+	for _, statsMethod := range GetStatsMethods() {
+		names = append(names, statsMethod.Name)
+	}
+	return StatsMethod{}, fmt.Errorf("unknown stats method \"%s\", must be one of: %s", name, strings.Join(names, ", "))
+}
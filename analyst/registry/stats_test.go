@@ -0,0 +1,167 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTruncateToInterval(t *testing.T) {
+
+	testCases := []struct {
+		timestamp string
+		interval  string
+		want      string
+	}{
+		{"2017-10-11 13:45", dayInterval, "2017-10-11"},
+		//2017-10-11 is a Wednesday
+		{"2017-10-11 13:45", weekInterval, "2017-10-09"},
+		{"2017-10-09 00:00", weekInterval, "2017-10-09"},
+		//Sundays belong to the week starting the Monday before
+		{"2017-10-15 23:59", weekInterval, "2017-10-09"},
+		{"2017-10-01 13:45", weekInterval, "2017-09-25"},
+		{"2017-10-11 13:45", monthInterval, "2017-10-01"},
+		{"2017-12-31 23:59", monthInterval, "2017-12-01"},
+	}
+
+	for _, testCase := range testCases {
+		got := truncateToInterval(testTime(testCase.timestamp), testCase.interval)
+		if !got.Equal(testTime(testCase.want)) {
+			t.Errorf("truncateToInterval(%s, %s) = %s, want %s", testCase.timestamp, testCase.interval, got, testCase.want)
+		}
+	}
+
+}
+
+func TestGetLastIntervalStart(t *testing.T) {
+
+	testCases := []struct {
+		endDate  string
+		interval string
+		want     string
+	}{
+		//The endDate itself is not part of the period
+		{"2017-10-11", dayInterval, "2017-10-10"},
+		{"2017-10-11 12:00", dayInterval, "2017-10-11"},
+		{"2017-10-16", weekInterval, "2017-10-09"},
+		{"2017-10-17", weekInterval, "2017-10-16"},
+		{"2017-11-01", monthInterval, "2017-10-01"},
+	}
+
+	for _, testCase := range testCases {
+		got := getLastIntervalStart(testTime(testCase.endDate), testCase.interval)
+		if !got.Equal(testTime(testCase.want)) {
+			t.Errorf("getLastIntervalStart(%s, %s) = %s, want %s", testCase.endDate, testCase.interval, got, testCase.want)
+		}
+	}
+
+}
+
+func TestAddInterval(t *testing.T) {
+
+	testCases := []struct {
+		timestamp string
+		interval  string
+		want      string
+	}{
+		{"2017-12-31", dayInterval, "2018-01-01"},
+		{"2017-12-25", weekInterval, "2018-01-01"},
+		{"2017-12-01", monthInterval, "2018-01-01"},
+	}
+
+	for _, testCase := range testCases {
+		got := addInterval(testTime(testCase.timestamp), testCase.interval)
+		if !got.Equal(testTime(testCase.want)) {
+			t.Errorf("addInterval(%s, %s) = %s, want %s", testCase.timestamp, testCase.interval, got, testCase.want)
+		}
+	}
+
+}
+
+func TestPercentileDuration(t *testing.T) {
+
+	durations := []time.Duration{1 * time.Minute, 2 * time.Minute, 3 * time.Minute, 4 * time.Minute, 10 * time.Minute}
+
+	testCases := []struct {
+		durations  []time.Duration
+		percentile float64
+		want       time.Duration
+	}{
+		{nil, 50, 0},
+		{durations[:1], 90, 1 * time.Minute},
+		{durations, 0, 1 * time.Minute},
+		{durations, 20, 1 * time.Minute},
+		{durations, 21, 2 * time.Minute},
+		{durations, 50, 3 * time.Minute},
+		{durations, 90, 10 * time.Minute},
+		{durations, 100, 10 * time.Minute},
+		{durations[:4], 50, 2 * time.Minute},
+	}
+
+	for _, testCase := range testCases {
+		got := percentileDuration(testCase.durations, testCase.percentile)
+		if got != testCase.want {
+			t.Errorf("percentileDuration(%v, %v) = %s, want %s", testCase.durations, testCase.percentile, got, testCase.want)
+		}
+	}
+
+}
+
+func TestFormatDuration(t *testing.T) {
+
+	testCases := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{0, "0s"},
+		{45 * time.Second, "45s"},
+		{3*time.Minute + 20*time.Second, "3m"},
+		{3*time.Hour + 20*time.Minute, "3h 20m"},
+		{52*time.Hour + 30*time.Minute, "2d 4h"},
+	}
+
+	for _, testCase := range testCases {
+		if got := formatDuration(testCase.duration); got != testCase.want {
+			t.Errorf("formatDuration(%s) = %s, want %s", testCase.duration, got, testCase.want)
+		}
+	}
+
+}
+
+func TestCompareWithPreviousPeriodIsValid(t *testing.T) {
+
+	testCases := []struct {
+		name      string
+		compare   bool
+		startDate string
+		want      bool
+	}{
+		{"no comparison", false, "", true},
+		{"comparison with a start date", true, "2017-10-02", true},
+		{"comparison without a start date", true, "", false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			repositoriesParams := GetMostPushedToRepositoriesParameters{MaxNumberOfElements: 5, CompareWithPreviousPeriod: testCase.compare, GroupBy: repositoryGroupBy}
+			usersParams := GetMostPushingUsersParameters{MaxNumberOfElements: 5, CompareWithPreviousPeriod: testCase.compare}
+			for _, params := range []StatsMethodParameters{&repositoriesParams, &usersParams} {
+				if testCase.startDate != "" {
+					params.SetStartDate(testTime(testCase.startDate))
+				}
+				params.SetEndDate(testTime("2017-10-09"))
+				if got, reason := params.IsValid(); got != testCase.want {
+					t.Errorf("%T.IsValid() = %t (%s), want %t", params, got, reason, testCase.want)
+				}
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,150 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+)
+
+/*unassignedTeam is the name of the team that all repositories
+and users without a team of their own are aggregated under.*/
+const unassignedTeam = "unassigned"
+
+/*Team maps projects, repositories and users of the registry to
+the team with the given name.
+Projects are given by their name, repositories by patterns like the
+entries of a Filter matching their full name (e.g. "coreapp/*", "web/front*"
+or "^web/(front|back)end$") and users by their name.*/
+type Team struct {
+	Name               string
+	Projects           []string
+	RepositoryPatterns []string
+	Users              []string
+}
+
+/*TeamMapping configures which team the projects, repositories
+and users of the registry belong to.
+
+An access (i.e. a push or pull) is attributed to the team owning the
+repository it was performed on. A repository is owned by the first team
+with a repository pattern matching its name or, if there is none,
+by the first team listing its project. Accesses on repositories
+not owned by any team are attributed to the team of the user
+performing them or, if there is none either, to the "unassigned" team.*/
+type TeamMapping struct {
+	Teams []Team
+}
+
+/*IsValid checks whether all fields in the TeamMapping
+have a valid value. If not valid, false and a reason string is returned.*/
+func (t TeamMapping) IsValid() (bool, string) {
+	teamNames := map[string]bool{}
+	for _, team := range t.Teams {
+		if team.Name == "" {
+			return false, "a team has no name"
+		}
+		if team.Name == unassignedTeam {
+			return false, fmt.Sprintf("team name \"%s\" is reserved", unassignedTeam)
+		}
+		if teamNames[team.Name] {
+			return false, fmt.Sprintf("team \"%s\" is defined more than once", team.Name)
+		}
+		teamNames[team.Name] = true
+		for _, pattern := range team.RepositoryPatterns {
+			if _, err := compileNamePattern(pattern); err != nil {
+				return false, fmt.Sprintf("RepositoryPatterns entry \"%s\" of team \"%s\" is not a valid pattern: %v", pattern, team.Name, err)
+			}
+		}
+	}
+	return true, ""
+}
+
+/*compiledTeamMapping is a TeamMapping prepared for being applied in the loops
+of a stats method: its repository patterns are compiled once and the team
+owning a repository is remembered per name.*/
+type compiledTeamMapping struct {
+	TeamMapping
+	//the compiled repository patterns of every team (in the order of the teams)
+	repositoryPatterns [][]namePattern
+
+	teamOfRepository map[string]string
+}
+
+/*compile prepares the TeamMapping for being applied, see compiledTeamMapping.
+Invalid repository patterns are left out, the TeamMapping is expected
+to be checked with IsValid beforehand.*/
+func (t TeamMapping) compile() *compiledTeamMapping {
+	compiled := &compiledTeamMapping{
+		TeamMapping:      t,
+		teamOfRepository: map[string]string{},
+	}
+	for _, team := range t.Teams {
+		compiled.repositoryPatterns = append(compiled.repositoryPatterns, compileNamePatterns(team.RepositoryPatterns))
+	}
+	return compiled
+}
+
+/*getTeamOfRepository returns the name of the team owning the repository
+with the given name within the project with the given name.
+If no team owns the repository, false is returned.*/
+func (c *compiledTeamMapping) getTeamOfRepository(projectName string, repositoryName string) (string, bool) {
+	teamName, isKnown := c.teamOfRepository[repositoryName]
+	if !isKnown {
+		teamName = c.findTeamOfRepository(projectName, repositoryName)
+		c.teamOfRepository[repositoryName] = teamName
+	}
+	return teamName, teamName != ""
+}
+
+/*findTeamOfRepository looks up the name of the team owning the repository
+with the given name within the project with the given name,
+which is empty if no team owns the repository.*/
+func (c *compiledTeamMapping) findTeamOfRepository(projectName string, repositoryName string) string {
+	for idx, team := range c.Teams {
+		if matchesAny(c.repositoryPatterns[idx], repositoryName) {
+			return team.Name
+		}
+	}
+	for _, team := range c.Teams {
+		for _, teamProjectName := range team.Projects {
+			if teamProjectName == projectName {
+				return team.Name
+			}
+		}
+	}
+	return ""
+}
+
+/*getTeamOfUser returns the name of the first team
+listing the user with the given name.
+If no team lists the user, false is returned.*/
+func (t TeamMapping) getTeamOfUser(userName string) (string, bool) {
+	for _, team := range t.Teams {
+		for _, teamUserName := range team.Users {
+			if teamUserName == userName {
+				return team.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+/*getTeamOfAccess returns the name of the team an access on the given
+repository within the given project by the given user is attributed to.
+The user may be nil if unknown.
+See TeamMapping for how the team is determined.*/
+func (c *compiledTeamMapping) getTeamOfAccess(projectName string, repositoryName string, user *User) string {
+	if teamName, isOwned := c.getTeamOfRepository(projectName, repositoryName); isOwned {
+		return teamName
+	}
+	if user != nil {
+		if teamName, isListed := c.getTeamOfUser(user.Name); isListed {
+			return teamName
+		}
+	}
+	return unassignedTeam
+}
@@ -0,0 +1,168 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"log"
+)
+
+const (
+	humanUserClass      = "human"
+	automationUserClass = "automation"
+	anyUserClass        = "any"
+)
+
+/*userClasses are all classes a user can be classified as,
+in the order in which they are shown when a stat is split by user class.*/
+var userClasses = []string{humanUserClass, automationUserClass}
+
+/*UserClassification configures how users of the registry are
+classified as either human or automation (i.e. robot or CI) accounts.
+
+A user is classified as automation if their name matches any of the
+AutomationNamePatterns (patterns like the entries of a Filter, e.g. "robot$*",
+"ci-*" or "^robot\$") or if one of the heuristics applies to their pushes
+over the whole history:
+ - AutomationMinPushesPerDay: the user performed at least this number
+   of pushes per day on average over the days they pushed at all.
+ - AutomationMinHoursOfDay: the user pushed in at least this number
+   of distinct hours (0 to 23) of a single day i.e. around the clock.
+   Busy developers push in many distinct hours over time,
+   but hardly ever in most of the hours of one day.
+A heuristic with a value of zero is disabled.
+All other users are classified as human.*/
+type UserClassification struct {
+	AutomationNamePatterns    []string
+	AutomationMinPushesPerDay int
+	AutomationMinHoursOfDay   int
+}
+
+/*IsValid checks whether all fields in the UserClassification
+have a valid value. If not valid, false and a reason string is returned.*/
+func (u UserClassification) IsValid() (bool, string) {
+	for _, pattern := range u.AutomationNamePatterns {
+		if _, err := compileNamePattern(pattern); err != nil {
+			return false, fmt.Sprintf("AutomationNamePatterns entry \"%s\" is not a valid pattern: %v", pattern, err)
+		}
+	}
+	if u.AutomationMinPushesPerDay < 0 {
+		return false, "AutomationMinPushesPerDay is less than zero"
+	}
+	if u.AutomationMinHoursOfDay < 0 || u.AutomationMinHoursOfDay > 24 {
+		return false, "AutomationMinHoursOfDay is not between 0 and 24"
+	}
+	return true, ""
+}
+
+/*UserClassSelection restricts the users a stats method takes into account
+to "human" or "automation" users ("any" or empty for all users, see UserClassification).
+It is embedded in the parameters struct of every stats method supporting it,
+so its field is configured like any other parameter.*/
+type UserClassSelection struct {
+	UserClass string `doc:"Only count users of this class, one of human, automation or any (empty for any)" enum:",human,automation,any"`
+}
+
+/*IsValid checks whether the UserClass is one of the user classes
+that stats methods accept (i.e. "human", "automation", "any" or empty).
+If not valid, false and a reason string is returned.*/
+func (u UserClassSelection) IsValid() (bool, string) {
+	switch u.UserClass {
+	case "", anyUserClass, humanUserClass, automationUserClass:
+		return true, ""
+	}
+	return false, fmt.Sprintf("UserClass \"%s\" is not one of human, automation or any", u.UserClass)
+}
+
+/*isOfUserClass checks whether the user with the given name belongs to the
+given user class (which may be "any" or empty to match all users),
+according to the given mapping of user names to their class.*/
+func isOfUserClass(classPerUser map[string]string, userName string, userClass string) bool {
+	if userClass == "" || userClass == anyUserClass {
+		return true
+	}
+	return classPerUser[userName] == userClass
+}
+
+/*getClassPerUser classifies every user who has ever pushed or pulled
+as either human or automation according to the UserClassification
+of the registry and returns the classes mapped to the user names.*/
+func (registry *Registry) getClassPerUser() map[string]string {
+
+	if isValid, reason := registry.UserClassification.IsValid(); !isValid {
+		log.Fatalf("\nUserClassification is invalid :: %s", reason)
+	}
+
+	pushesPerUser := map[string]int{}
+	//The hours of the day in which a user pushed, per day
+	pushHoursPerDayPerUser := map[string]map[string]map[int]bool{}
+
+	//Users who only pull are classified as well
+	//(e.g. robot accounts used for deployments)
+	for _, project := range registry.Projects {
+		for _, repository := range project.Repositories {
+			for _, tag := range repository.Tags {
+				for _, pull := range tag.Pulls {
+					if pull.User == nil {
+						continue
+					}
+					if _, ok := pushesPerUser[pull.User.Name]; !ok {
+						pushesPerUser[pull.User.Name] = 0
+					}
+				}
+				for _, push := range tag.Pushes {
+					if push.User == nil {
+						continue
+					}
+					userName := push.User.Name
+					pushesPerUser[userName]++
+					if _, ok := pushHoursPerDayPerUser[userName]; !ok {
+						pushHoursPerDayPerUser[userName] = map[string]map[int]bool{}
+					}
+					day := push.Timestamp.Format("2006-01-02")
+					if _, ok := pushHoursPerDayPerUser[userName][day]; !ok {
+						pushHoursPerDayPerUser[userName][day] = map[int]bool{}
+					}
+					pushHoursPerDayPerUser[userName][day][push.Timestamp.Hour()] = true
+				}
+			}
+		}
+	}
+
+	classification := registry.UserClassification
+	automationNamePatterns := compileNamePatterns(classification.AutomationNamePatterns)
+	classPerUser := map[string]string{}
+	for userName, pushCount := range pushesPerUser {
+
+		maxPushHoursOfDay := 0
+		for _, pushHours := range pushHoursPerDayPerUser[userName] {
+			if len(pushHours) > maxPushHoursOfDay {
+				maxPushHoursOfDay = len(pushHours)
+			}
+		}
+
+		userClass := humanUserClass
+		switch {
+		case matchesAny(automationNamePatterns, userName):
+			userClass = automationUserClass
+		case classification.AutomationMinPushesPerDay > 0 && pushCount > 0 &&
+			pushCount >= classification.AutomationMinPushesPerDay*len(pushHoursPerDayPerUser[userName]):
+			userClass = automationUserClass
+		case classification.AutomationMinHoursOfDay > 0 &&
+			maxPushHoursOfDay >= classification.AutomationMinHoursOfDay:
+			userClass = automationUserClass
+		}
+
+		if userClass == automationUserClass {
+			log.Printf("\nClassify user %s as %s.", userName, userClass)
+		}
+		classPerUser[userName] = userClass
+	}
+
+	return classPerUser
+
+}
@@ -0,0 +1,92 @@
+// Copyright (C) Activision Publishing, Inc. 2017
+// https://github.com/Demonware/harbor-analytics
+// Author: David Rieger
+// Licensed under the 3-Clause BSD License (the "License");
+// you may not use this file except in compliance with the License.
+
+package registry
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestGetClassPerUser(t *testing.T) {
+
+	var accesses []testAccess
+	for hour := 0; hour < 24; hour++ {
+		//A busy developer pushing at every hour of the day
+		//over time, but only a few times per day
+		accesses = append(accesses, testAccess{pushOperation, "game/server", "v1", "alice",
+			fmt.Sprintf("2017-10-%02d %02d:15", hour+1, hour)})
+		//A nightly build pushing around the clock on a single day
+		accesses = append(accesses, testAccess{pushOperation, "game/server", "nightly", "builder",
+			fmt.Sprintf("2017-10-02 %02d:00", hour)})
+	}
+	for minute := 0; minute < 10; minute++ {
+		//Many pushes on a single day
+		accesses = append(accesses, testAccess{pushOperation, "game/client", "latest", "deployer",
+			fmt.Sprintf("2017-10-03 10:%02d", minute)})
+	}
+	accesses = append(accesses,
+		testAccess{pushOperation, "game/client", "v1", "ci-game", "2017-10-04 10:00"},
+		testAccess{pullOperation, "game/client", "v1", "bob", "2017-10-04 11:00"},
+	)
+
+	testCases := []struct {
+		name           string
+		classification UserClassification
+		want           map[string]string
+	}{
+		{
+			name: "no heuristics",
+			want: map[string]string{
+				"alice":    humanUserClass,
+				"builder":  humanUserClass,
+				"deployer": humanUserClass,
+				"ci-game":  humanUserClass,
+				"bob":      humanUserClass,
+			},
+		},
+		{
+			name: "name patterns and heuristics",
+			classification: UserClassification{
+				AutomationNamePatterns:    []string{"ci-*", "^bui"},
+				AutomationMinPushesPerDay: 10,
+				AutomationMinHoursOfDay:   20,
+			},
+			want: map[string]string{
+				"alice":    humanUserClass,
+				"builder":  automationUserClass,
+				"deployer": automationUserClass,
+				"ci-game":  automationUserClass,
+				"bob":      humanUserClass,
+			},
+		},
+		{
+			name: "regular expression",
+			classification: UserClassification{
+				AutomationNamePatterns: []string{"^(ci|bui)"},
+			},
+			want: map[string]string{
+				"alice":    humanUserClass,
+				"builder":  automationUserClass,
+				"deployer": humanUserClass,
+				"ci-game":  automationUserClass,
+				"bob":      humanUserClass,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			registry := newTestRegistry(accesses)
+			registry.UserClassification = testCase.classification
+			if got := registry.getClassPerUser(); !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("getClassPerUser() = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+
+}
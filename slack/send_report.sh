@@ -14,4 +14,18 @@ then
     exit 1
 fi
 
-curl -F file=@../out/report.pdf -F channels="#channel" -F token=$TOKEN https://slack.com/api/files.upload
+# The analyst lists every report and slack channel to publish it to
+# as "<file name> <channel>" in publish.txt. Without any such
+# publishing targets, there is nothing to post.
+PUBLISH_LIST=../out/publish.txt
+
+if [ ! -s $PUBLISH_LIST ]
+then
+    echo "No report has any slack channels to publish to in $PUBLISH_LIST. Nothing to do."
+    exit 0
+fi
+
+while read FILE CHANNEL
+do
+    curl -F file=@../out/$FILE -F channels="$CHANNEL" -F token=$TOKEN https://slack.com/api/files.upload || exit 1
+done < $PUBLISH_LIST